@@ -38,6 +38,11 @@ var dao bool
 var pre string
 var df string
 var env string
+var dryRun bool
+var migration bool
+var migrationDir string
+var migrationName string
+var drop bool
 
 // ddlCmd represents the ddl command
 var ddlCmd = &cobra.Command{
@@ -46,27 +51,48 @@ var ddlCmd = &cobra.Command{
 	Long: ``,
 	Run: func(cmd *cobra.Command, args []string) {
 		var err error
-		if env, err = pathutils.FixPath(env, ".env"); err != nil {
+		conf := loadDbConfig()
+		if dir, err = pathutils.FixPath(dir, "domain"); err != nil {
 			logrus.Panicln(err)
 		}
-		if _, err = os.Stat(env); err == nil {
-			if err = godotenv.Load(env); err != nil {
-				logrus.Panicln("Error loading .env file", err)
-			}
-		}
-		if dir, err = pathutils.FixPath(dir, "domain"); err != nil {
+		if migrationDir, err = pathutils.FixPath(migrationDir, "migrations"); err != nil {
 			logrus.Panicln(err)
 		}
-		var conf config.DbConfig
-		err = envconfig.Process("db", &conf)
-		if err != nil {
-			logrus.Panicln("Error processing env", err)
+		d := ddl.Ddl{
+			Dir:           dir,
+			Reverse:       reverse,
+			Dao:           dao,
+			Pre:           pre,
+			Df:            df,
+			Conf:          conf,
+			DryRun:        dryRun,
+			Migrate:       migration,
+			MigrationDir:  migrationDir,
+			MigrationName: migrationName,
+			Drop:          drop,
 		}
-		d := ddl.Ddl{dir, reverse, dao, pre, df, conf}
 		d.Exec()
 	},
 }
 
+func loadDbConfig() config.DbConfig {
+	var err error
+	if env, err = pathutils.FixPath(env, ".env"); err != nil {
+		logrus.Panicln(err)
+	}
+	if _, err = os.Stat(env); err == nil {
+		if err = godotenv.Load(env); err != nil {
+			logrus.Panicln("Error loading .env file", err)
+		}
+	}
+	var conf config.DbConfig
+	err = envconfig.Process("db", &conf)
+	if err != nil {
+		logrus.Panicln("Error processing env", err)
+	}
+	return conf
+}
+
 func init() {
 	rootCmd.AddCommand(ddlCmd)
 
@@ -81,7 +107,12 @@ func init() {
 	ddlCmd.Flags().StringVar(&dir, "domain", "domain", "Path of domain folder.")
 	ddlCmd.Flags().StringVar(&pre, "pre", "", "Table name prefix. e.g.: prefix biz_ for biz_product.")
 	ddlCmd.Flags().StringVar(&df, "df", "dao", "Name of dao folder.")
-	ddlCmd.Flags().StringVar(&env, "env", ".env", "Path of database connection config .env file")
+	ddlCmd.PersistentFlags().StringVar(&env, "env", ".env", "Path of database connection config .env file")
+	ddlCmd.PersistentFlags().StringVar(&migrationDir, "migrations", "migrations", "Path of migration files folder.")
 	ddlCmd.Flags().BoolVarP(&reverse, "reverse", "r", false, "If true, generate domain code from database. If false, update or create database tables from domain code.")
 	ddlCmd.Flags().BoolVarP(&dao, "dao", "d", false, "If true, generate dao code.")
+	ddlCmd.Flags().BoolVar(&dryRun, "dry-run", false, "If true, print schema changes between domain code and database without applying them.")
+	ddlCmd.Flags().BoolVarP(&migration, "migrate", "m", false, "If true, write schema changes to versioned up and down migration files instead of applying them.")
+	ddlCmd.Flags().StringVar(&migrationName, "name", "migration", "Name of migration files written by --migrate.")
+	ddlCmd.Flags().BoolVar(&drop, "drop", false, "If true, drop columns which are removed from domain code when updating existing tables.")
 }
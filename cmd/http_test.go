@@ -139,6 +139,7 @@ func (receiver *TestfileshttpcmdClient) PageUsers(ctx context.Context, query vo.
 		_req.SetFormDataFromValues(_urlValues)
 	}
 	_resp, _err := _req.Post(_server + "/testfileshttpcmd/pageusers")
+	ddhttp.ReportResult(receiver.provider, _server, _resp, _err)
 	if _err != nil {
 		msg = errors.Wrap(_err, "")
 		return
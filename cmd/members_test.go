@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unionj-cloud/go-doudou/svc/config"
+	"github.com/unionj-cloud/go-doudou/svc/registry"
+)
+
+func TestMembersCmd(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	memport := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	os.Setenv(config.GddName.String(), "memberscmd")
+	os.Setenv(config.GddMemPort.String(), fmt.Sprint(memport))
+	os.Setenv(config.GddMemJoinRetry.String(), "1")
+	os.Setenv(config.GddMemJoinBackoff.String(), "10ms")
+	defer os.Unsetenv(config.GddName.String())
+	defer os.Unsetenv(config.GddMemPort.String())
+	defer os.Unsetenv(config.GddMemJoinRetry.String())
+	defer os.Unsetenv(config.GddMemJoinBackoff.String())
+	defer os.Unsetenv(config.GddSeed.String())
+	node, err := registry.NewNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Shutdown()
+
+	dir, err := ioutil.TempDir("", "memberscmd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// unreachable seed is covered by svc.TestSvc_Members, which doesn't need the containers of TestMain
+	assert.NotPanics(t, func() {
+		ExecuteCommandC(rootCmd, []string{"svc", "members", dir, "--seed", fmt.Sprintf("127.0.0.1:%d", memport)}...)
+	})
+}
@@ -0,0 +1,58 @@
+/*
+Copyright © 2021 wubin1989 <328454505@qq.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/unionj-cloud/go-doudou/ddl"
+	"github.com/unionj-cloud/go-doudou/pathutils"
+)
+
+var steps int
+
+// migrateCmd represents the ddl migrate command
+var migrateCmd = &cobra.Command{
+	Use:       "migrate [up|down|status]",
+	Short:     "apply or revert migration files written by ddl --migrate, or print their status",
+	Long:      `applied migrations are recorded in schema_migrations table. up applies all pending migrations, down reverts the last applied one.`,
+	Args:      cobra.ExactValidArgs(1),
+	ValidArgs: []string{"up", "down", "status"},
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		conf := loadDbConfig()
+		if migrationDir, err = pathutils.FixPath(migrationDir, "migrations"); err != nil {
+			logrus.Panicln(err)
+		}
+		d := ddl.Ddl{
+			Conf:         conf,
+			MigrationDir: migrationDir,
+		}
+		d.RunMigration(args[0], steps)
+	},
+}
+
+func init() {
+	ddlCmd.AddCommand(migrateCmd)
+
+	migrateCmd.Flags().IntVarP(&steps, "steps", "n", 0, "Number of migrations to apply or revert, 0 means all pending ones for up and the last one for down.")
+}
@@ -0,0 +1,76 @@
+/*
+Copyright © 2021 wubin1989 <328454505@qq.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/unionj-cloud/go-doudou/ddl"
+	"github.com/unionj-cloud/go-doudou/pathutils"
+)
+
+var truncate bool
+var upsert bool
+
+// seedCmd represents the ddl seed command
+var seedCmd = &cobra.Command{
+	Use:   "seed [fixture files or folders]",
+	Short: "load yaml or json fixture files keyed by domain struct name into database",
+	Long:  `tables are seeded in foreign key dependency order in one transaction. fixtures folder is loaded if no file or folder is given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		conf := loadDbConfig()
+		if dir, err = pathutils.FixPath(dir, "domain"); err != nil {
+			logrus.Panicln(err)
+		}
+		if len(args) == 0 {
+			args = []string{"fixtures"}
+		}
+		for i := range args {
+			if args[i], err = pathutils.FixPath(args[i], "fixtures"); err != nil {
+				logrus.Panicln(err)
+			}
+		}
+		var options []ddl.SeedOption
+		if truncate {
+			options = append(options, ddl.WithTruncate())
+		}
+		if upsert {
+			options = append(options, ddl.WithUpsert())
+		}
+		d := ddl.Ddl{
+			Dir:  dir,
+			Pre:  pre,
+			Conf: conf,
+		}
+		d.Seed(args, options...)
+	},
+}
+
+func init() {
+	ddlCmd.AddCommand(seedCmd)
+
+	seedCmd.Flags().StringVar(&dir, "domain", "domain", "Path of domain folder.")
+	seedCmd.Flags().StringVar(&pre, "pre", "", "Table name prefix. e.g.: prefix biz_ for biz_product.")
+	seedCmd.Flags().BoolVarP(&truncate, "truncate", "t", false, "If true, delete all rows of seeded tables before seeding.")
+	seedCmd.Flags().BoolVarP(&upsert, "upsert", "u", false, "If true, upsert rows instead of inserting them.")
+}
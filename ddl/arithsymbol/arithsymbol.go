@@ -12,4 +12,9 @@ const (
 	Is  ArithSymbol = "is"
 	Not ArithSymbol = "is not"
 	In  ArithSymbol = "in"
+
+	NotIn   ArithSymbol = "not in"
+	Like    ArithSymbol = "like"
+	NotLike ArithSymbol = "not like"
+	Between ArithSymbol = "between"
 )
@@ -0,0 +1,51 @@
+package ddl
+
+import "reflect"
+
+// MaxBatchBytes limits bytes of bind arguments of a multi-row statement executed by generated dao, so that the
+// statement fits in max_allowed_packet of MySQL, which is 4MB by default before MySQL 8.0
+var MaxBatchBytes = 4 << 20
+
+// Chunks splits bind arguments of rows into chunks for multi-row statements. Each chunk has no more than maxParams
+// arguments and MaxBatchBytes bytes of arguments approximately, a row exceeding the limits alone makes a chunk.
+func Chunks(rows [][]interface{}, maxParams int) [][][]interface{} {
+	var (
+		chunks [][][]interface{}
+		start  int
+		params int
+		size   int
+	)
+	for i, row := range rows {
+		rowSize := argsSize(row)
+		if i > start && (params+len(row) > maxParams || size+rowSize > MaxBatchBytes) {
+			chunks = append(chunks, rows[start:i])
+			start, params, size = i, 0, 0
+		}
+		params += len(row)
+		size += rowSize
+	}
+	if start < len(rows) {
+		chunks = append(chunks, rows[start:])
+	}
+	return chunks
+}
+
+// argsSize estimates bytes of args sent to database, strings and byte slices count their length, others count 8
+func argsSize(args []interface{}) int {
+	var size int
+	for _, arg := range args {
+		v := reflect.ValueOf(arg)
+		for v.Kind() == reflect.Ptr && !v.IsNil() {
+			v = v.Elem()
+		}
+		switch {
+		case v.Kind() == reflect.String:
+			size += v.Len()
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+			size += v.Len()
+		default:
+			size += 8
+		}
+	}
+	return size
+}
@@ -0,0 +1,61 @@
+package ddl
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestChunks(t *testing.T) {
+	defer func(max int) {
+		MaxBatchBytes = max
+	}(MaxBatchBytes)
+	MaxBatchBytes = 100
+	long := strings.Repeat("a", 60)
+	tests := []struct {
+		name      string
+		rows      [][]interface{}
+		maxParams int
+		want      []int
+	}{
+		{
+			name:      "empty",
+			maxParams: 10,
+		},
+		{
+			name:      "one chunk",
+			rows:      [][]interface{}{{1, "a"}, {2, "b"}},
+			maxParams: 4,
+			want:      []int{2},
+		},
+		{
+			name:      "by params",
+			rows:      [][]interface{}{{1, "a"}, {2, "b"}, {3, "c"}},
+			maxParams: 4,
+			want:      []int{2, 1},
+		},
+		{
+			name:      "by bytes",
+			rows:      [][]interface{}{{1, long}, {2, &long}, {3, []byte(long)}},
+			maxParams: 100,
+			want:      []int{1, 1, 1},
+		},
+		{
+			name:      "row over limit",
+			rows:      [][]interface{}{{1, long + long}, {2, "b"}},
+			maxParams: 1,
+			want:      []int{1, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, chunk := range Chunks(tt.rows, tt.maxParams) {
+				got = append(got, len(chunk))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Chunks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package ddl
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores results of queries for CachedDB. LRUCache is an in-process implementation, implement it by redis etc.
+// to share results among instances.
+type Cache interface {
+	// Get returns value of key, ok is false if key doesn't exist or has expired
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set sets value of key which expires after ttl, non positive ttl means never
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+type cacheConfig struct {
+	prefix   string
+	ttl      time.Duration
+	tableTTL map[string]time.Duration
+}
+
+type CacheOption func(*cacheConfig)
+
+// WithTTL sets ttl of cached results, default 1 minute
+func WithTTL(ttl time.Duration) CacheOption {
+	return func(conf *cacheConfig) {
+		conf.ttl = ttl
+	}
+}
+
+// WithTableTTL sets ttl of cached results of table, which overrides WithTTL
+func WithTableTTL(table string, ttl time.Duration) CacheOption {
+	return func(conf *cacheConfig) {
+		conf.tableTTL[table] = ttl
+	}
+}
+
+// WithKeyPrefix sets prefix of cache keys, default "ddl:"
+func WithKeyPrefix(prefix string) CacheOption {
+	return func(conf *cacheConfig) {
+		conf.prefix = prefix
+	}
+}
+
+// CachedDB decorates a DB by caching results of GetContext and SelectContext in Cache, keyed by tables and query.
+// Results of a query are cached under every table it references, including joined tables and tables of subqueries,
+// e.g. Exists and In of query package, so they are invalidated when any of the tables is written. Tables are compared
+// without schema and quotes, so `test`.`user` and user are the same table. Cached results of a table are invalidated
+// after statements writing it are run through CachedDB, or transactions begun by CachedDB are committed, so daos
+// writing a table should share a CachedDB. Queries referencing no table and queries through ctx returned by
+// WithPrimary are not cached. Concurrent cache misses of the same query run the query only once.
+type CachedDB struct {
+	DB
+	cache Cache
+	conf  *cacheConfig
+	group *singleflight.Group
+}
+
+// NewCachedDB decorates db by cache and options
+func NewCachedDB(db DB, cache Cache, options ...CacheOption) CachedDB {
+	conf := &cacheConfig{
+		prefix:   "ddl:",
+		ttl:      time.Minute,
+		tableTTL: make(map[string]time.Duration),
+	}
+	for _, opt := range options {
+		opt(conf)
+	}
+	return CachedDB{db, cache, conf, &singleflight.Group{}}
+}
+
+// ttl returns the shortest ttl of tables
+func (c CachedDB) ttl(tables []string) time.Duration {
+	var result time.Duration
+	for i, table := range tables {
+		ttl, ok := c.conf.tableTTL[table]
+		if !ok {
+			ttl = c.conf.ttl
+		}
+		if i == 0 || (ttl > 0 && (result <= 0 || ttl < result)) {
+			result = ttl
+		}
+	}
+	return result
+}
+
+func (c CachedDB) generationKey(table string) string {
+	return c.conf.prefix + table + ":gen"
+}
+
+// generation returns current generation of table, which is a part of cache keys of table
+func (c CachedDB) generation(ctx context.Context, table string) (string, error) {
+	value, ok, err := c.cache.Get(ctx, c.generationKey(table))
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return string(value), nil
+	}
+	// generation may be evicted, a new one never hits stale results
+	return c.invalidate(ctx, table)
+}
+
+// invalidate makes cached results of table unreachable by changing its generation
+func (c CachedDB) invalidate(ctx context.Context, table string) (string, error) {
+	gen := strconv.FormatInt(time.Now().UnixNano(), 36) + strconv.FormatInt(rand.Int63(), 36)
+	if err := c.cache.Set(ctx, c.generationKey(table), []byte(gen), 0); err != nil {
+		return "", err
+	}
+	return gen, nil
+}
+
+func (c CachedDB) invalidateAll(tables ...string) {
+	for _, table := range tables {
+		// the write has been done, so cache is invalidated even if ctx of it is canceled
+		if _, err := c.invalidate(context.Background(), table); err != nil {
+			logrus.Errorf("failed to invalidate cache of table %s: %v", table, err)
+		}
+	}
+}
+
+// generations returns current generations of tables joined by comma
+func (c CachedDB) generations(ctx context.Context, tables []string) (string, error) {
+	gens := make([]string, len(tables))
+	for i, table := range tables {
+		gen, err := c.generation(ctx, table)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get generation of table %s", table)
+		}
+		gens[i] = gen
+	}
+	return strings.Join(gens, ","), nil
+}
+
+func (c CachedDB) key(tables []string, gen, query string, args []interface{}) string {
+	h := sha1.New()
+	h.Write([]byte(query))
+	for _, arg := range args {
+		if valuer, ok := arg.(driver.Valuer); ok {
+			if value, err := valuer.Value(); err == nil {
+				arg = value
+			}
+		}
+		v := reflect.ValueOf(arg)
+		for v.Kind() == reflect.Ptr && !v.IsNil() {
+			v = v.Elem()
+		}
+		if v.IsValid() {
+			arg = v.Interface()
+		}
+		fmt.Fprintf(h, "\x00%T:%v", arg, arg)
+	}
+	return c.conf.prefix + strings.Join(tables, ",") + ":" + gen + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+type readFunc func(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+
+func (c CachedDB) read(ctx context.Context, dest interface{}, query string, args []interface{}, read readFunc) error {
+	verb, tables := statementTables(query)
+	if verb != "select" {
+		// e.g. insert statement with returning clause
+		err := read(ctx, dest, query, args...)
+		if err == nil && len(tables) > 0 {
+			c.invalidateAll(tables[0])
+		}
+		return err
+	}
+	if len(tables) == 0 || PrimaryForced(ctx) {
+		return read(ctx, dest, query, args...)
+	}
+	gen, err := c.generations(ctx, tables)
+	if err != nil {
+		logrus.Warnf("failed to get generations from cache: %v", err)
+		return read(ctx, dest, query, args...)
+	}
+	key := c.key(tables, gen, query, args)
+	if value, ok, err := c.cache.Get(ctx, key); err != nil {
+		logrus.Warnf("failed to get %s from cache: %v", key, err)
+	} else if ok {
+		if err = gob.NewDecoder(bytes.NewReader(value)).Decode(dest); err == nil {
+			return nil
+		}
+		logrus.Warnf("failed to decode cached result of %s: %v", key, err)
+	}
+	var leader bool
+	value, err, _ := c.group.Do(key, func() (interface{}, error) {
+		leader = true
+		if err := read(ctx, dest, query, args...); err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(dest); err != nil {
+			logrus.Warnf("failed to encode result of %s: %v", key, err)
+			return nil, nil
+		}
+		if err := c.cache.Set(ctx, key, buf.Bytes(), c.ttl(tables)); err != nil {
+			logrus.Warnf("failed to set %s to cache: %v", key, err)
+		}
+		return buf.Bytes(), nil
+	})
+	if err != nil || leader {
+		return err
+	}
+	if value == nil {
+		// result of the leader can't be shared
+		return read(ctx, dest, query, args...)
+	}
+	return errors.Wrap(gob.NewDecoder(bytes.NewReader(value.([]byte))).Decode(dest), "failed to decode shared result")
+}
+
+func (c CachedDB) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return c.read(ctx, dest, query, args, c.DB.GetContext)
+}
+
+func (c CachedDB) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return c.read(ctx, dest, query, args, c.DB.SelectContext)
+}
+
+func (c CachedDB) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	result, err := c.DB.NamedExecContext(ctx, query, arg)
+	if err == nil {
+		if _, table := parseStatement(query); table != "" {
+			c.invalidateAll(table)
+		}
+	}
+	return result, err
+}
+
+func (c CachedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	result, err := c.DB.ExecContext(ctx, query, args...)
+	if err == nil {
+		if _, table := parseStatement(query); table != "" {
+			c.invalidateAll(table)
+		}
+	}
+	return result, err
+}
+
+// BeginTxx begins a transaction, which reads from db directly and invalidates cached results of tables written by it
+// after it is committed
+func (c CachedDB) BeginTxx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	tx, err := c.DB.BeginTxx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &cachedTx{Tx: tx, db: c, tables: make(map[string]struct{})}, nil
+}
+
+// DriverName returns driver name of the decorated db if any, RunInTx decides whether to retry transactions by it
+func (c CachedDB) DriverName() string {
+	if named, ok := c.DB.(interface{ DriverName() string }); ok {
+		return named.DriverName()
+	}
+	return ""
+}
+
+type cachedTx struct {
+	Tx
+	db     CachedDB
+	mu     sync.Mutex
+	tables map[string]struct{}
+}
+
+// written records table written by query
+func (t *cachedTx) written(query string) {
+	if verb, table := parseStatement(query); verb != "select" && table != "" {
+		t.mu.Lock()
+		t.tables[table] = struct{}{}
+		t.mu.Unlock()
+	}
+}
+
+func (t *cachedTx) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	t.written(query)
+	return t.Tx.NamedExecContext(ctx, query, arg)
+}
+
+func (t *cachedTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	t.written(query)
+	return t.Tx.ExecContext(ctx, query, args...)
+}
+
+func (t *cachedTx) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	t.written(query)
+	return t.Tx.GetContext(ctx, dest, query, args...)
+}
+
+func (t *cachedTx) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	t.written(query)
+	return t.Tx.SelectContext(ctx, dest, query, args...)
+}
+
+func (t *cachedTx) Commit() error {
+	if err := t.Tx.Commit(); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for table := range t.tables {
+		t.db.invalidateAll(table)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package ddl
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countedDB counts reads run on db
+type countedDB struct {
+	DB
+	reads int32
+	delay time.Duration
+}
+
+func (c *countedDB) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	atomic.AddInt32(&c.reads, 1)
+	time.Sleep(c.delay)
+	return c.DB.SelectContext(ctx, dest, query, args...)
+}
+
+func selectNames(t *testing.T, ctx context.Context, q Querier) []string {
+	var result []string
+	if err := q.SelectContext(ctx, &result, "select name from item where name <> ? order by name", "x"); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestCachedDB(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   []CacheOption
+		run       func(t *testing.T, ctx context.Context, c CachedDB)
+		want      []string
+		wantReads int32
+	}{
+		{
+			name:      "hit",
+			run:       func(t *testing.T, ctx context.Context, c CachedDB) {},
+			want:      []string{"a"},
+			wantReads: 1,
+		},
+		{
+			name: "invalidated by write",
+			run: func(t *testing.T, ctx context.Context, c CachedDB) {
+				if err := insert(ctx, c, "b"); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want:      []string{"a", "b"},
+			wantReads: 2,
+		},
+		{
+			name: "invalidated by commit",
+			run: func(t *testing.T, ctx context.Context, c CachedDB) {
+				err := RunInTx(ctx, c, func(ctx context.Context, tx Tx) error {
+					if err := insert(ctx, c, "b"); err != nil {
+						return err
+					}
+					if got := selectNames(t, context.Background(), c); !reflect.DeepEqual(got, []string{"a"}) {
+						t.Errorf("cached result before commit = %v", got)
+					}
+					return nil
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+			want:      []string{"a", "b"},
+			wantReads: 2,
+		},
+		{
+			name: "primary forced",
+			run: func(t *testing.T, ctx context.Context, c CachedDB) {
+				selectNames(t, WithPrimary(ctx), c)
+			},
+			want:      []string{"a"},
+			wantReads: 2,
+		},
+		{
+			name:    "expired",
+			options: []CacheOption{WithTableTTL("item", time.Millisecond)},
+			run: func(t *testing.T, ctx context.Context, c CachedDB) {
+				time.Sleep(10 * time.Millisecond)
+			},
+			want:      []string{"a"},
+			wantReads: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := &countedDB{DB: newTestDB(t)}
+			c := NewCachedDB(db, NewLRUCache(100), tt.options...)
+			if err := insert(ctx, c, "a"); err != nil {
+				t.Fatal(err)
+			}
+			selectNames(t, ctx, c)
+			tt.run(t, ctx, c)
+			if got := selectNames(t, ctx, c); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SelectContext() = %v, want %v", got, tt.want)
+			}
+			if got := atomic.LoadInt32(&db.reads); got != tt.wantReads {
+				t.Errorf("reads = %v, want %v", got, tt.wantReads)
+			}
+		})
+	}
+}
+
+// TestCachedDB_Tables runs statements shaped like those of generated daos, which qualify tables by schema in insert,
+// update and upsert statements but not in select and delete statements
+func TestCachedDB_Tables(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		write     string
+		want      []string
+		wantReads int32
+	}{
+		{
+			name:      "qualified insert",
+			query:     "select * from `item` where `name` <> ? order by name",
+			write:     "INSERT INTO `main`.`item` (`name`) VALUES ('b')",
+			want:      []string{"a", "b"},
+			wantReads: 2,
+		},
+		{
+			name:      "qualified update",
+			query:     "select count(1) from `item` where `name` <> ?",
+			write:     "UPDATE `main`.`item` SET `name` = 'x' where `name` = 'a'",
+			want:      []string{"0"},
+			wantReads: 2,
+		},
+		{
+			name:      "delete",
+			query:     "select * from \"main\".\"item\" where name <> ?",
+			write:     "delete from `item` where `name` = 'a';",
+			want:      nil,
+			wantReads: 2,
+		},
+		{
+			name:      "exists",
+			query:     "select * from `item` where `name` <> ? and exists (select 1 from `tag` where tag.name = item.name)",
+			write:     "INSERT INTO `main`.`tag` (`name`) VALUES ('a')",
+			want:      []string{"a"},
+			wantReads: 2,
+		},
+		{
+			name:      "join",
+			query:     "select i.name from `item` i join tag t on t.name = i.name where i.name <> ?",
+			write:     "insert into tag (name) values ('a')",
+			want:      []string{"a"},
+			wantReads: 2,
+		},
+		{
+			name:      "other table",
+			query:     "select * from `item` where `name` <> ?",
+			write:     "insert into tag (name) values ('a')",
+			want:      []string{"a"},
+			wantReads: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := &countedDB{DB: newTestDB(t)}
+			db.DB.(*GddDB).MustExec("create table tag (name varchar(255))")
+			c := NewCachedDB(db, NewLRUCache(100))
+			if err := insert(ctx, c, "a"); err != nil {
+				t.Fatal(err)
+			}
+			var result []string
+			if err := c.SelectContext(ctx, &result, tt.query, "x"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := c.ExecContext(ctx, tt.write); err != nil {
+				t.Fatal(err)
+			}
+			result = nil
+			if err := c.SelectContext(ctx, &result, tt.query, "x"); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(result, tt.want) {
+				t.Errorf("SelectContext() = %v, want %v", result, tt.want)
+			}
+			if got := atomic.LoadInt32(&db.reads); got != tt.wantReads {
+				t.Errorf("reads = %v, want %v", got, tt.wantReads)
+			}
+		})
+	}
+}
+
+func TestCachedDB_Singleflight(t *testing.T) {
+	ctx := context.Background()
+	db := &countedDB{DB: newTestDB(t), delay: 100 * time.Millisecond}
+	c := NewCachedDB(db, NewLRUCache(100))
+	if err := insert(ctx, c, "a"); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var result []string
+			if err := c.SelectContext(ctx, &result, "select name from item"); err != nil {
+				t.Error(err)
+			} else if !reflect.DeepEqual(result, []string{"a"}) {
+				t.Errorf("SelectContext() = %v", result)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := atomic.LoadInt32(&db.reads); got != 1 {
+		t.Errorf("reads = %v, want 1", got)
+	}
+}
+
+func TestCachedDB_key(t *testing.T) {
+	c := NewCachedDB(nil, nil)
+	a, b := "a", "a"
+	tables := []string{"t"}
+	if c.key(tables, "1", "q", []interface{}{&a}) != c.key(tables, "1", "q", []interface{}{&b}) {
+		t.Error("keys of pointers to equal values should be equal")
+	}
+	if c.key(tables, "1", "q", []interface{}{1}) == c.key(tables, "1", "q", []interface{}{"1"}) {
+		t.Error("keys of args of different types should be different")
+	}
+}
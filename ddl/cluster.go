@@ -0,0 +1,190 @@
+package ddl
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type primaryKey struct{}
+
+// WithPrimary returns a copy of ctx which routes reads of ClusterDB to the primary database, e.g. reading rows just
+// written before they are replicated
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// PrimaryForced reports whether ctx is returned by WithPrimary
+func PrimaryForced(ctx context.Context) bool {
+	forced, _ := ctx.Value(primaryKey{}).(bool)
+	return forced
+}
+
+type replica struct {
+	db      *sqlx.DB
+	healthy int32
+}
+
+// ClusterDB is a DB of a primary database and its read replicas. GetContext, SelectContext and QueryxContext are
+// routed to healthy replicas by round robin, other statements and transactions are run on the primary. Reads fall
+// back to the primary if ctx is returned by WithPrimary or no replica is healthy. Replicas are pinged periodically
+// to check health.
+type ClusterDB struct {
+	primary  *sqlx.DB
+	replicas []*replica
+	next     uint32
+	interval time.Duration
+	timeout  time.Duration
+	stop     chan struct{}
+	once     sync.Once
+}
+
+type ClusterOption func(*ClusterDB)
+
+// WithHealthCheck sets interval and timeout of pinging replicas, default 5 seconds and 1 second.
+// Non positive interval disables health check, non positive timeout keeps the default.
+func WithHealthCheck(interval, timeout time.Duration) ClusterOption {
+	return func(c *ClusterDB) {
+		c.interval = interval
+		if timeout > 0 {
+			c.timeout = timeout
+		}
+	}
+}
+
+// NewClusterDB creates a ClusterDB and starts health check of replicas, which is stopped by Close
+func NewClusterDB(primary *sqlx.DB, replicas []*sqlx.DB, options ...ClusterOption) *ClusterDB {
+	c := &ClusterDB{
+		primary:  primary,
+		interval: 5 * time.Second,
+		timeout:  time.Second,
+		stop:     make(chan struct{}),
+	}
+	for _, db := range replicas {
+		c.replicas = append(c.replicas, &replica{db: db, healthy: 1})
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+	if c.interval > 0 && len(c.replicas) > 0 {
+		go c.healthCheck()
+	}
+	return c
+}
+
+func (c *ClusterDB) healthCheck() {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.check()
+		}
+	}
+}
+
+// check pings all replicas and marks them healthy or not
+func (c *ClusterDB) check() {
+	for i, r := range c.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		err := r.db.PingContext(ctx)
+		cancel()
+		var healthy int32
+		if err == nil {
+			healthy = 1
+		}
+		if atomic.SwapInt32(&r.healthy, healthy) != healthy {
+			if err != nil {
+				logrus.Warnf("replica %d is down: %v", i, err)
+			} else {
+				logrus.Infof("replica %d is up", i)
+			}
+		}
+	}
+}
+
+// reader returns the database which reads through ctx are routed to
+func (c *ClusterDB) reader(ctx context.Context) *sqlx.DB {
+	if len(c.replicas) == 0 || PrimaryForced(ctx) {
+		return c.primary
+	}
+	n := uint32(len(c.replicas))
+	start := atomic.AddUint32(&c.next, 1)
+	for i := uint32(0); i < n; i++ {
+		r := c.replicas[(start+i)%n]
+		if atomic.LoadInt32(&r.healthy) == 1 {
+			return r.db
+		}
+	}
+	return c.primary
+}
+
+// Primary returns the primary database
+func (c *ClusterDB) Primary() *sqlx.DB {
+	return c.primary
+}
+
+// DriverName returns driver name of the primary database, RunInTx decides whether to retry transactions by it
+func (c *ClusterDB) DriverName() string {
+	return c.primary.DriverName()
+}
+
+func (c *ClusterDB) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	return c.primary.NamedExecContext(ctx, query, arg)
+}
+
+func (c *ClusterDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.primary.ExecContext(ctx, query, args...)
+}
+
+func (c *ClusterDB) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return c.reader(ctx).GetContext(ctx, dest, query, args...)
+}
+
+func (c *ClusterDB) Rebind(query string) string {
+	return c.primary.Rebind(query)
+}
+
+func (c *ClusterDB) BindNamed(query string, arg interface{}) (string, []interface{}, error) {
+	return c.primary.BindNamed(query, arg)
+}
+
+func (c *ClusterDB) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return c.reader(ctx).SelectContext(ctx, dest, query, args...)
+}
+
+func (c *ClusterDB) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	return c.reader(ctx).QueryxContext(ctx, query, args...)
+}
+
+func (c *ClusterDB) BeginTxx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	tx, err := c.primary.BeginTxx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return GddTx{tx}, nil
+}
+
+// Close stops health check and closes all databases
+func (c *ClusterDB) Close() error {
+	c.once.Do(func() {
+		close(c.stop)
+	})
+	var result error
+	for _, r := range c.replicas {
+		if err := r.db.Close(); err != nil {
+			result = err
+		}
+	}
+	if err := c.primary.Close(); err != nil {
+		result = err
+	}
+	return errors.Wrap(result, "failed to close database")
+}
@@ -0,0 +1,120 @@
+package ddl
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newNamedDB(t *testing.T, name string) *sqlx.DB {
+	db, err := sqlx.Connect("sqlite3", filepath.Join(t.TempDir(), name+".db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+	db.MustExec("create table item (name varchar(255))")
+	db.MustExec("insert into item (name) values (?)", name)
+	return db
+}
+
+func TestClusterDB(t *testing.T) {
+	tests := []struct {
+		name     string
+		replicas []string
+		down     []int
+		ctx      context.Context
+		want     []string
+	}{
+		{
+			name: "no replica",
+			ctx:  context.Background(),
+			want: []string{"primary", "primary", "primary"},
+		},
+		{
+			name:     "round robin",
+			replicas: []string{"r0", "r1"},
+			ctx:      context.Background(),
+			want:     []string{"r1", "r0", "r1"},
+		},
+		{
+			name:     "primary forced",
+			replicas: []string{"r0", "r1"},
+			ctx:      WithPrimary(context.Background()),
+			want:     []string{"primary", "primary", "primary"},
+		},
+		{
+			name:     "skip unhealthy",
+			replicas: []string{"r0", "r1"},
+			down:     []int{1},
+			ctx:      context.Background(),
+			want:     []string{"r0", "r0", "r0"},
+		},
+		{
+			name:     "all unhealthy",
+			replicas: []string{"r0", "r1"},
+			down:     []int{0, 1},
+			ctx:      context.Background(),
+			want:     []string{"primary", "primary", "primary"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var replicas []*sqlx.DB
+			for _, name := range tt.replicas {
+				replicas = append(replicas, newNamedDB(t, name))
+			}
+			c := NewClusterDB(newNamedDB(t, "primary"), replicas, WithHealthCheck(0, 0))
+			for _, i := range tt.down {
+				replicas[i].Close()
+			}
+			c.check()
+			var got []string
+			for i := 0; i < 3; i++ {
+				var name string
+				if err := c.GetContext(tt.ctx, &name, "select name from item limit 1"); err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reads = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClusterDB_Write(t *testing.T) {
+	primary := newNamedDB(t, "primary")
+	c := NewClusterDB(primary, []*sqlx.DB{newNamedDB(t, "r0")}, WithHealthCheck(0, 0))
+	ctx := context.Background()
+	if _, err := c.ExecContext(ctx, "insert into item (name) values (?)", "a"); err != nil {
+		t.Fatal(err)
+	}
+	err := RunInTx(ctx, c, func(ctx context.Context, tx Tx) error {
+		return insert(ctx, c, "b")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	if err = c.SelectContext(WithPrimary(ctx), &got, "select name from item order by name"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b", "primary"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("primary = %v, want %v", got, want)
+	}
+	got = nil
+	if err = c.SelectContext(ctx, &got, "select name from item order by name"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"r0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("replica = %v, want %v", got, want)
+	}
+	if err = c.Close(); err != nil {
+		t.Error(err)
+	}
+}
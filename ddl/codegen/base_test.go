@@ -41,19 +41,20 @@ import (
 	"github.com/unionj-cloud/go-doudou/ddl/query"
 )
 
+// Base declares dao methods whose signatures don't depend on domain type, typed methods are declared by each dao
 type Base interface {
-	Insert(ctx context.Context, data interface{}) (int64, error)
-	Upsert(ctx context.Context, data interface{}) (int64, error)
-	UpsertNoneZero(ctx context.Context, data interface{}) (int64, error)
+	// DeleteMany sets deletion time of rows if the domain has a softdelete field, deletes them otherwise
 	DeleteMany(ctx context.Context, where query.Q) (int64, error)
-	Update(ctx context.Context, data interface{}) (int64, error)
-	UpdateNoneZero(ctx context.Context, data interface{}) (int64, error)
-	UpdateMany(ctx context.Context, data interface{}, where query.Q) (int64, error)
-	UpdateManyNoneZero(ctx context.Context, data interface{}, where query.Q) (int64, error)
-	Get(ctx context.Context, id interface{}) (interface{}, error)
-	SelectMany(ctx context.Context, where ...query.Q) (interface{}, error)
+	// ForceDeleteMany deletes rows even if the domain has a softdelete field
+	ForceDeleteMany(ctx context.Context, where query.Q) (int64, error)
 	CountMany(ctx context.Context, where ...query.Q) (int, error)
-	PageMany(ctx context.Context, page query.Page, where ...query.Q) (query.PageRet, error)
+	// SelectManyCols selects columns of sel into dest, a pointer to slice of structs with fields for the columns,
+	// or to slice of values if only one column is selected
+	SelectManyCols(ctx context.Context, sel query.Sel, dest interface{}, where ...query.Q) error
+	// SelectMaps selects columns of sel as maps keyed by column name
+	SelectMaps(ctx context.Context, sel query.Sel, where ...query.Q) ([]map[string]interface{}, error)
+	// PageManyCols is like SelectManyCols but selects a page, Items of the result is the slice dest points to
+	PageManyCols(ctx context.Context, sel query.Sel, page query.Page, dest interface{}, where ...query.Q) (query.PageRet, error)
 }
 `
 			basefile := dir + "/dao/base.go"
@@ -3,6 +3,7 @@ package codegen
 import (
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"github.com/unionj-cloud/go-doudou/astutils"
 	"github.com/unionj-cloud/go-doudou/ddl/table"
 	"github.com/unionj-cloud/go-doudou/pathutils"
 	"os"
@@ -11,6 +12,40 @@ import (
 	"text/template"
 )
 
+// relation is a foreign key column of the table. Generated dao loads rows of the table for a set of referenced ids,
+// one row per id if the column has a unique index of its own (has-one), many rows otherwise (has-many).
+type relation struct {
+	Field  astutils.FieldMeta
+	Column string
+	// KeyType is type of the field without pointer
+	KeyType  string
+	Nullable bool
+	HasOne   bool
+}
+
+func relations(t table.Table) []relation {
+	var result []relation
+	for _, col := range t.Columns {
+		if col.Fk == nil {
+			continue
+		}
+		r := relation{
+			Field:    col.Meta,
+			Column:   col.Name,
+			KeyType:  strings.TrimPrefix(col.Meta.Type, "*"),
+			Nullable: strings.HasPrefix(col.Meta.Type, "*"),
+		}
+		for _, idx := range t.Indexes {
+			if idx.Unique && len(idx.Items) == 1 && idx.Items[0].Column == col.Name {
+				r.HasOne = true
+				break
+			}
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
 func GenDaoGo(domainpath string, t table.Table, folder ...string) error {
 	var (
 		err     error
@@ -37,10 +72,25 @@ func GenDaoGo(domainpath string, t table.Table, folder ...string) error {
 		if tpl, err = template.New("dao.go.tmpl").ParseFiles(pathutils.Abs("dao.go.tmpl")); err != nil {
 			return errors.Wrap(err, "error")
 		}
+		var pkColumn table.Column
+		for _, column := range t.Columns {
+			if column.Pk {
+				pkColumn = column
+				break
+			}
+		}
 		if err = tpl.Execute(f, struct {
-			DomainName string
+			DomainPackage string
+			DomainName    string
+			TableName     string
+			PkField       astutils.FieldMeta
+			Relations     []relation
 		}{
-			DomainName: t.Meta.Name,
+			DomainPackage: astutils.GetImportPath(domainpath),
+			DomainName:    t.Meta.Name,
+			TableName:     t.Name,
+			PkField:       pkColumn.Meta,
+			Relations:     relations(t),
 		}); err != nil {
 			return errors.Wrap(err, "error")
 		}
@@ -4,6 +4,10 @@ import (
 	"github.com/iancoleman/strcase"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"github.com/unionj-cloud/go-doudou/ddl/dialect"
+	_ "github.com/unionj-cloud/go-doudou/ddl/dialect/mysql"
+	_ "github.com/unionj-cloud/go-doudou/ddl/dialect/postgres"
+	_ "github.com/unionj-cloud/go-doudou/ddl/dialect/sqlite"
 	"github.com/unionj-cloud/go-doudou/ddl/table"
 	"github.com/unionj-cloud/go-doudou/pathutils"
 	"os"
@@ -12,22 +16,28 @@ import (
 	"text/template"
 )
 
+// GenDaoSql generates sql templates of dao, sql dialect is decided by DB_DRIVER environment variable
 func GenDaoSql(domainpath string, t table.Table, folder ...string) error {
 	var (
 		err      error
+		dia      dialect.Dialect
 		tplpath  string
 		daopath  string
 		f        *os.File
 		funcMap  map[string]interface{}
 		tpl      *template.Template
-		iColumns []table.Column
 		uColumns []table.Column
+		oColumns []table.Column
+		vColumn  *table.Column
 		df       string
 	)
 	df = "dao"
 	if len(folder) > 0 {
 		df = folder[0]
 	}
+	if dia, err = dialect.Get(os.Getenv("DB_DRIVER")); err != nil {
+		return errors.Wrap(err, "error")
+	}
 	daopath = filepath.Join(filepath.Dir(domainpath), df)
 	if err = os.MkdirAll(daopath, os.ModePerm); err != nil {
 		return errors.Wrap(err, "error")
@@ -43,19 +53,12 @@ func GenDaoSql(domainpath string, t table.Table, folder ...string) error {
 		tplpath = pathutils.Abs("dao.sql.tmpl")
 		funcMap = make(map[string]interface{})
 		funcMap["ToSnake"] = strcase.ToSnake
+		funcMap["quote"] = dia.Quote
+		funcMap["excluded"] = dia.Excluded
 		if tpl, err = template.New("dao.sql.tmpl").Funcs(funcMap).ParseFiles(tplpath); err != nil {
 			return errors.Wrap(err, "error")
 		}
 
-		for _, co := range t.Columns {
-			if !co.AutoSet {
-				iColumns = append(iColumns, co)
-			}
-			if !co.AutoSet && !co.Pk {
-				uColumns = append(uColumns, co)
-			}
-		}
-
 		var pkColumn table.Column
 		for _, co := range t.Columns {
 			if co.Pk {
@@ -64,20 +67,44 @@ func GenDaoSql(domainpath string, t table.Table, folder ...string) error {
 			}
 		}
 
+		for i, co := range t.Columns {
+			if co.Version && vColumn == nil {
+				vColumn = &t.Columns[i]
+			}
+			if !co.AutoSet && !co.Pk && !co.CreatedBy && !co.Version {
+				uColumns = append(uColumns, co)
+			}
+			if onUpdate(co) {
+				oColumns = append(oColumns, co)
+			}
+		}
+
 		if err = tpl.Execute(f, struct {
 			Schema        string
 			TableName     string
+			Table         string
 			DomainName    string
 			InsertColumns []table.Column
 			UpdateColumns []table.Column
-			Pk            table.Column
+			// OnUpdateColumns are set to current time by update statements
+			OnUpdateColumns []table.Column
+			// Version is increased by update statements, and checked by update statements by primary key
+			Version   *table.Column
+			Pk        table.Column
+			Upsert    string
+			Returning string
 		}{
-			Schema:        os.Getenv("DB_SCHEMA"),
-			TableName:     t.Name,
-			DomainName:    t.Meta.Name,
-			InsertColumns: iColumns,
-			UpdateColumns: uColumns,
-			Pk:            pkColumn,
+			Schema:          os.Getenv("DB_SCHEMA"),
+			TableName:       t.Name,
+			Table:           dia.QualifiedTable(os.Getenv("DB_SCHEMA"), t.Name),
+			DomainName:      t.Meta.Name,
+			InsertColumns:   insertColumns(t),
+			UpdateColumns:   uColumns,
+			OnUpdateColumns: oColumns,
+			Version:         vColumn,
+			Pk:              pkColumn,
+			Upsert:          dia.Upsert(pkColumn.Name),
+			Returning:       dia.Returning(pkColumn.Name),
 		}); err != nil {
 			return errors.Wrap(err, "error")
 		}
@@ -86,3 +113,22 @@ func GenDaoSql(domainpath string, t table.Table, folder ...string) error {
 	}
 	return nil
 }
+
+// insertColumns returns columns set by insert statements, zero autoincrement primary key is left out from insert
+// statement to get value generated
+func insertColumns(t table.Table) []table.Column {
+	var iColumns []table.Column
+	for _, co := range t.Columns {
+		if !co.AutoSet && !(co.Pk && co.Autoincrement) {
+			iColumns = append(iColumns, co)
+		}
+	}
+	return iColumns
+}
+
+// onUpdate reports whether co is a timestamp column updated automatically, e.g.
+// dd:"default:CURRENT_TIMESTAMP;extra:on update CURRENT_TIMESTAMP". Only mysql supports the extra, so generated update
+// statements set these columns explicitly for every dialect.
+func onUpdate(co table.Column) bool {
+	return co.AutoSet && strings.Contains(strings.ToLower(string(co.Extra)), "on update")
+}
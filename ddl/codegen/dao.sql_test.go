@@ -1,17 +1,23 @@
 package codegen
 
 import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
 	"github.com/sirupsen/logrus"
 	"github.com/unionj-cloud/go-doudou/astutils"
 	"github.com/unionj-cloud/go-doudou/ddl/ddlast"
 	"github.com/unionj-cloud/go-doudou/ddl/table"
 	"github.com/unionj-cloud/go-doudou/pathutils"
+	"github.com/unionj-cloud/go-doudou/templateutils"
 	"go/ast"
 	"go/parser"
 	"go/token"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestGenDaoSql(t *testing.T) {
@@ -81,3 +87,74 @@ func TestGenDaoSql(t *testing.T) {
 		})
 	}
 }
+
+// argUser has fields of User with embedded Base flattened, Where is used by UpdateUsers blocks
+type argUser struct {
+	ID        int
+	Name      string
+	Phone     string
+	Age       int
+	No        int
+	School    string
+	IsStudent bool
+	CreateAt  *time.Time
+	UpdateAt  *time.Time
+	DeleteAt  *time.Time
+	Where     string
+}
+
+func TestGenDaoSql_Args(t *testing.T) {
+	domain := pathutils.Abs("../testfiles/domain")
+	sc := astutils.NewStructCollector(astutils.ExprString)
+	for _, file := range []string{"user.go", "base.go"} {
+		root, err := parser.ParseFile(token.NewFileSet(), filepath.Join(domain, file), nil, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Walk(sc, root)
+	}
+	if err := GenDaoSql(domain, table.NewTableFromStruct(ddlast.FlatEmbed(sc.Structs)[0], "")); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(pathutils.Abs("../testfiles/dao"))
+	sqlfile := pathutils.Abs("../testfiles/dao/userdao.sql")
+	value := "it's a ?"
+	tests := []struct {
+		block string
+		data  argUser
+		// number of values bound by arg
+		wantArgs int
+	}{
+		{"UpdateUserNoneZero", argUser{ID: 1, Name: value}, 2},
+		{"UpsertUserNoneZero", argUser{ID: 1, Name: value}, 9},
+		{"UpdateUsers", argUser{Name: value, Where: "`id` = ?"}, 7},
+		{"UpdateUsersNoneZero", argUser{Name: value, Where: "`id` = ?"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.block, func(t *testing.T) {
+			statement, args, err := templateutils.StringBlockMysqlArgs(sqlfile, tt.block, tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(statement, value) {
+				t.Errorf("value is spliced into statement %s", statement)
+			}
+			var bound bool
+			for _, arg := range args {
+				bound = bound || arg == value
+			}
+			if len(args) != tt.wantArgs || !bound {
+				t.Errorf("args = %v, want %d args including %q", args, tt.wantArgs, value)
+			}
+			placeholders := len(args)
+			if tt.data.Where != "" {
+				placeholders++
+			}
+			// postgres statements are rebound, placeholders in values must not be rewritten
+			rebound := sqlx.Rebind(sqlx.DOLLAR, statement)
+			if !strings.Contains(rebound, fmt.Sprintf("$%d", placeholders)) || strings.Contains(rebound, fmt.Sprintf("$%d", placeholders+1)) {
+				t.Errorf("want %d placeholders in %s", placeholders, rebound)
+			}
+		})
+	}
+}
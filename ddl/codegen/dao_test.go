@@ -15,6 +15,14 @@ import (
 )
 
 func TestGenDaoGo(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err = os.Chdir(pathutils.Abs("../testfiles")); err != nil {
+		t.Fatal(err)
+	}
 	domain := "../testfiles/domain"
 
 	sc := astutils.NewStructCollector(astutils.ExprString)
@@ -68,9 +76,51 @@ func TestGenDaoGo(t *testing.T) {
 			defer os.RemoveAll(pathutils.Abs("../testfiles/dao"))
 			expect := `package dao
 
+import (
+	"context"
+	"testfiles/domain"
+	"github.com/unionj-cloud/go-doudou/ddl/query"
+)
+
 type UserDao interface {
 	Base
-}`
+	// Insert inserts data and sets its primary key generated by database
+	Insert(ctx context.Context, data *domain.User) (int64, error)
+	Upsert(ctx context.Context, data *domain.User) (int64, error)
+	UpsertNoneZero(ctx context.Context, data *domain.User) (int64, error)
+	// InsertMany inserts data by multi-row statements and sets primary keys generated by database where the dialect allows
+	InsertMany(ctx context.Context, data []domain.User) (int64, error)
+	// UpsertMany upserts data by multi-row statements
+	UpsertMany(ctx context.Context, data []domain.User) (int64, error)
+	Update(ctx context.Context, data *domain.User) (int64, error)
+	UpdateNoneZero(ctx context.Context, data *domain.User) (int64, error)
+	UpdateMany(ctx context.Context, data domain.User, where query.Q) (int64, error)
+	UpdateManyNoneZero(ctx context.Context, data domain.User, where query.Q) (int64, error)
+	Get(ctx context.Context, id int) (domain.User, error)
+	// GetCols selects columns of sel of the row by primary key into dest
+	GetCols(ctx context.Context, sel query.Sel, id int, dest interface{}) error
+	SelectMany(ctx context.Context, where ...query.Q) ([]domain.User, error)
+	PageMany(ctx context.Context, page query.Page, where ...query.Q) (UserPageRet, error)
+	// PageAfter selects the page after keyset.After sorted by keyset.Orders and primary key
+	PageAfter(ctx context.Context, keyset query.Keyset, where ...query.Q) (UserKeysetRet, error)
+}
+
+// UserPageRet is a page of user rows returned by PageMany
+type UserPageRet struct {
+	Items    []domain.User
+	PageNo   int
+	PageSize int
+	Total    int
+	HasNext  bool
+}
+
+// UserKeysetRet is a page of user rows returned by PageAfter, Next is the cursor of the next page
+type UserKeysetRet struct {
+	Items   []domain.User
+	Next    string
+	HasNext bool
+}
+`
 			daofile := pathutils.Abs("../testfiles/dao/userdao.go")
 			f, err := os.Open(daofile)
 			if err != nil {
@@ -86,3 +136,82 @@ type UserDao interface {
 		})
 	}
 }
+
+func TestGenDaoGo_Relations(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	testDir := pathutils.Abs("../testfiles")
+	if err = os.Chdir(testDir); err != nil {
+		t.Fatal(err)
+	}
+	sc := astutils.NewStructCollector(astutils.ExprString)
+	root, err := parser.ParseFile(token.NewFileSet(), testDir+"/domain/purchase.go", nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ast.Walk(sc, root)
+	purchase := table.NewTableFromStruct(ddlast.FlatEmbed(sc.Structs)[0], "")
+	if err = GenDaoGo(testDir+"/domain", purchase); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(testDir + "/dao")
+	expect := `package dao
+
+import (
+	"context"
+	"testfiles/domain"
+	"github.com/unionj-cloud/go-doudou/ddl/query"
+)
+
+type PurchaseDao interface {
+	Base
+	// Insert inserts data and sets its primary key generated by database
+	Insert(ctx context.Context, data *domain.Purchase) (int64, error)
+	Upsert(ctx context.Context, data *domain.Purchase) (int64, error)
+	UpsertNoneZero(ctx context.Context, data *domain.Purchase) (int64, error)
+	// InsertMany inserts data by multi-row statements and sets primary keys generated by database where the dialect allows
+	InsertMany(ctx context.Context, data []domain.Purchase) (int64, error)
+	// UpsertMany upserts data by multi-row statements
+	UpsertMany(ctx context.Context, data []domain.Purchase) (int64, error)
+	Update(ctx context.Context, data *domain.Purchase) (int64, error)
+	UpdateNoneZero(ctx context.Context, data *domain.Purchase) (int64, error)
+	UpdateMany(ctx context.Context, data domain.Purchase, where query.Q) (int64, error)
+	UpdateManyNoneZero(ctx context.Context, data domain.Purchase, where query.Q) (int64, error)
+	Get(ctx context.Context, id int) (domain.Purchase, error)
+	// GetCols selects columns of sel of the row by primary key into dest
+	GetCols(ctx context.Context, sel query.Sel, id int, dest interface{}) error
+	SelectMany(ctx context.Context, where ...query.Q) ([]domain.Purchase, error)
+	PageMany(ctx context.Context, page query.Page, where ...query.Q) (PurchasePageRet, error)
+	// PageAfter selects the page after keyset.After sorted by keyset.Orders and primary key
+	PageAfter(ctx context.Context, keyset query.Keyset, where ...query.Q) (PurchaseKeysetRet, error)
+	// LoadManyByUserId selects purchase rows whose user_id is in ids in one query, grouped by user_id
+	LoadManyByUserId(ctx context.Context, ids []int) (map[int][]domain.Purchase, error)
+}
+
+// PurchasePageRet is a page of purchase rows returned by PageMany
+type PurchasePageRet struct {
+	Items    []domain.Purchase
+	PageNo   int
+	PageSize int
+	Total    int
+	HasNext  bool
+}
+
+// PurchaseKeysetRet is a page of purchase rows returned by PageAfter, Next is the cursor of the next page
+type PurchaseKeysetRet struct {
+	Items   []domain.Purchase
+	Next    string
+	HasNext bool
+}
+`
+	content, err := ioutil.ReadFile(testDir + "/dao/purchasedao.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != expect {
+		t.Errorf("want %s, got %s\n", expect, string(content))
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/unionj-cloud/go-doudou/astutils"
+	"github.com/unionj-cloud/go-doudou/ddl/dialect"
 	"github.com/unionj-cloud/go-doudou/ddl/table"
 	"github.com/unionj-cloud/go-doudou/pathutils"
 	"os"
@@ -13,22 +14,33 @@ import (
 	"text/template"
 )
 
+// GenDaoImplGo generates dao implementation, sql dialect is decided by DB_DRIVER environment variable
 func GenDaoImplGo(domainpath string, t table.Table, folder ...string) error {
 	var (
-		err      error
-		dpkg     string
-		tplpath  string
-		daopath  string
-		f        *os.File
-		funcMap  map[string]interface{}
-		tpl      *template.Template
-		pkColumn table.Column
-		df       string
+		err       error
+		dia       dialect.Dialect
+		quote     string
+		returning string
+		dpkg      string
+		tplpath   string
+		daopath   string
+		f         *os.File
+		funcMap   map[string]interface{}
+		tpl       *template.Template
+		pkColumn  table.Column
+		softCol   *table.Column
+		createdBy []table.Column
+		updatedBy []table.Column
+		version   *table.Column
+		df        string
 	)
 	df = "dao"
 	if len(folder) > 0 {
 		df = folder[0]
 	}
+	if dia, err = dialect.Get(os.Getenv("DB_DRIVER")); err != nil {
+		return errors.Wrap(err, "error")
+	}
 	daopath = filepath.Join(filepath.Dir(domainpath), df)
 	if err = os.MkdirAll(daopath, os.ModePerm); err != nil {
 		return errors.Wrap(err, "error")
@@ -56,18 +68,63 @@ func GenDaoImplGo(domainpath string, t table.Table, folder ...string) error {
 				break
 			}
 		}
+		for i, column := range t.Columns {
+			if column.SoftDelete && softCol == nil {
+				softCol = &t.Columns[i]
+			}
+			if column.CreatedBy {
+				createdBy = append(createdBy, column)
+			}
+			if column.UpdatedBy {
+				updatedBy = append(updatedBy, column)
+			}
+			if column.Version && version == nil {
+				version = &t.Columns[i]
+			}
+		}
+		if pkColumn.Autoincrement {
+			returning = dia.Returning(pkColumn.Name)
+		}
+		quote = dia.Quote("")[:1]
 		if err = tpl.Execute(f, struct {
 			DomainPackage string
 			DomainName    string
 			TableName     string
 			PkField       astutils.FieldMeta
 			PkCol         table.Column
+			Returning     string
+			Requote       bool
+			Quote         string
+			Relations     []relation
+			Columns       []table.Column
+			SoftDelete    *table.Column
+			CreatedBy     []table.Column
+			UpdatedBy     []table.Column
+			Stamped       bool
+			Version       *table.Column
+			InsertColumns []table.Column
+			MaxParams     int
+			// LastIdOfLastRow is true if LastInsertId of multi-row insert statement is id of the last row
+			LastIdOfLastRow bool
 		}{
-			DomainPackage: dpkg,
-			DomainName:    t.Meta.Name,
-			TableName:     t.Name,
-			PkField:       pkColumn.Meta,
-			PkCol:         pkColumn,
+			DomainPackage:   dpkg,
+			DomainName:      t.Meta.Name,
+			TableName:       t.Name,
+			PkField:         pkColumn.Meta,
+			PkCol:           pkColumn,
+			Returning:       returning,
+			Requote:         quote != "`",
+			Quote:           quote,
+			Relations:       relations(t),
+			Columns:         t.Columns,
+			SoftDelete:      softCol,
+			CreatedBy:       createdBy,
+			UpdatedBy:       updatedBy,
+			Stamped:         len(createdBy) > 0 || len(updatedBy) > 0,
+			Version:         version,
+			InsertColumns:   insertColumns(t),
+			MaxParams:       dia.MaxParams(),
+			LastIdOfLastRow: dia.LastInsertIdOfLastRow(),
 		}); err != nil {
 			return errors.Wrap(err, "error")
 		}
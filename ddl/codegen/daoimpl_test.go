@@ -4,6 +4,8 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/unionj-cloud/go-doudou/astutils"
 	"github.com/unionj-cloud/go-doudou/ddl/ddlast"
+	"github.com/unionj-cloud/go-doudou/ddl/dialect"
+	_ "github.com/unionj-cloud/go-doudou/ddl/dialect/postgres"
 	"github.com/unionj-cloud/go-doudou/ddl/table"
 	"github.com/unionj-cloud/go-doudou/pathutils"
 	"go/ast"
@@ -11,7 +13,9 @@ import (
 	"go/token"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -84,15 +88,16 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
 	"testfiles/domain"
 	"github.com/unionj-cloud/go-doudou/ddl"
 	"github.com/unionj-cloud/go-doudou/ddl/query"
 	"github.com/unionj-cloud/go-doudou/pathutils"
-	"github.com/unionj-cloud/go-doudou/reflectutils"
 	"github.com/unionj-cloud/go-doudou/templateutils"
 	"strings"
 	"math"
+	"reflect"
 )
 
 type UserDaoImpl struct {
@@ -105,26 +110,41 @@ func NewUserDao(querier ddl.Querier) UserDao {
 	}
 }
 
-func (receiver UserDaoImpl) Insert(ctx context.Context, data interface{}) (int64, error) {
+// querier returns the transaction carried by ctx if any, so that the dao joins transaction started by ddl.RunInTx
+func (receiver UserDaoImpl) querier(ctx context.Context) ddl.Querier {
+	return ddl.QuerierFromContext(ctx, receiver.db)
+}
+
+func (receiver UserDaoImpl) rebind(statement string) string {
+	return receiver.db.Rebind(statement)
+}
+
+// scope excludes soft deleted rows from where unless ctx is returned by ddl.WithDeleted
+func (receiver UserDaoImpl) scope(ctx context.Context, where []query.Q) []query.Q {
+	if ddl.DeletedIncluded(ctx) {
+		return where
+	}
+	return []query.Q{query.And(append(append([]query.Q{}, where...), query.C().Col("delete_at").IsNull())...)}
+}
+
+func (receiver UserDaoImpl) Insert(ctx context.Context, data *domain.User) (int64, error) {
 	var (
 		statement    string
 		err          error
 		result       sql.Result
 		lastInsertID int64
 	)
-	if statement, err = templateutils.StringBlockMysql(pathutils.Abs("userdao.sql"), "InsertUser", nil); err != nil {
+	if statement, err = templateutils.StringBlockMysql(pathutils.Abs("userdao.sql"), "InsertUser", data); err != nil {
 		return 0, err
 	}
-	if result, err = receiver.db.NamedExecContext(ctx, statement, data); err != nil {
+	if result, err = receiver.querier(ctx).NamedExecContext(ctx, statement, data); err != nil {
 		return 0, errors.Wrap(err, "error returned from calling db.Exec")
 	}
 	if lastInsertID, err = result.LastInsertId(); err != nil {
 		return 0, errors.Wrap(err, "error returned from calling result.LastInsertId")
 	}
 	if lastInsertID > 0 {
-		if user, ok := data.(*domain.User); ok {
-			user.ID = int(lastInsertID)
-		}
+		data.ID = int(lastInsertID)
 	}
 	return result.RowsAffected()
 }
@@ -134,68 +154,153 @@ func (receiver UserDaoImpl) Insert(ctx context.Context, data interface{}) (int64
 // If you specify the CLIENT_FOUND_ROWS flag to the mysql_real_connect() C API function when connecting to mysqld,
 // the affected-rows value is 1 (not 0) if an existing row is set to its current values.
 // https://dev.mysql.com/doc/refman/5.7/en/insert-on-duplicate.html
-func (receiver UserDaoImpl) Upsert(ctx context.Context, data interface{}) (int64, error) {
+func (receiver UserDaoImpl) Upsert(ctx context.Context, data *domain.User) (int64, error) {
 	var (
 		statement    string
 		err          error
 		result       sql.Result
 		lastInsertID int64
 	)
-	if statement, err = templateutils.StringBlockMysql(pathutils.Abs("userdao.sql"), "UpsertUser", nil); err != nil {
+	if statement, err = templateutils.StringBlockMysql(pathutils.Abs("userdao.sql"), "UpsertUser", data); err != nil {
 		return 0, err
 	}
-	if result, err = receiver.db.NamedExecContext(ctx, statement, data); err != nil {
+	if result, err = receiver.querier(ctx).NamedExecContext(ctx, statement, data); err != nil {
 		return 0, errors.Wrap(err, "error returned from calling db.Exec")
 	}
 	if lastInsertID, err = result.LastInsertId(); err != nil {
 		return 0, errors.Wrap(err, "error returned from calling result.LastInsertId")
 	}
 	if lastInsertID > 0 {
-		if user, ok := data.(*domain.User); ok {
-			user.ID = int(lastInsertID)
-		}
+		data.ID = int(lastInsertID)
 	}
 	return result.RowsAffected()
 }
 
-func (receiver UserDaoImpl) UpsertNoneZero(ctx context.Context, data interface{}) (int64, error) {
+func (receiver UserDaoImpl) UpsertNoneZero(ctx context.Context, data *domain.User) (int64, error) {
 	var (
 		statement    string
 		err          error
+		args         []interface{}
 		result       sql.Result
 		lastInsertID int64
 	)
-	if statement, err = templateutils.StringBlockMysql(pathutils.Abs("userdao.sql"), "UpsertUserNoneZero", data); err != nil {
+	if statement, args, err = templateutils.StringBlockMysqlArgs(pathutils.Abs("userdao.sql"), "UpsertUserNoneZero", data); err != nil {
 		return 0, err
 	}
-	if result, err = receiver.db.ExecContext(ctx, statement); err != nil {
+	if result, err = receiver.querier(ctx).ExecContext(ctx, receiver.rebind(statement), args...); err != nil {
 		return 0, errors.Wrap(err, "error returned from calling db.Exec")
 	}
 	if lastInsertID, err = result.LastInsertId(); err != nil {
 		return 0, errors.Wrap(err, "error returned from calling result.LastInsertId")
 	}
 	if lastInsertID > 0 {
-		if user, ok := data.(*domain.User); ok {
-			user.ID = int(lastInsertID)
-		}
+		data.ID = int(lastInsertID)
 	}
 	return result.RowsAffected()
 }
 
+func (receiver UserDaoImpl) InsertMany(ctx context.Context, data []domain.User) (int64, error) {
+	return receiver.insertMany(ctx, "InsertUsers", data)
+}
+
+func (receiver UserDaoImpl) UpsertMany(ctx context.Context, data []domain.User) (int64, error) {
+	return receiver.insertMany(ctx, "UpsertUsers", data)
+}
+
+// insertMany executes multi-row statement of sql block for data, rows are split into chunks by parameter limit of
+// database and ddl.MaxBatchBytes
+func (receiver UserDaoImpl) insertMany(ctx context.Context, block string, data []domain.User) (int64, error) {
+	var (
+		statement    string
+		err          error
+		result       sql.Result
+		affected     int64
+		lastInsertID int64
+		total        int64
+		offset       int
+		rows         [][]interface{}
+	)
+	// primary keys are inserted if they are set, otherwise generated by database
+	withPk := len(data) > 0 && data[0].ID != 0
+	for i := range data {
+		if (data[i].ID != 0) != withPk {
+			return 0, errors.New("primary keys of data should be either all set or all zero")
+		}
+		var row []interface{}
+		if withPk {
+			row = append(row, data[i].ID)
+		}
+		row = append(row, data[i].Name, data[i].Phone, data[i].Age, data[i].No, data[i].School, data[i].IsStudent, data[i].DeleteAt)
+		rows = append(rows, row)
+	}
+	for _, chunk := range ddl.Chunks(rows, 65535) {
+		if statement, err = templateutils.StringBlockMysql(pathutils.Abs("userdao.sql"), block, struct {
+			WithPk bool
+			Rows   [][]interface{}
+		}{
+			WithPk: withPk,
+			Rows:   chunk,
+		}); err != nil {
+			return total, err
+		}
+		var args []interface{}
+		for _, row := range chunk {
+			args = append(args, row...)
+		}
+		if result, err = receiver.querier(ctx).ExecContext(ctx, receiver.rebind(statement), args...); err != nil {
+			return total, errors.Wrap(err, "error returned from calling db.ExecContext")
+		}
+		if affected, err = result.RowsAffected(); err != nil {
+			return total, errors.Wrap(err, "error returned from calling result.RowsAffected")
+		}
+		total += affected
+		// ids are written back for inserts only, ids of rows updated by upsert are unknown
+		if block == "InsertUsers" && !withPk {
+			if lastInsertID, err = result.LastInsertId(); err != nil {
+				return total, errors.Wrap(err, "error returned from calling result.LastInsertId")
+			}
+			for j := range chunk {
+				data[offset+j].ID = int(lastInsertID + int64(j))
+			}
+		}
+		offset += len(chunk)
+	}
+	return total, nil
+}
+
 func (receiver UserDaoImpl) DeleteMany(ctx context.Context, where query.Q) (int64, error) {
 	var (
 		statement string
 		err       error
 		result    sql.Result
+		whereSql  string
+		args      []interface{}
+	)
+	whereSql, args = query.And(query.C().Col("delete_at").IsNull(), where).Sql()
+	statement = fmt.Sprintf("update ` + "`" + `user` + "`" + ` set ` + "`" + `delete_at` + "`" + ` = CURRENT_TIMESTAMP where %s;", whereSql)
+	if result, err = receiver.querier(ctx).ExecContext(ctx, receiver.rebind(statement), args...); err != nil {
+		return 0, errors.Wrap(err, "error returned from calling db.ExecContext")
+	}
+	return result.RowsAffected()
+}
+
+func (receiver UserDaoImpl) ForceDeleteMany(ctx context.Context, where query.Q) (int64, error) {
+	var (
+		statement string
+		err       error
+		result    sql.Result
+		whereSql  string
+		args      []interface{}
 	)
-	statement = fmt.Sprintf("delete from user where %s;", where.Sql())
-	if result, err = receiver.db.ExecContext(ctx, statement); err != nil {
+	whereSql, args = where.Sql()
+	statement = fmt.Sprintf("delete from ` + "`" + `user` + "`" + ` where %s;", whereSql)
+	if result, err = receiver.querier(ctx).ExecContext(ctx, receiver.rebind(statement), args...); err != nil {
 		return 0, errors.Wrap(err, "error returned from calling db.ExecContext")
 	}
 	return result.RowsAffected()
 }
 
-func (receiver UserDaoImpl) Update(ctx context.Context, data interface{}) (int64, error) {
+func (receiver UserDaoImpl) Update(ctx context.Context, data *domain.User) (int64, error) {
 	var (
 		statement string
 		err       error
@@ -204,82 +309,83 @@ func (receiver UserDaoImpl) Update(ctx context.Context, data interface{}) (int64
 	if statement, err = templateutils.StringBlockMysql(pathutils.Abs("userdao.sql"), "UpdateUser", nil); err != nil {
 		return 0, err
 	}
-	if result, err = receiver.db.NamedExecContext(ctx, statement, data); err != nil {
+	if result, err = receiver.querier(ctx).NamedExecContext(ctx, statement, data); err != nil {
 		return 0, errors.Wrap(err, "error returned from calling db.Exec")
 	}
 	return result.RowsAffected()
 }
 
-func (receiver UserDaoImpl) UpdateNoneZero(ctx context.Context, data interface{}) (int64, error) {
+func (receiver UserDaoImpl) UpdateNoneZero(ctx context.Context, data *domain.User) (int64, error) {
 	var (
 		statement string
 		err       error
 		result    sql.Result
+		args      []interface{}
 	)
-	if statement, err = templateutils.StringBlockMysql(pathutils.Abs("userdao.sql"), "UpdateUserNoneZero", data); err != nil {
+	if statement, args, err = templateutils.StringBlockMysqlArgs(pathutils.Abs("userdao.sql"), "UpdateUserNoneZero", data); err != nil {
 		return 0, err
 	}
-	if result, err = receiver.db.ExecContext(ctx, statement); err != nil {
+	if result, err = receiver.querier(ctx).ExecContext(ctx, receiver.rebind(statement), args...); err != nil {
 		return 0, errors.Wrap(err, "error returned from calling db.Exec")
 	}
 	return result.RowsAffected()
 }
 
-func (receiver UserDaoImpl) UpdateMany(ctx context.Context, data interface{}, where query.Q) (int64, error) {
+func (receiver UserDaoImpl) UpdateMany(ctx context.Context, data domain.User, where query.Q) (int64, error) {
 	var (
 		statement string
 		err       error
 		result    sql.Result
-		user   domain.User
-		ok        bool
+		whereSql  string
+		whereArgs []interface{}
+		args      []interface{}
 	)
-	value := reflectutils.ValueOf(data).Interface()
-	if user, ok = value.(domain.User); !ok {
-		return 0, errors.New("incorrect type of parameter data")
-	}
-	if statement, err = templateutils.StringBlockMysql(pathutils.Abs("userdao.sql"), "UpdateUsers", struct {
+	whereSql, whereArgs = where.Sql()
+	if statement, args, err = templateutils.StringBlockMysqlArgs(pathutils.Abs("userdao.sql"), "UpdateUsers", struct {
 		domain.User
 		Where string
 	}{
-		User:  user,
-		Where: where.Sql(),
+		User:  data,
+		Where: whereSql,
 	}); err != nil {
 		return 0, err
 	}
-	if result, err = receiver.db.ExecContext(ctx, statement); err != nil {
+	// values of set clause come before args of where clause
+	args = append(args, whereArgs...)
+	if result, err = receiver.querier(ctx).ExecContext(ctx, receiver.rebind(statement), args...); err != nil {
 		return 0, errors.Wrap(err, "error returned from calling db.Exec")
 	}
 	return result.RowsAffected()
 }
 
-func (receiver UserDaoImpl) UpdateManyNoneZero(ctx context.Context, data interface{}, where query.Q) (int64, error) {
+func (receiver UserDaoImpl) UpdateManyNoneZero(ctx context.Context, data domain.User, where query.Q) (int64, error) {
 	var (
 		statement string
 		err       error
 		result    sql.Result
-		user   domain.User
-		ok        bool
+		whereSql  string
+		whereArgs []interface{}
+		args      []interface{}
 	)
-	value := reflectutils.ValueOf(data).Interface()
-	if user, ok = value.(domain.User); !ok {
-		return 0, errors.New("incorrect type of parameter data")
-	}
-	if statement, err = templateutils.StringBlockMysql(pathutils.Abs("userdao.sql"), "UpdateUsersNoneZero", struct {
+	whereSql, whereArgs = where.Sql()
+	if statement, args, err = templateutils.StringBlockMysqlArgs(pathutils.Abs("userdao.sql"), "UpdateUsersNoneZero", struct {
 		domain.User
 		Where string
 	}{
-		User:  user,
-		Where: where.Sql(),
+		User:  data,
+		Where: whereSql,
 	}); err != nil {
 		return 0, err
 	}
-	if result, err = receiver.db.ExecContext(ctx, statement); err != nil {
+	// values of set clause come before args of where clause
+	args = append(args, whereArgs...)
+	if result, err = receiver.querier(ctx).ExecContext(ctx, receiver.rebind(statement), args...); err != nil {
 		return 0, errors.Wrap(err, "error returned from calling db.Exec")
 	}
 	return result.RowsAffected()
 }
 
-func (receiver UserDaoImpl) Get(ctx context.Context, id interface{}) (interface{}, error) {
+func (receiver UserDaoImpl) Get(ctx context.Context, id int) (domain.User, error) {
 	var (
 		statement string
 		err       error
@@ -288,26 +394,33 @@ func (receiver UserDaoImpl) Get(ctx context.Context, id interface{}) (interface{
 	if statement, err = templateutils.StringBlockMysql(pathutils.Abs("userdao.sql"), "GetUser", nil); err != nil {
 		return domain.User{}, err
 	}
-	if err = receiver.db.GetContext(ctx, &user, receiver.db.Rebind(statement), id); err != nil {
+	if err = receiver.querier(ctx).GetContext(ctx, &user, receiver.rebind(statement), id); err != nil {
 		return domain.User{}, errors.Wrap(err, "error returned from calling db.Select")
 	}
+	if user.DeleteAt != nil && !ddl.DeletedIncluded(ctx) {
+		return domain.User{}, errors.Wrap(sql.ErrNoRows, "error returned from calling db.Select")
+	}
 	return user, nil
 }
 
-func (receiver UserDaoImpl) SelectMany(ctx context.Context, where ...query.Q) (interface{}, error) {
+func (receiver UserDaoImpl) SelectMany(ctx context.Context, where ...query.Q) ([]domain.User, error) {
 	var (
 		statements []string
 		err       error
 		users     []domain.User
+		args      []interface{}
 	)
-    statements = append(statements, "select * from user")
+	where = receiver.scope(ctx, where)
+    statements = append(statements, "select * from ` + "`" + `user` + "`" + `")
     if len(where) > 0 {
         statements = append(statements, "where")
         for _, item :=range where {
-            statements = append(statements, item.Sql())
+            whereSql, whereArgs := item.Sql()
+            statements = append(statements, whereSql)
+            args = append(args, whereArgs...)
         }
     }
-	if err = receiver.db.SelectContext(ctx, &users, strings.Join(statements, " ")); err != nil {
+	if err = receiver.querier(ctx).SelectContext(ctx, &users, receiver.rebind(strings.Join(statements, " ")), args...); err != nil {
 		return nil, errors.Wrap(err, "error returned from calling db.SelectContext")
 	}
 	return users, nil
@@ -318,56 +431,244 @@ func (receiver UserDaoImpl) CountMany(ctx context.Context, where ...query.Q) (in
 		statements []string
 		err       error
 		total     int
+		args      []interface{}
 	)
-	statements = append(statements, "select count(1) from user")
+	where = receiver.scope(ctx, where)
+	statements = append(statements, "select count(1) from ` + "`" + `user` + "`" + `")
     if len(where) > 0 {
         statements = append(statements, "where")
         for _, item :=range where {
-            statements = append(statements, item.Sql())
+            whereSql, whereArgs := item.Sql()
+            statements = append(statements, whereSql)
+            args = append(args, whereArgs...)
         }
     }
-	if err = receiver.db.GetContext(ctx, &total, strings.Join(statements, " ")); err != nil {
+	if err = receiver.querier(ctx).GetContext(ctx, &total, receiver.rebind(strings.Join(statements, " ")), args...); err != nil {
 		return 0, errors.Wrap(err, "error returned from calling db.GetContext")
 	}
 	return total, nil
 }
 
-func (receiver UserDaoImpl) PageMany(ctx context.Context, page query.Page, where ...query.Q) (query.PageRet, error) {
+func (receiver UserDaoImpl) PageMany(ctx context.Context, page query.Page, where ...query.Q) (UserPageRet, error) {
 	var (
 		statements []string
 		err       error
 		users     []domain.User
 		total     int
+		args      []interface{}
 	)
-	statements = append(statements, "select * from user")
+	where = receiver.scope(ctx, where)
+	statements = append(statements, "select * from ` + "`" + `user` + "`" + `")
     if len(where) > 0 {
         statements = append(statements, "where")
         for _, item :=range where {
-            statements = append(statements, item.Sql())
+            whereSql, whereArgs := item.Sql()
+            statements = append(statements, whereSql)
+            args = append(args, whereArgs...)
         }
     }
     statements = append(statements, page.Sql())
-	if err = receiver.db.SelectContext(ctx, &users, strings.Join(statements, " ")); err != nil {
-		return query.PageRet{}, errors.Wrap(err, "error returned from calling db.SelectContext")
+	if err = receiver.querier(ctx).SelectContext(ctx, &users, receiver.rebind(strings.Join(statements, " ")), args...); err != nil {
+		return UserPageRet{}, errors.Wrap(err, "error returned from calling db.SelectContext")
 	}
 
     statements = nil
-	statements = append(statements, "select count(1) from user")
+    args = nil
+	statements = append(statements, "select count(1) from ` + "`" + `user` + "`" + `")
     if len(where) > 0 {
         statements = append(statements, "where")
         for _, item :=range where {
-            statements = append(statements, item.Sql())
+            whereSql, whereArgs := item.Sql()
+            statements = append(statements, whereSql)
+            args = append(args, whereArgs...)
         }
     }
-	if err = receiver.db.GetContext(ctx, &total, strings.Join(statements, " ")); err != nil {
+	if err = receiver.querier(ctx).GetContext(ctx, &total, receiver.rebind(strings.Join(statements, " ")), args...); err != nil {
+		return UserPageRet{}, errors.Wrap(err, "error returned from calling db.GetContext")
+	}
+
+	ret := query.NewPageRet(page)
+	pageRet := UserPageRet{
+		Items:    users,
+		PageNo:   ret.PageNo,
+		PageSize: ret.PageSize,
+		Total:    total,
+	}
+
+	if pageRet.PageSize > 0 && math.Ceil(float64(total)/float64(pageRet.PageSize)) > float64(pageRet.PageNo) {
+		pageRet.HasNext = true
+	}
+
+	return pageRet, nil
+}
+
+// sortKey returns value of column col of item for encoding cursor
+func (receiver UserDaoImpl) sortKey(item domain.User, col string) (interface{}, error) {
+	switch col {
+	case "id":
+		return item.ID, nil
+	case "name":
+		return item.Name, nil
+	case "phone":
+		return item.Phone, nil
+	case "age":
+		return item.Age, nil
+	case "no":
+		return item.No, nil
+	case "school":
+		return item.School, nil
+	case "is_student":
+		return item.IsStudent, nil
+	case "create_at":
+		return item.CreateAt, nil
+	case "update_at":
+		return item.UpdateAt, nil
+	case "delete_at":
+		return item.DeleteAt, nil
+	}
+	return nil, errors.Errorf("unknown sort column %s", col)
+}
+
+func (receiver UserDaoImpl) PageAfter(ctx context.Context, keyset query.Keyset, where ...query.Q) (UserKeysetRet, error) {
+	var (
+		statements []string
+		err        error
+		users     []domain.User
+		args       []interface{}
+		after      query.Q
+		ret        UserKeysetRet
+	)
+	keyset = keyset.Unique("id")
+	where = receiver.scope(ctx, where)
+	if after, err = keyset.Where(); err != nil {
+		return ret, err
+	}
+	if after != nil {
+		where = []query.Q{query.And(append(append([]query.Q{}, where...), after)...)}
+	}
+	statements = append(statements, "select * from ` + "`" + `user` + "`" + `")
+	if len(where) > 0 {
+		statements = append(statements, "where")
+		for _, item := range where {
+			whereSql, whereArgs := item.Sql()
+			statements = append(statements, whereSql)
+			args = append(args, whereArgs...)
+		}
+	}
+	statements = append(statements, keyset.Sql())
+	if err = receiver.querier(ctx).SelectContext(ctx, &users, receiver.rebind(strings.Join(statements, " ")), args...); err != nil {
+		return ret, errors.Wrap(err, "error returned from calling db.SelectContext")
+	}
+	if keyset.Size > 0 && len(users) > keyset.Size {
+		users = users[:keyset.Size]
+		ret.HasNext = true
+	}
+	ret.Items = users
+	if ret.HasNext {
+		last := users[len(users)-1]
+		var values []interface{}
+		for _, order := range keyset.Orders {
+			value, err := receiver.sortKey(last, order.Col)
+			if err != nil {
+				return UserKeysetRet{}, err
+			}
+			values = append(values, value)
+		}
+		if ret.Next, err = query.EncodeCursor(values...); err != nil {
+			return UserKeysetRet{}, err
+		}
+	}
+	return ret, nil
+}
+
+// projection returns select statement of sel with where conditions
+func (receiver UserDaoImpl) projection(ctx context.Context, sel query.Sel, where []query.Q) (string, []interface{}) {
+	var (
+		statements []string
+		args       []interface{}
+	)
+	where = receiver.scope(ctx, where)
+	statements = append(statements, sel.Sql("` + "`" + `user` + "`" + `"))
+	if len(where) > 0 {
+		statements = append(statements, "where")
+		for _, item := range where {
+			whereSql, whereArgs := item.Sql()
+			statements = append(statements, whereSql)
+			args = append(args, whereArgs...)
+		}
+	}
+	if groupSql, groupArgs := sel.GroupSql(); groupSql != "" {
+		statements = append(statements, groupSql)
+		args = append(args, groupArgs...)
+	}
+	return strings.Join(statements, " "), args
+}
+
+func (receiver UserDaoImpl) GetCols(ctx context.Context, sel query.Sel, id int, dest interface{}) error {
+	statement, args := receiver.projection(ctx, sel, []query.Q{query.C().Col("id").Eq(query.Literal(id))})
+	if err := receiver.querier(ctx).GetContext(ctx, dest, receiver.rebind(statement), args...); err != nil {
+		return errors.Wrap(err, "error returned from calling db.GetContext")
+	}
+	return nil
+}
+
+func (receiver UserDaoImpl) SelectManyCols(ctx context.Context, sel query.Sel, dest interface{}, where ...query.Q) error {
+	statement, args := receiver.projection(ctx, sel, where)
+	if err := receiver.querier(ctx).SelectContext(ctx, dest, receiver.rebind(statement), args...); err != nil {
+		return errors.Wrap(err, "error returned from calling db.SelectContext")
+	}
+	return nil
+}
+
+func (receiver UserDaoImpl) SelectMaps(ctx context.Context, sel query.Sel, where ...query.Q) ([]map[string]interface{}, error) {
+	var (
+		err    error
+		rows   *sqlx.Rows
+		result []map[string]interface{}
+	)
+	statement, args := receiver.projection(ctx, sel, where)
+	if rows, err = receiver.querier(ctx).QueryxContext(ctx, receiver.rebind(statement), args...); err != nil {
+		return nil, errors.Wrap(err, "error returned from calling db.QueryxContext")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		row := make(map[string]interface{})
+		if err = rows.MapScan(row); err != nil {
+			return nil, errors.Wrap(err, "error returned from calling rows.MapScan")
+		}
+		for k, v := range row {
+			// text columns are scanned as []byte by some drivers
+			if b, ok := v.([]byte); ok {
+				row[k] = string(b)
+			}
+		}
+		result = append(result, row)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "error returned from calling rows.Next")
+	}
+	return result, nil
+}
+
+func (receiver UserDaoImpl) PageManyCols(ctx context.Context, sel query.Sel, page query.Page, dest interface{}, where ...query.Q) (query.PageRet, error) {
+	var (
+		err   error
+		total int
+	)
+	statement, args := receiver.projection(ctx, sel, where)
+	if err = receiver.querier(ctx).SelectContext(ctx, dest, receiver.rebind(statement+" "+page.Sql()), args...); err != nil {
+		return query.PageRet{}, errors.Wrap(err, "error returned from calling db.SelectContext")
+	}
+	// rows are counted after distinct and group by
+	if err = receiver.querier(ctx).GetContext(ctx, &total, receiver.rebind("select count(1) from ("+statement+") t"), args...); err != nil {
 		return query.PageRet{}, errors.Wrap(err, "error returned from calling db.GetContext")
 	}
 
 	pageRet := query.NewPageRet(page)
-	pageRet.Items = users
+	pageRet.Items = reflect.ValueOf(dest).Elem().Interface()
 	pageRet.Total = total
 
-	if math.Ceil(float64(total)/float64(pageRet.PageSize)) > float64(pageRet.PageNo) {
+	if pageRet.PageSize > 0 && math.Ceil(float64(total)/float64(pageRet.PageSize)) > float64(pageRet.PageNo) {
 		pageRet.HasNext = true
 	}
 
@@ -389,3 +690,161 @@ func (receiver UserDaoImpl) PageMany(ctx context.Context, page query.Page, where
 		})
 	}
 }
+
+func TestGenDaoImplGo_Postgres(t *testing.T) {
+	os.Setenv("DB_DRIVER", "postgres")
+	defer os.Unsetenv("DB_DRIVER")
+	dir := pathutils.Abs("../testfiles/domain")
+	sc := astutils.NewStructCollector(astutils.ExprString)
+	for _, file := range []string{"user.go", "base.go"} {
+		root, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, file), nil, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Walk(sc, root)
+	}
+	// user is a reserved word of postgres, select * from user returns current user
+	if err := GenDaoImplGo(dir, table.NewTableFromStruct(ddlast.FlatEmbed(sc.Structs)[0], "")); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(filepath.Join(dir, "../dao"))
+	content, err := ioutil.ReadFile(filepath.Join(dir, "../dao/userdaoimpl.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "return receiver.db.Rebind(dialect.Requote(statement, `\"`))") {
+		t.Fatal("statements should be requoted for postgres")
+	}
+	for _, statement := range []string{
+		"update `user` set `delete_at` = CURRENT_TIMESTAMP where %s;",
+		"delete from `user` where %s;",
+		"select * from `user`",
+		"select count(1) from `user`",
+		"sel.Sql(\"`user`\")",
+	} {
+		if !strings.Contains(string(content), statement) {
+			t.Errorf("%s not found in generated dao", statement)
+		}
+	}
+	if got := dialect.Requote("select * from `user` where `id` = ?", `"`); got != `select * from "user" where "id" = ?` {
+		t.Errorf("Requote() = %s", got)
+	}
+}
+
+const versionMain = `package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/iancoleman/strcase"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/unionj-cloud/go-doudou/ddl/dialect/sqlite"
+	"testfiles/dao"
+	"testfiles/domain"
+)
+
+func main() {
+	db := sqlx.MustConnect("sqlite3", ":memory:")
+	db.MapperFunc(strcase.ToSnake)
+	if _, err := db.Exec("create table purchase (id integer primary key autoincrement, user_id int, purchase_at datetime, create_at datetime, update_at datetime, delete_at datetime, arrive_at datetime, status int, note text, version int not null default 0)"); err != nil {
+		fail(err)
+	}
+	ctx := context.Background()
+	d := dao.NewPurchaseDao(db)
+	p := &domain.Purchase{Note: "a"}
+	if _, err := d.Insert(ctx, p); err != nil {
+		fail(err)
+	}
+	for _, note := range []string{"b", "c"} {
+		p.Note = note
+		if _, err := d.Update(ctx, p); err != nil {
+			fail(err)
+		}
+	}
+	for _, note := range []string{"d", "e"} {
+		p.Note = note
+		if _, err := d.UpdateNoneZero(ctx, p); err != nil {
+			fail(err)
+		}
+	}
+	got, err := d.Get(ctx, p.Id)
+	if err != nil {
+		fail(err)
+	}
+	fmt.Printf("%d %d %s", p.Version, got.Version, got.Note)
+}
+
+func fail(err error) {
+	fmt.Fprint(os.Stderr, err)
+	os.Exit(1)
+}
+`
+
+func TestGenDaoImplGo_Version(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs generated dao against sqlite")
+	}
+	os.Setenv("DB_DRIVER", "sqlite3")
+	os.Setenv("DB_SCHEMA", "main")
+	defer os.Unsetenv("DB_DRIVER")
+	defer os.Unsetenv("DB_SCHEMA")
+	// generated code is run in its own module, which replaces go-doudou with this repository
+	root := pathutils.Abs("../..")
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	mod := t.TempDir()
+	if err := os.Chdir(mod); err != nil {
+		t.Fatal(err)
+	}
+	gomod := "module testfiles\n\ngo 1.15\n\nrequire github.com/unionj-cloud/go-doudou v0.0.0\n\nreplace github.com/unionj-cloud/go-doudou => " + root + "\n"
+	gosum, err := ioutil.ReadFile(filepath.Join(root, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	purchasego, err := ioutil.ReadFile(pathutils.Abs("../testfiles/domain/purchase.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(mod, "domain")
+	for _, dir := range []string{dir, filepath.Join(mod, "versioncheck")} {
+		if err = os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for file, content := range map[string][]byte{
+		"go.mod":               []byte(gomod),
+		"go.sum":               gosum,
+		"domain/purchase.go":   purchasego,
+		"versioncheck/main.go": []byte(versionMain),
+	} {
+		if err = ioutil.WriteFile(filepath.Join(mod, file), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sc := astutils.NewStructCollector(astutils.ExprString)
+	f, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, "purchase.go"), nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ast.Walk(sc, f)
+	if err = GenBaseGo(dir); err != nil {
+		t.Fatal(err)
+	}
+	purchase := table.NewTableFromStruct(ddlast.FlatEmbed(sc.Structs)[0], "")
+	for _, fn := range []func(string, table.Table, ...string) error{GenDaoGo, GenDaoImplGo, GenDaoSql} {
+		if err = fn(dir, purchase); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command("go", "run", "-mod=mod", "./versioncheck")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s: %s", err, out)
+	}
+	// two Update and two UpdateNoneZero in a row, each one sees the version written back by the previous one
+	if string(out) != "4 4 e" {
+		t.Errorf("got %s, want 4 4 e", out)
+	}
+}
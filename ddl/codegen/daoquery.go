@@ -0,0 +1,254 @@
+package codegen
+
+import (
+	"bytes"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/unionj-cloud/go-doudou/astutils"
+	"github.com/unionj-cloud/go-doudou/ddl/table"
+	"github.com/unionj-cloud/go-doudou/pathutils"
+	"github.com/unionj-cloud/go-doudou/sliceutils"
+	"github.com/unionj-cloud/go-doudou/templateutils"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// sqlAnnotation marks a dao interface method implemented by the named sql block, e.g. //dd:sql FindByAge
+const sqlAnnotation = "dd:sql"
+
+var (
+	// namedParamReg matches :name params in sql, :: casts of PostgreSQL excluded
+	namedParamReg = regexp.MustCompile(`(^|[^:\w]):(\w+)`)
+	actionReg     = regexp.MustCompile(`(?s){{.*?}}`)
+	// reservedNames are local variables in generated methods
+	reservedNames = []string{"receiver", "statement", "args", "err", "params", "result", "ret"}
+)
+
+// queryMethod is a dao interface method annotated with dd:sql. Kind is select if Result is a slice, exec if the
+// method returns error only or the statement doesn't query rows, get otherwise.
+type queryMethod struct {
+	Name   string
+	Block  string
+	Ctx    string
+	Params []astutils.FieldMeta
+	// Result is type of the first result, empty if the method returns error only
+	Result string
+	Kind   string
+}
+
+func (m queryMethod) Signature() string {
+	params := []string{m.Ctx + " context.Context"}
+	for _, p := range m.Params {
+		params = append(params, p.Name+" "+p.Type)
+	}
+	return strings.Join(params, ", ")
+}
+
+func annotatedBlock(doc *ast.CommentGroup) (string, bool) {
+	if doc == nil {
+		return "", false
+	}
+	for _, comment := range doc.List {
+		text := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//"))
+		if strings.HasPrefix(text, sqlAnnotation) {
+			return strings.TrimSpace(strings.TrimPrefix(text, sqlAnnotation)), true
+		}
+	}
+	return "", false
+}
+
+// queryMethods collects methods annotated with dd:sql from interface name in daofile, imports of daofile are returned
+// for the types used by these methods
+func queryMethods(daofile, name string) ([]queryMethod, []string, error) {
+	root, err := parser.ParseFile(token.NewFileSet(), daofile, nil, parser.ParseComments)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error")
+	}
+	var imports []string
+	for _, spec := range root.Imports {
+		imp := spec.Path.Value
+		if spec.Name != nil {
+			imp = spec.Name.Name + " " + imp
+		}
+		imports = append(imports, imp)
+	}
+	var methods []queryMethod
+	ast.Inspect(root, func(n ast.Node) bool {
+		spec, ok := n.(*ast.TypeSpec)
+		if !ok || spec.Name.Name != name {
+			return true
+		}
+		it, ok := spec.Type.(*ast.InterfaceType)
+		if !ok {
+			return false
+		}
+		for _, field := range it.Methods.List {
+			block, ok := annotatedBlock(field.Doc)
+			if !ok || len(field.Names) == 0 {
+				continue
+			}
+			if block == "" {
+				block = field.Names[0].Name
+			}
+			mm := astutils.NewMethodMeta(field.Type.(*ast.FuncType), astutils.ExprString)
+			mm.Name = field.Names[0].Name
+			var m queryMethod
+			if m, err = newQueryMethod(mm, block); err != nil {
+				return false
+			}
+			methods = append(methods, m)
+		}
+		return false
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return methods, imports, nil
+}
+
+func newQueryMethod(mm astutils.MethodMeta, block string) (queryMethod, error) {
+	m := queryMethod{
+		Name:  mm.Name,
+		Block: block,
+	}
+	if len(mm.Params) == 0 || mm.Params[0].Type != "context.Context" {
+		return m, errors.Errorf("first parameter of method %s should be context.Context", mm.Name)
+	}
+	m.Ctx = mm.Params[0].Name
+	for _, p := range mm.Params {
+		if p.Name == "" || p.Name == "_" {
+			return m, errors.Errorf("parameters of method %s should be named, they are bound to sql by name", mm.Name)
+		}
+		if sliceutils.StringContains(reservedNames, p.Name) {
+			return m, errors.Errorf("parameter name %s of method %s is reserved", p.Name, mm.Name)
+		}
+	}
+	m.Params = mm.Params[1:]
+	switch {
+	case len(mm.Results) == 1 && mm.Results[0].Type == "error":
+		m.Kind = "exec"
+	case len(mm.Results) == 2 && mm.Results[1].Type == "error":
+		m.Result = mm.Results[0].Type
+		m.Kind = "get"
+		if strings.HasPrefix(m.Result, "[]") {
+			m.Kind = "select"
+		}
+	default:
+		return m, errors.Errorf("method %s should return (error) or (T, error)", mm.Name)
+	}
+	return m, nil
+}
+
+// validate checks the sql block of m exists in tpl and every :name param in it is a parameter of m
+func (m *queryMethod) validate(tpl *template.Template, sqlfile string) error {
+	block := tpl.Lookup(m.Block)
+	if block == nil || block.Tree == nil {
+		return errors.Errorf("sql block %s of method %s not found in %s", m.Block, m.Name, sqlfile)
+	}
+	text := block.Tree.Root.String()
+	var names []string
+	for _, p := range m.Params {
+		names = append(names, p.Name)
+	}
+	for _, match := range namedParamReg.FindAllStringSubmatch(text, -1) {
+		if !sliceutils.StringContains(names, match[2]) {
+			return errors.Errorf("param :%s in sql block %s is not a parameter of method %s", match[2], m.Block, m.Name)
+		}
+	}
+	for _, name := range names {
+		if !strings.Contains(text, ":"+name) && !strings.Contains(text, "."+name) {
+			log.Warnf("parameter %s of method %s is not used by sql block %s", name, m.Name, m.Block)
+		}
+	}
+	if m.Kind == "get" && m.Result == "int64" {
+		fields := strings.Fields(strings.ToLower(actionReg.ReplaceAllString(text, " ")))
+		if len(fields) > 0 && !sliceutils.StringContains([]string{"select", "with", "show"}, fields[0]) {
+			// rows affected of insert, update or delete statement
+			m.Kind = "exec"
+		}
+	}
+	return nil
+}
+
+// GenDaoQueryGo generates implementation of dao interface methods annotated with dd:sql. Each method executes the
+// named sql block in dao sql file with its parameters bound to :name params. The file is generated again every time
+// from dao interface and sql file, and removed if no method is annotated.
+func GenDaoQueryGo(domainpath string, t table.Table, folder ...string) error {
+	var (
+		err     error
+		daopath string
+		methods []queryMethod
+		imports []string
+		tpl     *template.Template
+		sqlTpl  *template.Template
+		buf     bytes.Buffer
+		df      string
+	)
+	df = "dao"
+	if len(folder) > 0 {
+		df = folder[0]
+	}
+	daopath = filepath.Join(filepath.Dir(domainpath), df)
+	name := strings.ToLower(t.Meta.Name)
+	daofile := filepath.Join(daopath, name+"dao.go")
+	sqlfile := filepath.Join(daopath, name+"dao.sql")
+	queryfile := filepath.Join(daopath, name+"daoquery.go")
+	if _, err = os.Stat(daofile); os.IsNotExist(err) {
+		return nil
+	}
+	if methods, imports, err = queryMethods(daofile, t.Meta.Name+"Dao"); err != nil {
+		return err
+	}
+	if len(methods) == 0 {
+		if err = os.Remove(queryfile); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "error")
+		}
+		return nil
+	}
+	if sqlTpl, err = templateutils.ParseMysql(sqlfile); err != nil {
+		return errors.Wrap(err, "error")
+	}
+	for i := range methods {
+		if err = methods[i].validate(sqlTpl, sqlfile); err != nil {
+			return err
+		}
+	}
+
+	fixed := []string{
+		`"context"`,
+		`"database/sql"`,
+		`"github.com/jmoiron/sqlx"`,
+		`"github.com/pkg/errors"`,
+		`"github.com/unionj-cloud/go-doudou/pathutils"`,
+		`"github.com/unionj-cloud/go-doudou/templateutils"`,
+	}
+	for _, imp := range imports {
+		if !sliceutils.StringContains(fixed, imp) {
+			fixed = append(fixed, imp)
+		}
+	}
+	if tpl, err = template.New("daoquery.go.tmpl").ParseFiles(pathutils.Abs("daoquery.go.tmpl")); err != nil {
+		return errors.Wrap(err, "error")
+	}
+	if err = tpl.Execute(&buf, struct {
+		DomainName string
+		SqlFile    string
+		Imports    []string
+		Methods    []queryMethod
+	}{
+		DomainName: t.Meta.Name,
+		SqlFile:    name + "dao.sql",
+		Imports:    fixed,
+		Methods:    methods,
+	}); err != nil {
+		return errors.Wrap(err, "error")
+	}
+	astutils.FixImport(buf.Bytes(), queryfile)
+	return nil
+}
@@ -0,0 +1,155 @@
+package codegen
+
+import (
+	"fmt"
+	"github.com/unionj-cloud/go-doudou/astutils"
+	"github.com/unionj-cloud/go-doudou/ddl/ddlast"
+	"github.com/unionj-cloud/go-doudou/ddl/table"
+	"github.com/unionj-cloud/go-doudou/pathutils"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+const purchaseDaoTmpl = `package dao
+
+import (
+	"context"
+	"testfiles/domain"
+)
+
+type PurchaseDao interface {
+	Base
+	%s
+}
+`
+
+func TestGenDaoQueryGo(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	testDir := pathutils.Abs("../testfiles")
+	if err = os.Chdir(testDir); err != nil {
+		t.Fatal(err)
+	}
+	sc := astutils.NewStructCollector(astutils.ExprString)
+	root, err := parser.ParseFile(token.NewFileSet(), testDir+"/domain/purchase.go", nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ast.Walk(sc, root)
+	purchase := table.NewTableFromStruct(ddlast.FlatEmbed(sc.Structs)[0], "")
+	sql := `{{define "FindPurchases"}}
+select * from purchase where user_id = :userId and status in (:status)
+{{end}}`
+	tests := []struct {
+		name    string
+		methods string
+		wantErr bool
+	}{
+		{
+			name: "ok",
+			methods: `// FindByUser selects purchases of user
+	//dd:sql FindPurchases
+	FindByUser(ctx context.Context, userId int, status []int8) ([]domain.Purchase, error)`,
+		},
+		{
+			name: "block not found",
+			methods: `//dd:sql
+	FindByUser(ctx context.Context, userId int, status []int8) ([]domain.Purchase, error)`,
+			wantErr: true,
+		},
+		{
+			name: "param not found",
+			methods: `//dd:sql FindPurchases
+	FindByUser(ctx context.Context, userId int) ([]domain.Purchase, error)`,
+			wantErr: true,
+		},
+		{
+			name: "no context",
+			methods: `//dd:sql FindPurchases
+	FindByUser(userId int, status []int8) ([]domain.Purchase, error)`,
+			wantErr: true,
+		},
+		{
+			name: "no error",
+			methods: `//dd:sql FindPurchases
+	FindByUser(ctx context.Context, userId int, status []int8) []domain.Purchase`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			daopath := testDir + "/dao"
+			defer os.RemoveAll(daopath)
+			if err := os.MkdirAll(daopath, os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(daopath+"/purchasedao.go", []byte(fmt.Sprintf(purchaseDaoTmpl, tt.methods)), os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(daopath+"/purchasedao.sql", []byte(sql), os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+			if err := GenDaoQueryGo(testDir+"/domain", purchase); (err != nil) != tt.wantErr {
+				t.Errorf("GenDaoQueryGo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			expect := `// Code generated by go-doudou from purchasedao.sql. DO NOT EDIT.
+
+package dao
+
+import (
+	"context"
+	"testfiles/domain"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/unionj-cloud/go-doudou/pathutils"
+	"github.com/unionj-cloud/go-doudou/templateutils"
+)
+
+// FindByUser executes sql block FindPurchases in purchasedao.sql
+func (receiver PurchaseDaoImpl) FindByUser(ctx context.Context, userId int, status []int8) ([]domain.Purchase, error) {
+	var (
+		statement string
+		args      []interface{}
+		err       error
+		result    []domain.Purchase
+	)
+	params := map[string]interface{}{
+		"userId": userId,
+		"status": status,
+	}
+	if statement, err = templateutils.StringBlockMysql(pathutils.Abs("purchasedao.sql"), "FindPurchases", params); err != nil {
+		return result, err
+	}
+	if statement, args, err = sqlx.Named(statement, params); err != nil {
+		return result, errors.Wrap(err, "error returned from calling sqlx.Named")
+	}
+	if statement, args, err = sqlx.In(statement, args...); err != nil {
+		return result, errors.Wrap(err, "error returned from calling sqlx.In")
+	}
+	if err = receiver.querier(ctx).SelectContext(ctx, &result, receiver.rebind(statement), args...); err != nil {
+		return result, errors.Wrap(err, "error returned from calling db.Select")
+	}
+	return result, nil
+}
+`
+			content, err := ioutil.ReadFile(daopath + "/purchasedaoquery.go")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != expect {
+				t.Errorf("want %s, got %s\n", expect, string(content))
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package codegen
 
 import (
+	"github.com/iancoleman/strcase"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/unionj-cloud/go-doudou/astutils"
@@ -8,10 +9,64 @@ import (
 	"github.com/unionj-cloud/go-doudou/templateutils"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"unicode"
 )
 
-func GenDomainGo(dpath string, domain astutils.StructMeta) error {
+// Enum is a string type with constants generated for an enum or set column
+type Enum struct {
+	Name   string
+	Column string
+	Values []EnumValue
+}
+
+type EnumValue struct {
+	Name  string
+	Value string
+}
+
+// NewEnum names constants of values by appending camel cased values to name, e.g. UserStatusActive
+func NewEnum(name, column string, values []string) Enum {
+	enum := Enum{
+		Name:   name,
+		Column: column,
+	}
+	used := make(map[string]bool)
+	for _, value := range values {
+		suffix := strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, strcase.ToCamel(value))
+		if suffix == "" {
+			suffix = "Empty"
+		}
+		constName := name + suffix
+		for i := 2; used[constName]; i++ {
+			constName = name + suffix + strconv.Itoa(i)
+		}
+		used[constName] = true
+		enum.Values = append(enum.Values, EnumValue{
+			Name:  constName,
+			Value: value,
+		})
+	}
+	return enum
+}
+
+type domainData struct {
+	astutils.StructMeta
+	// Doc is lines of doc comment, Directives is lines like dd:table
+	Doc        []string
+	Directives []string
+	Enums      []Enum
+}
+
+// GenDomainGo generates domain struct and string types of enums. Comments of domain starting with dd: are
+// written as directives, dd:table is written if there is none.
+func GenDomainGo(dpath string, domain astutils.StructMeta, enums ...Enum) error {
 	var (
 		err     error
 		tplpath string
@@ -29,9 +84,24 @@ func GenDomainGo(dpath string, domain astutils.StructMeta) error {
 		}
 		defer f.Close()
 
+		data := domainData{
+			StructMeta: domain,
+			Enums:      enums,
+		}
+		for _, comment := range domain.Comments {
+			if strings.HasPrefix(comment, "dd:") {
+				data.Directives = append(data.Directives, comment)
+			} else {
+				data.Doc = append(data.Doc, comment)
+			}
+		}
+		if len(data.Directives) == 0 {
+			data.Directives = []string{"dd:table"}
+		}
+
 		tplpath = pathutils.Abs("domain.go.tmpl")
 		var source string
-		if source, err = templateutils.String(tplpath, domain); err != nil {
+		if source, err = templateutils.String(tplpath, data); err != nil {
 			return errors.Wrap(err, "error")
 		}
 
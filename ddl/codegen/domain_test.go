@@ -72,3 +72,47 @@ type User struct {
 		})
 	}
 }
+
+func TestGenDomainGo_Enum(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "domain")
+	status := NewEnum("UserStatus", "status", []string{"active", "in-active", "", "Active"})
+	meta := astutils.StructMeta{
+		Name: "User",
+		Fields: []astutils.FieldMeta{
+			{Name: "Id", Type: "int", Tag: `dd:"pk;auto;type:int"`},
+			{Name: "Status", Type: "*UserStatus", Tag: `dd:"type:enum('active','in-active','','Active')"`, Comments: []string{"状态"}},
+		},
+		Comments: []string{"用户", "dd:table"},
+	}
+	if err := GenDomainGo(dir, meta, status); err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dir, "user.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `package domain
+
+// 用户
+//
+//dd:table
+type User struct {
+	Id int ` + "`" + `dd:"pk;auto;type:int"` + "`" + `
+	// 状态
+	Status *UserStatus ` + "`" + `dd:"type:enum('active','in-active','','Active')"` + "`" + `
+}
+
+// UserStatus is value of column status
+type UserStatus string
+
+const (
+	UserStatusActive   UserStatus = "active"
+	UserStatusInActive UserStatus = "in-active"
+	UserStatusEmpty    UserStatus = ""
+	UserStatusActive2  UserStatus = "Active"
+)
+`
+	if string(content) != expect {
+		t.Errorf("want %s, got %s\n", expect, string(content))
+	}
+}
@@ -1,6 +1,8 @@
 package config
 
 type DbConfig struct {
+	// Driver is the name of dialect, mysql, postgres or sqlite3
+	Driver  string `default:"mysql"`
 	Host    string
 	Port    string
 	User    string
@@ -2,10 +2,20 @@ package ddlast
 
 import (
 	. "github.com/unionj-cloud/go-doudou/astutils"
-	"github.com/unionj-cloud/go-doudou/sliceutils"
 	"strings"
 )
 
+// IsTable reports whether the struct is annotated by dd:table. gofmt moves directives to the end of doc comments,
+// so any line of the comments may be dd:table.
+func IsTable(structMeta StructMeta) bool {
+	for _, comment := range structMeta.Comments {
+		if strings.Contains(comment, "dd:table") {
+			return true
+		}
+	}
+	return false
+}
+
 func FlatEmbed(structs []StructMeta) []StructMeta {
 	structMap := make(map[string]StructMeta)
 	for _, structMeta := range structs {
@@ -15,10 +25,7 @@ func FlatEmbed(structs []StructMeta) []StructMeta {
 	}
 	var result []StructMeta
 	for _, structMeta := range structs {
-		if sliceutils.IsEmpty(structMeta.Comments) {
-			continue
-		}
-		if !strings.Contains(structMeta.Comments[0], "dd:table") {
+		if !IsTable(structMeta) {
 			continue
 		}
 		_structMeta := StructMeta{
@@ -29,13 +36,12 @@ func FlatEmbed(structs []StructMeta) []StructMeta {
 		copy(_structMeta.Comments, structMeta.Comments)
 
 		fieldMap := make(map[string]FieldMeta)
-		embedFieldMap := make(map[string]FieldMeta)
+		// embedded fields keep their declaration order, so that generated code is stable
+		var embedFields []FieldMeta
 		for _, fieldMeta := range structMeta.Fields {
 			if strings.HasPrefix(fieldMeta.Type, "embed") {
 				if embeded, exists := structMap[fieldMeta.Name]; exists {
-					for _, field := range embeded.Fields {
-						embedFieldMap[field.Name] = field
-					}
+					embedFields = append(embedFields, embeded.Fields...)
 				}
 			} else {
 				_structMeta.Fields = append(_structMeta.Fields, fieldMeta)
@@ -43,9 +49,10 @@ func FlatEmbed(structs []StructMeta) []StructMeta {
 			}
 		}
 
-		for key, field := range embedFieldMap {
-			if _, exists := fieldMap[key]; !exists {
+		for _, field := range embedFields {
+			if _, exists := fieldMap[field.Name]; !exists {
 				_structMeta.Fields = append(_structMeta.Fields, field)
+				fieldMap[field.Name] = field
 			}
 		}
 		result = append(result, _structMeta)
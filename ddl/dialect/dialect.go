@@ -0,0 +1,234 @@
+package dialect
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/unionj-cloud/go-doudou/ddl/columnenum"
+	"github.com/unionj-cloud/go-doudou/ddl/config"
+	"github.com/unionj-cloud/go-doudou/stringutils"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Default is the dialect used when no driver is configured
+const Default = "mysql"
+
+// Dialect hides the differences between databases from ddl tool and generated dao code.
+// Column types in dd tags and the query builder output are MySQL flavored, dialects translate them.
+type Dialect interface {
+	// Name returns the driver name registered to database/sql, e.g. mysql, postgres, sqlite3
+	Name() string
+	// DSN builds data source name from config
+	DSN(conf config.DbConfig) string
+	// Quote quotes an identifier
+	Quote(ident string) string
+	// QualifiedTable returns table name qualified by schema if the database supports it
+	QualifiedTable(schema, table string) string
+	// ColumnType translates column type from dd tag to native type, goType is the type of the struct field
+	ColumnType(ct columnenum.ColumnType, goType string, autoincrement bool) columnenum.ColumnType
+	// Template returns path of ddl template file create.tmpl or alter.tmpl of the dialect
+	Template(name string) string
+	// Upsert returns the clause between values and update assignments of upsert statement
+	Upsert(pk string) string
+	// Returning returns the clause appended to insert statement for reading back the generated primary key,
+	// empty if the driver supports LastInsertId
+	Returning(pk string) string
+	// Excluded returns reference to the value of col proposed for insertion, used in update assignments of upsert
+	// statement
+	Excluded(col string) string
+	// MaxParams returns the maximum number of bind parameters of a statement, multi-row statements are split by it
+	MaxParams() int
+	// LastInsertIdOfLastRow reports whether LastInsertId of a multi-row insert statement is id of the last row
+	// instead of the first row
+	LastInsertIdOfLastRow() bool
+	// TransactionalDDL reports whether ddl statements can be rolled back in a transaction instead of being
+	// committed implicitly
+	TransactionalDDL() bool
+	// Rebind rewrites backtick quoted identifiers and ? placeholders from query builder to native ones
+	Rebind(statement string) string
+	// Tables lists tables in current schema, views excluded
+	Tables(db *sqlx.DB) ([]string, error)
+	// Views lists views in current schema
+	Views(db *sqlx.DB) ([]string, error)
+	// TableComment returns comment of table, empty if the database doesn't support table comments
+	TableComment(db *sqlx.DB, table string) (string, error)
+	// Columns lists columns of table in definition order
+	Columns(db *sqlx.DB, table string) ([]Column, error)
+	// Indexes lists index items of table, primary key excluded for databases without named primary index
+	Indexes(db *sqlx.DB, table string) ([]Index, error)
+	// ForeignKeys lists foreign keys of table
+	ForeignKeys(db *sqlx.DB, table string) ([]ForeignKey, error)
+	// Retryable reports whether err is a deadlock or serialization failure, the transaction can be run again
+	Retryable(err error) bool
+}
+
+// Column is a column read from database. Type is translated back to the MySQL flavored type used in dd tags.
+type Column struct {
+	Name          string
+	Type          string
+	Nullable      bool
+	Pk            bool
+	Unsigned      bool
+	Autoincrement bool
+	Default       *string
+	// Extra is MySQL only, e.g. on update CURRENT_TIMESTAMP
+	Extra   string
+	Comment string
+	// Enum is values of an enum or set column
+	Enum []string
+}
+
+// Index is one column of an index read from database
+type Index struct {
+	Name   string
+	Unique bool
+	Column string
+	// Seq is the column sequence number in the index starting from 1
+	Seq  int
+	Desc bool
+}
+
+// ForeignKey is a single column foreign key read from database. OnDelete and OnUpdate are upper case referential
+// actions, e.g. CASCADE, SET NULL, empty for the default NO ACTION.
+type ForeignKey struct {
+	Name      string
+	Column    string
+	RefTable  string
+	RefColumn string
+	OnDelete  string
+	OnUpdate  string
+}
+
+// Action normalizes referential action read from database, NO ACTION and RESTRICT become empty
+func Action(action string) string {
+	action = strings.ToUpper(strings.TrimSpace(action))
+	if action == "NO ACTION" || action == "RESTRICT" {
+		return ""
+	}
+	return action
+}
+
+// ErrUnsupported is returned for schema changes which can't be done by alter statements of a dialect, e.g. changing
+// column of sqlite table
+var ErrUnsupported = errors.New("unsupported by dialect")
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = make(map[string]Dialect)
+)
+
+// Register makes a dialect available by its name. Dialect packages call it in init like database/sql drivers.
+func Register(d Dialect) {
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+	if d == nil {
+		panic("dialect: Register dialect is nil")
+	}
+	if _, dup := dialects[d.Name()]; dup {
+		panic("dialect: Register called twice for dialect " + d.Name())
+	}
+	dialects[d.Name()] = d
+}
+
+// Get returns the dialect registered by name, empty name means Default.
+// Blank import the dialect package, e.g. github.com/unionj-cloud/go-doudou/ddl/dialect/postgres, to register it.
+func Get(name string) (Dialect, error) {
+	if stringutils.IsEmpty(name) {
+		name = Default
+	}
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+	d, ok := dialects[name]
+	if !ok {
+		return nil, errors.Errorf("unknown dialect %s (forgotten import?), registered: %s", name, strings.Join(names(), ","))
+	}
+	return d, nil
+}
+
+// MustGet is like Get but panics if the dialect is not registered
+func MustGet(name string) Dialect {
+	d, err := Get(name)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func names() []string {
+	var list []string
+	for name := range dialects {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Requote replaces backtick quoted identifiers with ones quoted by quote. Backticks inside single or double quoted
+// string literals are kept as they are.
+func Requote(statement string, quote string) string {
+	var (
+		sb    strings.Builder
+		inStr byte
+	)
+	for i := 0; i < len(statement); i++ {
+		ch := statement[i]
+		switch {
+		case inStr != 0:
+			if ch == inStr {
+				inStr = 0
+			}
+			sb.WriteByte(ch)
+		case ch == '\'' || ch == '"':
+			inStr = ch
+			sb.WriteByte(ch)
+		case ch == '`':
+			sb.WriteString(quote)
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return sb.String()
+}
+
+// Unquote strips single quotes from string literal, e.g. default value read from database
+func Unquote(literal string) string {
+	if len(literal) > 1 && strings.HasPrefix(literal, "'") && strings.HasSuffix(literal, "'") {
+		return strings.ReplaceAll(literal[1:len(literal)-1], "''", "'")
+	}
+	return literal
+}
+
+// EnumValues parses values of enum or set column type, e.g. enum('a','b'), nil for other types
+func EnumValues(typ string) []string {
+	lower := strings.ToLower(strings.TrimSpace(typ))
+	if !(strings.HasPrefix(lower, "enum(") || strings.HasPrefix(lower, "set(")) || !strings.HasSuffix(lower, ")") {
+		return nil
+	}
+	list := strings.TrimSpace(typ)
+	list = list[strings.Index(list, "(")+1 : len(list)-1]
+	var (
+		values []string
+		sb     strings.Builder
+		inStr  bool
+	)
+	for i := 0; i < len(list); i++ {
+		ch := list[i]
+		switch {
+		case !inStr:
+			if ch == '\'' {
+				inStr = true
+				sb.Reset()
+			}
+		case ch == '\'' && i+1 < len(list) && list[i+1] == '\'':
+			sb.WriteByte(ch)
+			i++
+		case ch == '\'':
+			inStr = false
+			values = append(values, sb.String())
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return values
+}
@@ -0,0 +1,112 @@
+package dialect_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/unionj-cloud/go-doudou/ddl/dialect"
+	_ "github.com/unionj-cloud/go-doudou/ddl/dialect/mysql"
+)
+
+func TestRequote(t *testing.T) {
+	tests := []struct {
+		name      string
+		statement string
+		want      string
+	}{
+		{
+			name:      "identifiers",
+			statement: "select * from `user` where `name` = ? and `age` > ?",
+			want:      `select * from "user" where "name" = ? and "age" > ?`,
+		},
+		{
+			name:      "string literal",
+			statement: "update `user` set `note` = 'a `b`' where `id` = ?",
+			want:      `update "user" set "note" = 'a ` + "`b`" + `' where "id" = ?`,
+		},
+		{
+			name:      "escaped quote",
+			statement: "`name` = 'it''s `x`'",
+			want:      `"name" = 'it''s ` + "`x`'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dialect.Requote(tt.statement, `"`); got != tt.want {
+				t.Errorf("Requote() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnquote(t *testing.T) {
+	tests := []struct {
+		literal string
+		want    string
+	}{
+		{"'jack'", "jack"},
+		{"'it''s'", "it's"},
+		{"CURRENT_TIMESTAMP", "CURRENT_TIMESTAMP"},
+		{"'", "'"},
+		{"0", "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.literal, func(t *testing.T) {
+			if got := dialect.Unquote(tt.literal); got != tt.want {
+				t.Errorf("Unquote() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "",
+			want: "mysql",
+		},
+		{
+			name: "mysql",
+			want: "mysql",
+		},
+		{
+			name:    "oracle",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dialect.Get(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.Name() != tt.want {
+				t.Errorf("Get() = %v, want %v", got.Name(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEnumValues(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  string
+		want []string
+	}{
+		{"enum", "enum('active','inactive')", []string{"active", "inactive"}},
+		{"set", "SET('a', 'b,c')", []string{"a", "b,c"}},
+		{"escaped quote", "enum('it''s','')", []string{"it's", ""}},
+		{"varchar", "varchar(255)", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dialect.EnumValues(tt.typ); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EnumValues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package mysql
+
+import (
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/unionj-cloud/go-doudou/ddl/columnenum"
+	"github.com/unionj-cloud/go-doudou/ddl/config"
+	"github.com/unionj-cloud/go-doudou/ddl/dialect"
+	"github.com/unionj-cloud/go-doudou/pathutils"
+	"strings"
+)
+
+func init() {
+	dialect.Register(Mysql{})
+}
+
+// Mysql is the default dialect
+type Mysql struct{}
+
+func (m Mysql) Name() string {
+	return "mysql"
+}
+
+func (m Mysql) DSN(conf config.DbConfig) string {
+	conn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=%s",
+		conf.User,
+		conf.Passwd,
+		conf.Host,
+		conf.Port,
+		conf.Schema,
+		conf.Charset)
+	conn += `&loc=Asia%2FShanghai&parseTime=True`
+	return conn
+}
+
+func (m Mysql) Quote(ident string) string {
+	return "`" + ident + "`"
+}
+
+func (m Mysql) QualifiedTable(schema, table string) string {
+	return m.Quote(schema) + "." + m.Quote(table)
+}
+
+func (m Mysql) ColumnType(ct columnenum.ColumnType, goType string, autoincrement bool) columnenum.ColumnType {
+	return ct
+}
+
+func (m Mysql) Template(name string) string {
+	return pathutils.Abs(name)
+}
+
+func (m Mysql) Upsert(pk string) string {
+	return "ON DUPLICATE KEY UPDATE"
+}
+
+func (m Mysql) Returning(pk string) string {
+	return ""
+}
+
+func (m Mysql) Excluded(col string) string {
+	return fmt.Sprintf("VALUES(%s)", m.Quote(col))
+}
+
+func (m Mysql) MaxParams() int {
+	return 65535
+}
+
+// LastInsertIdOfLastRow returns false, ids generated by a multi-row insert statement are consecutive from
+// LastInsertId if innodb_autoinc_lock_mode is not 2 (interleaved), or the statement is a simple insert
+func (m Mysql) LastInsertIdOfLastRow() bool {
+	return false
+}
+
+// TransactionalDDL returns false, mysql commits current transaction implicitly before and after a ddl statement
+func (m Mysql) TransactionalDDL() bool {
+	return false
+}
+
+func (m Mysql) Rebind(statement string) string {
+	return statement
+}
+
+func (m Mysql) Tables(db *sqlx.DB) ([]string, error) {
+	var tables []string
+	if err := db.Select(&tables, `select table_name from information_schema.tables
+where table_schema = database() and table_type = 'BASE TABLE' order by table_name`); err != nil {
+		return nil, errors.Wrap(err, "failed to list tables")
+	}
+	return tables, nil
+}
+
+func (m Mysql) Views(db *sqlx.DB) ([]string, error) {
+	var views []string
+	if err := db.Select(&views, "select table_name from information_schema.views where table_schema = database() order by table_name"); err != nil {
+		return nil, errors.Wrap(err, "failed to list views")
+	}
+	return views, nil
+}
+
+func (m Mysql) TableComment(db *sqlx.DB, table string) (string, error) {
+	var comment string
+	if err := db.Get(&comment, "select table_comment from information_schema.tables where table_schema = database() and table_name = ?", table); err != nil {
+		return "", errors.Wrap(err, "failed to get table comment")
+	}
+	return comment, nil
+}
+
+type dbColumn struct {
+	Field   string  `db:"Field"`
+	Type    string  `db:"Type"`
+	Null    string  `db:"Null"`
+	Key     string  `db:"Key"`
+	Default *string `db:"Default"`
+	Extra   string  `db:"Extra"`
+	Comment string  `db:"Comment"`
+}
+
+func (m Mysql) Columns(db *sqlx.DB, table string) ([]dialect.Column, error) {
+	var rows []dbColumn
+	if err := db.Unsafe().Select(&rows, fmt.Sprintf("SHOW FULL COLUMNS FROM %s", m.Quote(table))); err != nil {
+		return nil, errors.Wrap(err, "failed to list columns")
+	}
+	var columns []dialect.Column
+	for _, row := range rows {
+		extra := row.Extra
+		if strings.Contains(extra, "auto_increment") {
+			extra = ""
+		}
+		columns = append(columns, dialect.Column{
+			Name:          row.Field,
+			Type:          row.Type,
+			Nullable:      row.Null == "YES",
+			Pk:            row.Key == "PRI",
+			Unsigned:      checkUnsigned(row.Type),
+			Autoincrement: strings.Contains(row.Extra, "auto_increment"),
+			Default:       row.Default,
+			Extra:         strings.TrimSpace(strings.TrimPrefix(extra, "DEFAULT_GENERATED")),
+			Comment:       row.Comment,
+			Enum:          dialect.EnumValues(row.Type),
+		})
+	}
+	return columns, nil
+}
+
+func checkUnsigned(dbColType string) bool {
+	splits := strings.Split(dbColType, " ")
+	if len(splits) == 1 {
+		return false
+	}
+	return splits[1] == "unsigned"
+}
+
+// https://www.mysqltutorial.org/mysql-index/mysql-show-indexes/
+type dbIndex struct {
+	NonUnique  bool    `db:"Non_unique"`   // 1 if the index can contain duplicates, 0 if it cannot.
+	KeyName    string  `db:"Key_name"`     // The name of the index. The primary key index always has the name of PRIMARY.
+	SeqInIndex int     `db:"Seq_in_index"` // The column sequence number in the index. The first column sequence number starts from 1.
+	ColumnName string  `db:"Column_name"`  // The column name
+	Collation  *string `db:"Collation"`    // A means ascending, B means descending, or NULL means not sorted.
+}
+
+func (m Mysql) Indexes(db *sqlx.DB, table string) ([]dialect.Index, error) {
+	var rows []dbIndex
+	if err := db.Unsafe().Select(&rows, fmt.Sprintf("SHOW INDEXES FROM %s", m.Quote(table))); err != nil {
+		return nil, errors.Wrap(err, "failed to list indexes")
+	}
+	var indexes []dialect.Index
+	for _, row := range rows {
+		indexes = append(indexes, dialect.Index{
+			Name:   row.KeyName,
+			Unique: !row.NonUnique,
+			Column: row.ColumnName,
+			Seq:    row.SeqInIndex,
+			Desc:   row.Collation != nil && *row.Collation == "B",
+		})
+	}
+	return indexes, nil
+}
+
+type dbForeignKey struct {
+	Name      string `db:"name"`
+	Column    string `db:"col"`
+	RefTable  string `db:"ref_table"`
+	RefColumn string `db:"ref_col"`
+	OnDelete  string `db:"on_delete"`
+	OnUpdate  string `db:"on_update"`
+}
+
+const foreignKeysSql = `select k.CONSTRAINT_NAME as name, k.COLUMN_NAME as col, k.REFERENCED_TABLE_NAME as ref_table,
+k.REFERENCED_COLUMN_NAME as ref_col, r.DELETE_RULE as on_delete, r.UPDATE_RULE as on_update
+from information_schema.KEY_COLUMN_USAGE k
+join information_schema.REFERENTIAL_CONSTRAINTS r on r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA and r.CONSTRAINT_NAME = k.CONSTRAINT_NAME
+where k.TABLE_SCHEMA = database() and k.TABLE_NAME = ? and k.REFERENCED_TABLE_NAME is not null
+order by k.CONSTRAINT_NAME, k.ORDINAL_POSITION`
+
+func (m Mysql) ForeignKeys(db *sqlx.DB, table string) ([]dialect.ForeignKey, error) {
+	var rows []dbForeignKey
+	if err := db.Select(&rows, foreignKeysSql, table); err != nil {
+		return nil, errors.Wrap(err, "failed to list foreign keys")
+	}
+	var fks []dialect.ForeignKey
+	for _, row := range rows {
+		fks = append(fks, dialect.ForeignKey{
+			Name:      row.Name,
+			Column:    row.Column,
+			RefTable:  row.RefTable,
+			RefColumn: row.RefColumn,
+			OnDelete:  dialect.Action(row.OnDelete),
+			OnUpdate:  dialect.Action(row.OnUpdate),
+		})
+	}
+	return fks, nil
+}
+
+// erLockDeadlock is error number of ER_LOCK_DEADLOCK
+const erLockDeadlock = 1213
+
+func (m Mysql) Retryable(err error) bool {
+	var me *mysql.MySQLError
+	return errors.As(err, &me) && me.Number == erLockDeadlock
+}
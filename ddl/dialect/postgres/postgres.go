@@ -0,0 +1,356 @@
+package postgres
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/unionj-cloud/go-doudou/ddl/columnenum"
+	"github.com/unionj-cloud/go-doudou/ddl/config"
+	"github.com/unionj-cloud/go-doudou/ddl/dialect"
+	"github.com/unionj-cloud/go-doudou/pathutils"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+func init() {
+	dialect.Register(Postgres{})
+}
+
+// Postgres dialect. Tables are created in the current schema of the connection, Schema of config is the database name.
+type Postgres struct{}
+
+func (p Postgres) Name() string {
+	return "postgres"
+}
+
+// DSN builds url style data source name. sslmode is disabled unless PGSSLMODE environment variable is set.
+func (p Postgres) DSN(conf config.DbConfig) string {
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(conf.User, conf.Passwd),
+		Host:   net.JoinHostPort(conf.Host, conf.Port),
+		Path:   "/" + conf.Schema,
+	}
+	if os.Getenv("PGSSLMODE") == "" {
+		u.RawQuery = "sslmode=disable"
+	}
+	return u.String()
+}
+
+func (p Postgres) Quote(ident string) string {
+	return `"` + ident + `"`
+}
+
+func (p Postgres) QualifiedTable(schema, table string) string {
+	return p.Quote(table)
+}
+
+var typeParams = regexp.MustCompile(`\(.*\)`)
+
+func (p Postgres) ColumnType(ct columnenum.ColumnType, goType string, autoincrement bool) columnenum.ColumnType {
+	typ := strings.ToUpper(strings.TrimSpace(strings.Replace(strings.ToLower(string(ct)), "unsigned", "", 1)))
+	params := typeParams.FindString(typ)
+	base := strings.TrimSpace(typeParams.ReplaceAllString(typ, ""))
+	if autoincrement {
+		if base == string(columnenum.BigintType) {
+			return "BIGSERIAL"
+		}
+		return "SERIAL"
+	}
+	if goType == "bool" || goType == "sql.NullBool" {
+		return "BOOLEAN"
+	}
+	switch columnenum.ColumnType(base) {
+	case columnenum.IntType, columnenum.MediumintType:
+		return "INTEGER"
+	case columnenum.TinyintType:
+		return columnenum.SmallintType
+	case columnenum.FloatType:
+		return "REAL"
+	case columnenum.DoubleType:
+		return "DOUBLE PRECISION"
+	case columnenum.DatetimeType:
+		return "TIMESTAMP"
+	case columnenum.MediumtextType, columnenum.LongtextType:
+		return columnenum.TextType
+	case columnenum.BlobType, columnenum.MediumblobType, columnenum.LongblobType:
+		return "BYTEA"
+	case columnenum.DecimalType:
+		return columnenum.ColumnType("NUMERIC" + params)
+	case columnenum.EnumType, "SET":
+		// inline enum types are not supported
+		return columnenum.VarcharType
+	}
+	return columnenum.ColumnType(typ)
+}
+
+func (p Postgres) Template(name string) string {
+	return pathutils.Abs(name)
+}
+
+func (p Postgres) Upsert(pk string) string {
+	return fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET", p.Quote(pk))
+}
+
+func (p Postgres) Returning(pk string) string {
+	return "RETURNING " + p.Quote(pk)
+}
+
+func (p Postgres) Excluded(col string) string {
+	return "EXCLUDED." + p.Quote(col)
+}
+
+func (p Postgres) MaxParams() int {
+	return 65535
+}
+
+// LastInsertIdOfLastRow returns false, ids are read back by RETURNING clause instead
+func (p Postgres) LastInsertIdOfLastRow() bool {
+	return false
+}
+
+func (p Postgres) TransactionalDDL() bool {
+	return true
+}
+
+func (p Postgres) Rebind(statement string) string {
+	return sqlx.Rebind(sqlx.DOLLAR, dialect.Requote(statement, `"`))
+}
+
+func (p Postgres) Tables(db *sqlx.DB) ([]string, error) {
+	var tables []string
+	if err := db.Select(&tables, `select table_name from information_schema.tables
+where table_schema = current_schema() and table_type = 'BASE TABLE' order by table_name`); err != nil {
+		return nil, errors.Wrap(err, "failed to list tables")
+	}
+	return tables, nil
+}
+
+func (p Postgres) Views(db *sqlx.DB) ([]string, error) {
+	var views []string
+	if err := db.Select(&views, `select table_name from information_schema.views
+where table_schema = current_schema() order by table_name`); err != nil {
+		return nil, errors.Wrap(err, "failed to list views")
+	}
+	return views, nil
+}
+
+func (p Postgres) TableComment(db *sqlx.DB, table string) (string, error) {
+	var comment string
+	if err := db.Get(&comment, `select coalesce(obj_description(format('%I.%I', current_schema(), $1::text)::regclass, 'pg_class'), '')`, table); err != nil {
+		return "", errors.Wrap(err, "failed to get table comment")
+	}
+	return comment, nil
+}
+
+type dbColumn struct {
+	Name      string  `db:"column_name"`
+	DataType  string  `db:"data_type"`
+	Length    *int    `db:"character_maximum_length"`
+	Precision *int    `db:"numeric_precision"`
+	Scale     *int    `db:"numeric_scale"`
+	Nullable  string  `db:"is_nullable"`
+	Default   *string `db:"column_default"`
+	Identity  string  `db:"is_identity"`
+	Pk        bool    `db:"pk"`
+	Comment   string  `db:"comment"`
+	UdtName   string  `db:"udt_name"`
+	// Enum is quoted labels of enum type separated by comma
+	Enum *string `db:"enum"`
+}
+
+const columnsSql = `select c.column_name, c.data_type, c.character_maximum_length, c.numeric_precision, c.numeric_scale,
+c.is_nullable, c.column_default, c.is_identity,
+exists (select 1 from information_schema.table_constraints tc
+	join information_schema.key_column_usage kcu on kcu.constraint_name = tc.constraint_name and kcu.table_schema = tc.table_schema
+	where tc.constraint_type = 'PRIMARY KEY' and tc.table_schema = c.table_schema and tc.table_name = c.table_name
+	and kcu.column_name = c.column_name) as pk,
+coalesce(col_description(format('%I.%I', c.table_schema, c.table_name)::regclass, c.ordinal_position), '') as comment,
+c.udt_name,
+(select string_agg(quote_literal(e.enumlabel), ',' order by e.enumsortorder) from pg_enum e
+	join pg_type t on t.oid = e.enumtypid
+	join pg_namespace n on n.oid = t.typnamespace
+	where t.typname = c.udt_name and n.nspname = c.udt_schema) as enum
+from information_schema.columns c
+where c.table_schema = current_schema() and c.table_name = $1
+order by c.ordinal_position`
+
+func (p Postgres) Columns(db *sqlx.DB, table string) ([]dialect.Column, error) {
+	var rows []dbColumn
+	if err := db.Select(&rows, columnsSql, table); err != nil {
+		return nil, errors.Wrap(err, "failed to list columns")
+	}
+	var columns []dialect.Column
+	for _, row := range rows {
+		autoincrement := row.Identity == "YES" || (row.Default != nil && strings.HasPrefix(*row.Default, "nextval("))
+		var def *string
+		if !autoincrement {
+			def = toDefault(row.Default)
+		}
+		columns = append(columns, dialect.Column{
+			Name:          row.Name,
+			Type:          toType(row),
+			Nullable:      row.Nullable == "YES",
+			Pk:            row.Pk,
+			Autoincrement: autoincrement,
+			Default:       def,
+			Comment:       row.Comment,
+			Enum:          toEnum(row.Enum),
+		})
+	}
+	return columns, nil
+}
+
+func toEnum(labels *string) []string {
+	if labels == nil {
+		return nil
+	}
+	return dialect.EnumValues("enum(" + *labels + ")")
+}
+
+// toType translates native type back to the MySQL flavored type used in dd tags
+func toType(row dbColumn) string {
+	switch row.DataType {
+	case "integer", "smallint":
+		return string(columnenum.IntType)
+	case "bigint":
+		return string(columnenum.BigintType)
+	case "boolean":
+		return string(columnenum.TinyintType)
+	case "real":
+		return string(columnenum.FloatType)
+	case "double precision":
+		return string(columnenum.DoubleType)
+	case "character varying", "character":
+		if row.Length == nil {
+			return string(columnenum.VarcharType)
+		}
+		return fmt.Sprintf("VARCHAR(%d)", *row.Length)
+	case "text":
+		return string(columnenum.TextType)
+	case "numeric":
+		if row.Precision == nil {
+			return string(columnenum.DecimalType)
+		}
+		scale := 0
+		if row.Scale != nil {
+			scale = *row.Scale
+		}
+		return fmt.Sprintf("DECIMAL(%d,%d)", *row.Precision, scale)
+	case "date", "timestamp without time zone", "timestamp with time zone":
+		return string(columnenum.DatetimeType)
+	case "USER-DEFINED":
+		// e.g. enum types created by CREATE TYPE
+		return row.UdtName
+	}
+	return strings.ToUpper(row.DataType)
+}
+
+var typeCast = regexp.MustCompile(`::[a-z ]+(\(\d+(,\d+)?\))?$`)
+
+// toDefault strips type cast and quotes from default expression, e.g. 'abc'::character varying
+func toDefault(def *string) *string {
+	if def == nil {
+		return nil
+	}
+	val := typeCast.ReplaceAllString(*def, "")
+	switch {
+	case strings.EqualFold(val, "NULL"):
+		return nil
+	case strings.EqualFold(val, "now()"), strings.EqualFold(val, "CURRENT_TIMESTAMP"):
+		val = "CURRENT_TIMESTAMP"
+	default:
+		val = dialect.Unquote(val)
+	}
+	return &val
+}
+
+const indexesSql = `select i.relname as name, ix.indisunique as uniq, a.attname as col, k.ord as seq,
+(ix.indoption[k.ord - 1] & 1) = 1 as descending
+from pg_index ix
+join pg_class t on t.oid = ix.indrelid
+join pg_class i on i.oid = ix.indexrelid
+join pg_namespace n on n.oid = t.relnamespace
+cross join lateral unnest(ix.indkey::int2[]) with ordinality as k(attnum, ord)
+join pg_attribute a on a.attrelid = t.oid and a.attnum = k.attnum
+where n.nspname = current_schema() and t.relname = $1 and not ix.indisprimary
+order by i.relname, k.ord`
+
+type dbIndex struct {
+	Name   string `db:"name"`
+	Unique bool   `db:"uniq"`
+	Column string `db:"col"`
+	Seq    int    `db:"seq"`
+	Desc   bool   `db:"descending"`
+}
+
+// Indexes strips table name prefix added by create.tmpl from index names, because index names are
+// unique in schema rather than in table
+func (p Postgres) Indexes(db *sqlx.DB, table string) ([]dialect.Index, error) {
+	var rows []dbIndex
+	if err := db.Select(&rows, indexesSql, table); err != nil {
+		return nil, errors.Wrap(err, "failed to list indexes")
+	}
+	var indexes []dialect.Index
+	for _, row := range rows {
+		indexes = append(indexes, dialect.Index{
+			Name:   strings.TrimPrefix(row.Name, table+"_"),
+			Unique: row.Unique,
+			Column: row.Column,
+			Seq:    row.Seq,
+			Desc:   row.Desc,
+		})
+	}
+	return indexes, nil
+}
+
+const foreignKeysSql = `select c.conname as name, a.attname as col, rt.relname as ref_table, ra.attname as ref_col,
+case c.confdeltype when 'c' then 'CASCADE' when 'n' then 'SET NULL' when 'd' then 'SET DEFAULT' when 'r' then 'RESTRICT' else '' end as on_delete,
+case c.confupdtype when 'c' then 'CASCADE' when 'n' then 'SET NULL' when 'd' then 'SET DEFAULT' when 'r' then 'RESTRICT' else '' end as on_update
+from pg_constraint c
+join pg_class t on t.oid = c.conrelid
+join pg_namespace n on n.oid = t.relnamespace
+join pg_class rt on rt.oid = c.confrelid
+join pg_attribute a on a.attrelid = c.conrelid and a.attnum = c.conkey[1]
+join pg_attribute ra on ra.attrelid = c.confrelid and ra.attnum = c.confkey[1]
+where c.contype = 'f' and n.nspname = current_schema() and t.relname = $1
+order by c.conname`
+
+type dbForeignKey struct {
+	Name      string `db:"name"`
+	Column    string `db:"col"`
+	RefTable  string `db:"ref_table"`
+	RefColumn string `db:"ref_col"`
+	OnDelete  string `db:"on_delete"`
+	OnUpdate  string `db:"on_update"`
+}
+
+// ForeignKeys lists foreign keys of table, only the first column of composite foreign keys is read
+func (p Postgres) ForeignKeys(db *sqlx.DB, table string) ([]dialect.ForeignKey, error) {
+	var rows []dbForeignKey
+	if err := db.Select(&rows, foreignKeysSql, table); err != nil {
+		return nil, errors.Wrap(err, "failed to list foreign keys")
+	}
+	var fks []dialect.ForeignKey
+	for _, row := range rows {
+		fks = append(fks, dialect.ForeignKey{
+			Name:      row.Name,
+			Column:    row.Column,
+			RefTable:  row.RefTable,
+			RefColumn: row.RefColumn,
+			OnDelete:  dialect.Action(row.OnDelete),
+			OnUpdate:  dialect.Action(row.OnUpdate),
+		})
+	}
+	return fks, nil
+}
+
+// Retryable reports deadlock_detected and serialization_failure errors
+func (p Postgres) Retryable(err error) bool {
+	var pe *pq.Error
+	return errors.As(err, &pe) && (pe.Code == "40P01" || pe.Code == "40001")
+}
@@ -0,0 +1,15 @@
+//go:build cgo
+// +build cgo
+
+package sqlite
+
+import (
+	"github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+)
+
+// Retryable reports database is locked errors, sqlite locks the whole database instead of detecting deadlocks
+func (s Sqlite) Retryable(err error) bool {
+	var se sqlite3.Error
+	return errors.As(err, &se) && (se.Code == sqlite3.ErrBusy || se.Code == sqlite3.ErrLocked)
+}
@@ -0,0 +1,9 @@
+//go:build !cgo
+// +build !cgo
+
+package sqlite
+
+// Retryable reports nothing without cgo, go-sqlite3 can't open any database then
+func (s Sqlite) Retryable(err error) bool {
+	return false
+}
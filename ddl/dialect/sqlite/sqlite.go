@@ -0,0 +1,226 @@
+package sqlite
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+	"github.com/unionj-cloud/go-doudou/ddl/columnenum"
+	"github.com/unionj-cloud/go-doudou/ddl/config"
+	"github.com/unionj-cloud/go-doudou/ddl/dialect"
+	"github.com/unionj-cloud/go-doudou/pathutils"
+	"strings"
+)
+
+func init() {
+	dialect.Register(Sqlite{})
+}
+
+// Sqlite dialect. Schema of config is the path of database file, e.g. test.db or :memory:
+type Sqlite struct{}
+
+func (s Sqlite) Name() string {
+	return "sqlite3"
+}
+
+// DSN turns on foreign key enforcement unless the schema already has query parameters
+func (s Sqlite) DSN(conf config.DbConfig) string {
+	if strings.Contains(conf.Schema, "?") {
+		return conf.Schema
+	}
+	return conf.Schema + "?_foreign_keys=1"
+}
+
+func (s Sqlite) Quote(ident string) string {
+	return `"` + ident + `"`
+}
+
+func (s Sqlite) QualifiedTable(schema, table string) string {
+	return s.Quote(table)
+}
+
+// ColumnType keeps types as they are thanks to type affinity of sqlite, except that
+// autoincrement primary key must be INTEGER and ENUM and SET with value lists are not valid type names
+func (s Sqlite) ColumnType(ct columnenum.ColumnType, goType string, autoincrement bool) columnenum.ColumnType {
+	if autoincrement {
+		return "INTEGER"
+	}
+	typ := strings.ToUpper(strings.TrimSpace(string(ct)))
+	if strings.HasPrefix(typ, string(columnenum.EnumType)+"(") || strings.HasPrefix(typ, "SET(") {
+		return columnenum.VarcharType
+	}
+	return ct
+}
+
+func (s Sqlite) Template(name string) string {
+	return pathutils.Abs(name)
+}
+
+func (s Sqlite) Upsert(pk string) string {
+	return fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET", s.Quote(pk))
+}
+
+func (s Sqlite) Returning(pk string) string {
+	return ""
+}
+
+func (s Sqlite) Excluded(col string) string {
+	return "excluded." + s.Quote(col)
+}
+
+// MaxParams returns SQLITE_MAX_VARIABLE_NUMBER, which defaults to 32766 since SQLite 3.32.0
+func (s Sqlite) MaxParams() int {
+	return 32766
+}
+
+func (s Sqlite) LastInsertIdOfLastRow() bool {
+	return true
+}
+
+func (s Sqlite) TransactionalDDL() bool {
+	return true
+}
+
+// Rebind does nothing, sqlite accepts both backtick quoted identifiers and ? placeholders
+func (s Sqlite) Rebind(statement string) string {
+	return statement
+}
+
+func (s Sqlite) Tables(db *sqlx.DB) ([]string, error) {
+	var tables []string
+	if err := db.Select(&tables, "select name from sqlite_master where type = 'table' and name not like 'sqlite_%' order by name"); err != nil {
+		return nil, errors.Wrap(err, "failed to list tables")
+	}
+	return tables, nil
+}
+
+func (s Sqlite) Views(db *sqlx.DB) ([]string, error) {
+	var views []string
+	if err := db.Select(&views, "select name from sqlite_master where type = 'view' order by name"); err != nil {
+		return nil, errors.Wrap(err, "failed to list views")
+	}
+	return views, nil
+}
+
+// TableComment returns empty string, sqlite doesn't support table comments
+func (s Sqlite) TableComment(db *sqlx.DB, table string) (string, error) {
+	return "", nil
+}
+
+type tableInfo struct {
+	Cid     int     `db:"cid"`
+	Name    string  `db:"name"`
+	Type    string  `db:"type"`
+	NotNull bool    `db:"notnull"`
+	Default *string `db:"dflt_value"`
+	Pk      int     `db:"pk"`
+}
+
+func (s Sqlite) Columns(db *sqlx.DB, table string) ([]dialect.Column, error) {
+	var rows []tableInfo
+	if err := db.Select(&rows, fmt.Sprintf("pragma table_info(%s)", s.Quote(table))); err != nil {
+		return nil, errors.Wrap(err, "failed to list columns")
+	}
+	var ddl string
+	if err := db.Get(&ddl, "select sql from sqlite_master where type in ('table', 'view') and name = ?", table); err != nil {
+		return nil, errors.Wrap(err, "failed to get table definition")
+	}
+	// only an INTEGER PRIMARY KEY column can be AUTOINCREMENT
+	autoincrement := strings.Contains(strings.ToUpper(ddl), "AUTOINCREMENT")
+	var columns []dialect.Column
+	for _, row := range rows {
+		typ := strings.ToUpper(row.Type)
+		if typ == "INTEGER" {
+			typ = string(columnenum.IntType)
+		}
+		def := row.Default
+		if def != nil {
+			val := dialect.Unquote(*def)
+			def = &val
+		}
+		columns = append(columns, dialect.Column{
+			Name:          row.Name,
+			Type:          typ,
+			Nullable:      !row.NotNull && row.Pk == 0,
+			Pk:            row.Pk > 0,
+			Autoincrement: row.Pk > 0 && autoincrement,
+			Default:       def,
+		})
+	}
+	return columns, nil
+}
+
+type indexList struct {
+	Name   string `db:"name"`
+	Unique bool   `db:"unique"`
+	Origin string `db:"origin"`
+}
+
+type indexInfo struct {
+	Seqno int `db:"seqno"`
+	// Name is null for rowid of auxiliary columns
+	Name *string `db:"name"`
+	Desc bool    `db:"desc"`
+	Key  bool    `db:"key"`
+}
+
+// Indexes strips table name prefix added by create.tmpl from index names, because index names are
+// unique in database rather than in table
+func (s Sqlite) Indexes(db *sqlx.DB, table string) ([]dialect.Index, error) {
+	var list []indexList
+	if err := db.Unsafe().Select(&list, fmt.Sprintf("pragma index_list(%s)", s.Quote(table))); err != nil {
+		return nil, errors.Wrap(err, "failed to list indexes")
+	}
+	var indexes []dialect.Index
+	for _, idx := range list {
+		// indexes of primary key and unique constraints can't be dropped
+		if idx.Origin != "c" {
+			continue
+		}
+		var infos []indexInfo
+		if err := db.Unsafe().Select(&infos, fmt.Sprintf("pragma index_xinfo(%s)", s.Quote(idx.Name))); err != nil {
+			return nil, errors.Wrap(err, "failed to list index columns")
+		}
+		for _, info := range infos {
+			if !info.Key || info.Name == nil {
+				continue
+			}
+			indexes = append(indexes, dialect.Index{
+				Name:   strings.TrimPrefix(idx.Name, table+"_"),
+				Unique: idx.Unique,
+				Column: *info.Name,
+				Seq:    info.Seqno + 1,
+				Desc:   info.Desc,
+			})
+		}
+	}
+	return indexes, nil
+}
+
+type foreignKeyList struct {
+	Table    string `db:"table"`
+	From     string `db:"from"`
+	To       string `db:"to"`
+	OnUpdate string `db:"on_update"`
+	OnDelete string `db:"on_delete"`
+}
+
+// ForeignKeys names foreign keys like create.tmpl does, because sqlite doesn't keep constraint names
+func (s Sqlite) ForeignKeys(db *sqlx.DB, table string) ([]dialect.ForeignKey, error) {
+	var rows []foreignKeyList
+	if err := db.Unsafe().Select(&rows, fmt.Sprintf("pragma foreign_key_list(%s)", s.Quote(table))); err != nil {
+		return nil, errors.Wrap(err, "failed to list foreign keys")
+	}
+	var fks []dialect.ForeignKey
+	for _, row := range rows {
+		fks = append(fks, dialect.ForeignKey{
+			Name:      fmt.Sprintf("fk_%s_%s", table, row.From),
+			Column:    row.From,
+			RefTable:  row.Table,
+			RefColumn: row.To,
+			OnDelete:  dialect.Action(row.OnDelete),
+			OnUpdate:  dialect.Action(row.OnUpdate),
+		})
+	}
+	return fks, nil
+}
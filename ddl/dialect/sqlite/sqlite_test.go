@@ -0,0 +1,247 @@
+package sqlite
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/unionj-cloud/go-doudou/astutils"
+	"github.com/unionj-cloud/go-doudou/ddl/columnenum"
+	"github.com/unionj-cloud/go-doudou/ddl/ddlast"
+	"github.com/unionj-cloud/go-doudou/ddl/dialect"
+	"github.com/unionj-cloud/go-doudou/ddl/table"
+	"github.com/unionj-cloud/go-doudou/pathutils"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func userTable(t *testing.T) table.Table {
+	sc := astutils.NewStructCollector(astutils.ExprString)
+	for _, file := range []string{"user.go", "base.go"} {
+		root, err := parser.ParseFile(token.NewFileSet(), pathutils.Abs(filepath.Join("../../testfiles/domain", file)), nil, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Walk(sc, root)
+	}
+	for _, sm := range ddlast.FlatEmbed(sc.Structs) {
+		if sm.Name == "User" {
+			return table.NewTableFromStruct(sm)
+		}
+	}
+	t.Fatal("User not found")
+	return table.Table{}
+}
+
+func TestSqlite_CreateSql(t *testing.T) {
+	ut := userTable(t)
+	got, err := ut.CreateSql(Sqlite{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `CREATE TABLE "user" (
+"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+"name" VARCHAR(255) NOT NULL DEFAULT 'jack',
+"phone" VARCHAR(255) NOT NULL DEFAULT '13552053960',
+"age" INT NOT NULL,
+"no" INT NOT NULL,
+"school" VARCHAR(255) NULL DEFAULT 'harvard',
+"is_student" TINYINT NOT NULL,
+"create_at" DATETIME NULL DEFAULT CURRENT_TIMESTAMP,
+"update_at" DATETIME NULL DEFAULT CURRENT_TIMESTAMP,
+"delete_at" DATETIME NULL);
+CREATE INDEX "user_name_phone_idx" ON "user" ("phone" asc,"name" asc);
+CREATE INDEX "user_age_idx" ON "user" ("age" asc);
+CREATE UNIQUE INDEX "user_no_idx" ON "user" ("no" asc);`
+	// order of embedded columns and indexes is not stable
+	if !reflect.DeepEqual(lines(got), lines(want)) {
+		t.Errorf("CreateSql() got = %v, want %v", got, want)
+	}
+}
+
+func TestSqlite_ColumnType(t *testing.T) {
+	tests := []struct {
+		ct            columnenum.ColumnType
+		goType        string
+		autoincrement bool
+		want          columnenum.ColumnType
+	}{
+		{"INT", "int", true, "INTEGER"},
+		{"INT unsigned", "uint", false, "INT unsigned"},
+		{"DECIMAL(20,6)", "decimal.Decimal", false, "DECIMAL(20,6)"},
+		{"JSON", "json.RawMessage", false, "JSON"},
+		{"enum('male','female')", "string", false, "VARCHAR(255)"},
+		{"set('a','b')", "string", false, "VARCHAR(255)"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.ct), func(t *testing.T) {
+			if got := (Sqlite{}).ColumnType(tt.ct, tt.goType, tt.autoincrement); got != tt.want {
+				t.Errorf("ColumnType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func lines(statement string) []string {
+	var result []string
+	for _, line := range strings.Split(statement, "\n") {
+		result = append(result, strings.TrimRight(line, ",);"))
+	}
+	sort.Strings(result)
+	return result
+}
+
+func TestSqlite_Introspection(t *testing.T) {
+	db, err := sqlx.Connect("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ut := userTable(t)
+	if err = table.CreateTable(db, ut); err != nil {
+		t.Fatal(err)
+	}
+	if err = table.AddColumn(db, table.Column{Table: "user", Name: "email", Type: columnenum.VarcharType, Nullable: true}); err != nil {
+		t.Fatal(err)
+	}
+	// changing column is not supported by sqlite, it is skipped
+	if err = table.ChangeColumn(db, ut.Columns[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = db.Exec(`CREATE VIEW "user_name" AS SELECT "id", "name" FROM "user"`); err != nil {
+		t.Fatal(err)
+	}
+
+	d := Sqlite{}
+	tables, err := d.Tables(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tables, []string{"user"}) {
+		t.Errorf("Tables() got = %v, want [user]", tables)
+	}
+	views, err := d.Views(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(views, []string{"user_name"}) {
+		t.Errorf("Views() got = %v, want [user_name]", views)
+	}
+	viewColumns, err := d.Columns(db, "user_name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(viewColumns) != 2 || viewColumns[1].Name != "name" {
+		t.Errorf("Columns() of view got = %v", viewColumns)
+	}
+
+	columns, err := d.Columns(db, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jack := "jack"
+	now := "CURRENT_TIMESTAMP"
+	tests := []struct {
+		name string
+		want dialect.Column
+	}{
+		{
+			name: "id",
+			want: dialect.Column{Name: "id", Type: "INT", Pk: true, Autoincrement: true},
+		},
+		{
+			name: "name",
+			want: dialect.Column{Name: "name", Type: "VARCHAR(255)", Default: &jack},
+		},
+		{
+			name: "create_at",
+			want: dialect.Column{Name: "create_at", Type: "DATETIME", Nullable: true, Default: &now},
+		},
+		{
+			name: "email",
+			want: dialect.Column{Name: "email", Type: "VARCHAR(255)", Nullable: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, col := range columns {
+				if col.Name == tt.name {
+					if !reflect.DeepEqual(col, tt.want) {
+						t.Errorf("Columns() got = %+v, want %+v", col, tt.want)
+					}
+					return
+				}
+			}
+			t.Errorf("column %s not found", tt.name)
+		})
+	}
+
+	indexes, err := d.Indexes(db, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[dialect.Index]bool{
+		{Name: "name_phone_idx", Column: "phone", Seq: 1}:    true,
+		{Name: "name_phone_idx", Column: "name", Seq: 2}:     true,
+		{Name: "age_idx", Column: "age", Seq: 1}:             true,
+		{Name: "no_idx", Unique: true, Column: "no", Seq: 1}: true,
+	}
+	if len(indexes) != len(want) {
+		t.Fatalf("Indexes() got = %+v", indexes)
+	}
+	for _, idx := range indexes {
+		if !want[idx] {
+			t.Errorf("unexpected index %+v", idx)
+		}
+	}
+}
+
+func TestSqlite_ForeignKeys(t *testing.T) {
+	db, err := sqlx.Connect("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	purchase := table.Table{
+		Name: "purchase",
+		Pk:   "id",
+		Columns: []table.Column{
+			{Table: "purchase", Name: "id", Type: columnenum.IntType, Pk: true, Autoincrement: true},
+			{Table: "purchase", Name: "user_id", Type: columnenum.IntType},
+		},
+		Fks: []table.ForeignKey{
+			{Name: "fk_purchase_user_id", Column: "user_id", RefTable: "user", RefColumn: "id", OnDelete: "CASCADE"},
+		},
+	}
+	got, err := purchase.CreateSql(Sqlite{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `CREATE TABLE "purchase" (
+"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+"user_id" INT NOT NULL,
+CONSTRAINT "fk_purchase_user_id" FOREIGN KEY ("user_id") REFERENCES "user" ("id") ON DELETE CASCADE);`
+	if strings.TrimSpace(got) != want {
+		t.Errorf("CreateSql() got = %v, want %v", got, want)
+	}
+	if err = table.CreateTable(db, userTable(t)); err != nil {
+		t.Fatal(err)
+	}
+	if err = table.CreateTable(db, purchase); err != nil {
+		t.Fatal(err)
+	}
+	fks, err := Sqlite{}.ForeignKeys(db, "purchase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantFks := []dialect.ForeignKey{
+		{Name: "fk_purchase_user_id", Column: "user_id", RefTable: "user", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+	if !reflect.DeepEqual(fks, wantFks) {
+		t.Errorf("ForeignKeys() got = %+v, want %+v", fks, wantFks)
+	}
+}
@@ -1,9 +1,7 @@
 package ddl
 
 import (
-	"fmt"
 	mapset "github.com/deckarep/golang-set"
-	_ "github.com/go-sql-driver/mysql"
 	"github.com/iancoleman/strcase"
 	"github.com/jmoiron/sqlx"
 	"github.com/sirupsen/logrus"
@@ -12,8 +10,11 @@ import (
 	"github.com/unionj-cloud/go-doudou/ddl/columnenum"
 	"github.com/unionj-cloud/go-doudou/ddl/config"
 	"github.com/unionj-cloud/go-doudou/ddl/ddlast"
+	"github.com/unionj-cloud/go-doudou/ddl/dialect"
+	_ "github.com/unionj-cloud/go-doudou/ddl/dialect/mysql"
+	_ "github.com/unionj-cloud/go-doudou/ddl/dialect/postgres"
+	_ "github.com/unionj-cloud/go-doudou/ddl/dialect/sqlite"
 	"github.com/unionj-cloud/go-doudou/ddl/extraenum"
-	"github.com/unionj-cloud/go-doudou/ddl/sortenum"
 	"github.com/unionj-cloud/go-doudou/ddl/table"
 	"github.com/unionj-cloud/go-doudou/sliceutils"
 	"github.com/unionj-cloud/go-doudou/stringutils"
@@ -32,77 +33,221 @@ type Ddl struct {
 	Pre     string
 	Df      string
 	Conf    config.DbConfig
+	// DryRun prints schema changes without touching database or writing migration files
+	DryRun bool
+	// Migrate writes schema changes to migration files in MigrationDir instead of altering tables
+	Migrate       bool
+	MigrationDir  string
+	MigrationName string
+	// Drop drops columns which are removed from domain structs when updating existing tables
+	Drop bool
 }
 
-func (d Ddl) Exec() {
-	var db *sqlx.DB
-	var err error
-	conf := d.Conf
-	conn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=%s",
-		conf.User,
-		conf.Passwd,
-		conf.Host,
-		conf.Port,
-		conf.Schema,
-		conf.Charset)
-	conn += `&loc=Asia%2FShanghai&parseTime=True`
-	db, err = sqlx.Connect("mysql", conn)
+func connect(conf config.DbConfig) (*sqlx.DB, dialect.Dialect) {
+	dia, err := dialect.Get(conf.Driver)
+	if err != nil {
+		logrus.Panicln(err)
+	}
+	db, err := sqlx.Connect(dia.Name(), dia.DSN(conf))
 	if err != nil {
 		logrus.Panicln(err)
 	}
-	defer db.Close()
 	db.MapperFunc(strcase.ToSnake)
-	db = db.Unsafe()
+	return db.Unsafe(), dia
+}
 
-	var existTables []string
-	if err = db.Select(&existTables, "show tables"); err != nil {
+// update changes existing table to match domain struct. Stale foreign keys and indexes are dropped first, then
+// columns are renamed, changed, added and dropped, at last new indexes and foreign keys are added.
+func (d Ddl) update(db *sqlx.DB, dia dialect.Dialect, t table.Table) {
+	columns, err := dia.Columns(db, t.Name)
+	if err != nil {
+		logrus.Panicln(err)
+	}
+	dbIndice, err := dia.Indexes(db, t.Name)
+	if err != nil {
+		logrus.Panicln(err)
+	}
+	dbFks, err := dia.ForeignKeys(db, t.Name)
+	if err != nil {
 		logrus.Panicln(err)
 	}
+	existColSet := mapset.NewSet()
+	for _, dbCol := range columns {
+		existColSet.Add(dbCol.Name)
+	}
 
-	var tables []table.Table
-	if !d.Reverse {
-		var files []string
-		err = filepath.Walk(d.Dir, astutils.Visit(&files))
-		if err != nil {
-			logrus.Panicln(err)
+	addFks, dropFks := table.DiffFks(t.Fks, table.FksFromDb(dbFks))
+	for _, fk := range dropFks {
+		if err = table.DropFk(db, t, fk); err != nil {
+			logrus.Infof("FATAL: %+v\n", err)
 		}
-		sc := astutils.NewStructCollector(astutils.ExprString)
-		for _, file := range files {
-			fset := token.NewFileSet()
-			root, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
-			if err != nil {
-				logrus.Panicln(err)
+	}
+
+	addIndexes, dropIndexes := table.DiffIndexes(t.Indexes, table.IndexesFromDb(dbIndice, dbFks))
+	for _, idx := range dropIndexes {
+		if err = table.DropIndex(db, t, idx); err != nil {
+			logrus.Infof("FATAL: %+v\n", err)
+		}
+	}
+
+	colSet := mapset.NewSet()
+	for _, col := range t.Columns {
+		colSet.Add(col.Name)
+		if !existColSet.Contains(col.Name) && stringutils.IsNotEmpty(col.OldName) && existColSet.Contains(col.OldName) {
+			colSet.Add(col.OldName)
+			if err = table.RenameColumn(db, col); err != nil {
+				logrus.Infof("FATAL: %+v\n", err)
+				continue
 			}
-			ast.Walk(sc, root)
+			existColSet.Add(col.Name)
 		}
+		if existColSet.Contains(col.Name) {
+			if err = table.ChangeColumn(db, col); err != nil {
+				logrus.Infof("FATAL: %+v\n", err)
+			}
+		} else {
+			if err = table.AddColumn(db, col); err != nil {
+				logrus.Infof("FATAL: %+v\n", err)
+			}
+		}
+	}
+
+	for _, dbCol := range columns {
+		if colSet.Contains(dbCol.Name) {
+			continue
+		}
+		if !d.Drop {
+			logrus.Warnf("column %s of table %s is not in domain struct, use --drop to drop it", dbCol.Name, t.Name)
+			continue
+		}
+		if err = table.DropColumn(db, table.Column{Table: t.Name, Name: dbCol.Name}); err != nil {
+			logrus.Infof("FATAL: %+v\n", err)
+		}
+	}
+
+	if stringutils.IsNotEmpty(t.Comment) {
+		if err = table.CommentTable(db, t); err != nil {
+			logrus.Infof("FATAL: %+v\n", err)
+		}
+	}
+
+	for _, idx := range addIndexes {
+		if err = table.AddIndex(db, t, idx); err != nil {
+			logrus.Infof("FATAL: %+v\n", err)
+		}
+	}
 
-		flattened := ddlast.FlatEmbed(sc.Structs)
-		for _, sm := range flattened {
-			tables = append(tables, table.NewTableFromStruct(sm, d.Pre))
+	for _, fk := range addFks {
+		if err = table.AddFk(db, t, fk); err != nil {
+			logrus.Infof("FATAL: %+v\n", err)
+		}
+	}
+}
+
+// reverseColumns converts columns read from database to columns of table and fields of domain struct named name.
+// A string type with constants is generated for each enum or set column.
+func reverseColumns(t, name string, columns []dialect.Column, colIdxMap map[string][]table.IndexItem,
+	colFkMap map[string]table.ForeignKey) ([]table.Column, []astutils.FieldMeta, []codegen.Enum) {
+	var (
+		cols   []table.Column
+		fields []astutils.FieldMeta
+		enums  []codegen.Enum
+	)
+	for _, item := range columns {
+		col := table.Column{
+			Table:         t,
+			Name:          item.Name,
+			Type:          columnenum.ColumnType(item.Type),
+			Default:       item.Default,
+			Pk:            item.Pk,
+			Nullable:      item.Nullable,
+			Unsigned:      item.Unsigned,
+			Autoincrement: item.Autoincrement,
+			Extra:         extraenum.Extra(item.Extra),
+			AutoSet:       table.CheckAutoSet(item.Default),
+			Indexes:       colIdxMap[item.Name],
+			Comment:       item.Comment,
+			Enum:          item.Enum,
+		}
+		if fk, ok := colFkMap[item.Name]; ok {
+			col.Fk = &fk
+		}
+		col.Meta = table.NewFieldFromColumn(col)
+		if len(item.Enum) > 0 {
+			enum := codegen.NewEnum(name+col.Meta.Name, item.Name, item.Enum)
+			col.Meta.Type = strings.Replace(col.Meta.Type, "string", enum.Name, 1)
+			enums = append(enums, enum)
+		}
+		fields = append(fields, col.Meta)
+		cols = append(cols, col)
+	}
+	return cols, fields, enums
+}
+
+func docLines(comment string) []string {
+	if stringutils.IsEmpty(comment) {
+		return nil
+	}
+	return strings.Split(comment, "\n")
+}
+
+func (d Ddl) genDomain(domain astutils.StructMeta, enums []codegen.Enum) {
+	dfile := filepath.Join(d.Dir, strings.ToLower(domain.Name)+".go")
+	if _, err := os.Stat(dfile); os.IsNotExist(err) {
+		if err = codegen.GenDomainGo(d.Dir, domain, enums...); err != nil {
+			logrus.Errorf("FATAL: %+v\n", err)
+		}
+	} else {
+		logrus.Warnf("file %s already exists", dfile)
+	}
+}
+
+// domainTables parses structs annotated by dd:table in Dir, referenced tables come first
+func (d Ddl) domainTables() []table.Table {
+	var files []string
+	err := filepath.Walk(d.Dir, astutils.Visit(&files))
+	if err != nil {
+		logrus.Panicln(err)
+	}
+	sc := astutils.NewStructCollector(astutils.ExprString)
+	for _, file := range files {
+		fset := token.NewFileSet()
+		root, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+		if err != nil {
+			logrus.Panicln(err)
+		}
+		ast.Walk(sc, root)
+	}
+
+	var tables []table.Table
+	flattened := ddlast.FlatEmbed(sc.Structs)
+	for _, sm := range flattened {
+		tables = append(tables, table.NewTableFromStruct(sm, d.Pre))
+	}
+	return table.SortByFk(tables)
+}
+
+func (d Ddl) Exec() {
+	var db *sqlx.DB
+	var err error
+	db, dia := connect(d.Conf)
+	defer db.Close()
+
+	var existTables []string
+	if existTables, err = dia.Tables(db); err != nil {
+		logrus.Panicln(err)
+	}
+
+	var tables []table.Table
+	if !d.Reverse {
+		tables = d.domainTables()
+		if d.DryRun || d.Migrate {
+			d.diff(db, dia, tables)
+			return
 		}
 		for _, t := range tables {
 			if sliceutils.StringContains(existTables, t.Name) {
-				var columns []table.DbColumn
-				if err = db.Select(&columns, fmt.Sprintf("desc %s", t.Name)); err != nil {
-					logrus.Panicln(err)
-				}
-				var existColumnNames []interface{}
-				for _, dbCol := range columns {
-					existColumnNames = append(existColumnNames, dbCol.Field)
-				}
-				existColSet := mapset.NewSetFromSlice(existColumnNames)
-
-				for _, col := range t.Columns {
-					if existColSet.Contains(col.Name) {
-						if err = table.ChangeColumn(db, col); err != nil {
-							logrus.Infof("FATAL: %+v\n", err)
-						}
-					} else {
-						if err = table.AddColumn(db, col); err != nil {
-							logrus.Infof("FATAL: %+v\n", err)
-						}
-					}
-				}
+				d.update(db, dia, t)
 			} else {
 				if err = table.CreateTable(db, t); err != nil {
 					logrus.Errorf("FATAL: %+v\n", err)
@@ -117,100 +262,45 @@ func (d Ddl) Exec() {
 			if stringutils.IsNotEmpty(d.Pre) && !strings.HasPrefix(t, d.Pre) {
 				continue
 			}
-			var dbIndice []table.DbIndex
-			if err = db.Select(&dbIndice, fmt.Sprintf("SHOW INDEXES FROM %s", t)); err != nil {
+			var dbIndice []dialect.Index
+			if dbIndice, err = dia.Indexes(db, t); err != nil {
 				logrus.Panicln(err)
 			}
 
-			idxMap := make(map[string][]table.DbIndex)
-
-			for _, idx := range dbIndice {
-				if val, exists := idxMap[idx.Key_name]; exists {
-					val = append(val, idx)
-					idxMap[idx.Key_name] = val
-				} else {
-					idxMap[idx.Key_name] = []table.DbIndex{
-						idx,
-					}
-				}
+			var dbFks []dialect.ForeignKey
+			if dbFks, err = dia.ForeignKeys(db, t); err != nil {
+				logrus.Panicln(err)
+			}
+			fks := table.FksFromDb(dbFks)
+			colFkMap := make(map[string]table.ForeignKey)
+			for _, fk := range fks {
+				colFkMap[fk.Column] = fk
 			}
 
-			var indexes []table.Index
+			indexes := table.IndexesFromDb(dbIndice, dbFks)
 			colIdxMap := make(map[string][]table.IndexItem)
-			for k, v := range idxMap {
-				if len(v) == 0 {
-					continue
+			for _, idx := range indexes {
+				for _, item := range idx.Items {
+					colIdxMap[item.Column] = append(colIdxMap[item.Column], item)
 				}
-				items := make([]table.IndexItem, len(v))
-				for i, idx := range v {
-					var sort sortenum.Sort
-					if idx.Collation == "B" {
-						sort = sortenum.Desc
-					} else {
-						sort = sortenum.Asc
-					}
-					items[i] = table.IndexItem{
-						Unique: !v[0].Non_unique,
-						Name:   k,
-						Column: idx.Column_name,
-						Order:  idx.Seq_in_index,
-						Sort:   sort,
-					}
-					if val, exists := colIdxMap[idx.Column_name]; exists {
-						val = append(val, items[i])
-						colIdxMap[idx.Column_name] = val
-					} else {
-						colIdxMap[idx.Column_name] = []table.IndexItem{
-							items[i],
-						}
-					}
-				}
-				indexes = append(indexes, table.Index{
-					Unique: !v[0].Non_unique,
-					Name:   k,
-					Items:  items,
-				})
 			}
 
-			var columns []table.DbColumn
-			if err = db.Select(&columns, fmt.Sprintf("SHOW FULL COLUMNS FROM %s", t)); err != nil {
+			var columns []dialect.Column
+			if columns, err = dia.Columns(db, t); err != nil {
 				logrus.Panicln(err)
 			}
 
-			var cols []table.Column
-			var fields []astutils.FieldMeta
-			for _, item := range columns {
-				extra := item.Extra
-				if strings.Contains(extra, "auto_increment") {
-					extra = ""
-				}
-				extra = strings.TrimSpace(strings.TrimPrefix(extra, "DEFAULT_GENERATED"))
-				if stringutils.IsNotEmpty(item.Comment) {
-					extra += fmt.Sprintf(" comment '%s'", item.Comment)
-				}
-				extra = strings.TrimSpace(extra)
+			name := strcase.ToCamel(strings.TrimPrefix(t, d.Pre))
+			cols, fields, enums := reverseColumns(t, name, columns, colIdxMap, colFkMap)
 
-				col := table.Column{
-					Table:         t,
-					Name:          item.Field,
-					Type:          columnenum.ColumnType(item.Type),
-					Default:       item.Default,
-					Pk:            table.CheckPk(item.Key),
-					Nullable:      table.CheckNull(item.Null),
-					Unsigned:      table.CheckUnsigned(item.Type),
-					Autoincrement: table.CheckAutoincrement(item.Extra),
-					Extra:         extraenum.Extra(extra),
-					AutoSet:       table.CheckAutoSet(item.Default),
-					Indexes:       colIdxMap[item.Field],
-				}
-				col.Meta = table.NewFieldFromColumn(col)
-				fields = append(fields, col.Meta)
-				cols = append(cols, col)
+			var comment string
+			if comment, err = dia.TableComment(db, t); err != nil {
+				logrus.Panicln(err)
 			}
-
 			domain := astutils.StructMeta{
-				Name:   strcase.ToCamel(strings.TrimPrefix(t, d.Pre)),
-				Fields: fields,
+				Name:     name,
+				Fields:   fields,
+				Comments: append(docLines(comment), "dd:table"),
 			}
 
 			var pkColumn table.Column
@@ -226,17 +316,33 @@ func (d Ddl) Exec() {
 				Columns: cols,
 				Pk:      pkColumn.Name,
 				Indexes: indexes,
+				Fks:     fks,
 				Meta:    domain,
+				Comment: comment,
 			})
+			d.genDomain(domain, enums)
+		}
 
-			dfile := filepath.Join(d.Dir, strings.ToLower(domain.Name)+".go")
-			if _, err = os.Stat(dfile); os.IsNotExist(err) {
-				if err = codegen.GenDomainGo(d.Dir, domain); err != nil {
-					logrus.Errorf("FATAL: %+v\n", err)
-				}
-			} else {
-				logrus.Warnf("file %s already exists", dfile)
+		var views []string
+		if views, err = dia.Views(db); err != nil {
+			logrus.Panicln(err)
+		}
+		for _, v := range views {
+			if stringutils.IsNotEmpty(d.Pre) && !strings.HasPrefix(v, d.Pre) {
+				continue
+			}
+			var columns []dialect.Column
+			if columns, err = dia.Columns(db, v); err != nil {
+				logrus.Panicln(err)
 			}
+			name := strcase.ToCamel(strings.TrimPrefix(v, d.Pre))
+			_, fields, enums := reverseColumns(v, name, columns, nil, nil)
+			// views are read only, dd:view structs are skipped by forward mode and no dao is generated for them
+			d.genDomain(astutils.StructMeta{
+				Name:     name,
+				Fields:   fields,
+				Comments: []string{"dd:view"},
+			}, enums)
 		}
 	}
 
@@ -257,6 +363,10 @@ func (d Ddl) Exec() {
 				logrus.Errorf("FATAL: %+v\n", err)
 				break
 			}
+			if err = codegen.GenDaoQueryGo(d.Dir, t, d.Df); err != nil {
+				logrus.Errorf("FATAL: %+v\n", err)
+				break
+			}
 		}
 	}
 
@@ -0,0 +1,31 @@
+package ddl
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+// ErrConflict is matched by ConflictError with errors.Is
+var ErrConflict = errors.New("conflict")
+
+// ConflictError is returned by generated dao when no row is updated by statement with optimistic lock, because version
+// of the row has been increased by others since it was read, or the row has been deleted
+type ConflictError struct {
+	Table   string
+	Id      interface{}
+	Version interface{}
+}
+
+func (e ConflictError) Error() string {
+	return fmt.Sprintf("row %v of table %s has been changed or deleted since version %v", e.Id, e.Table, e.Version)
+}
+
+func (e ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// StatusCode makes generated http handlers respond 409 to the conflict
+func (e ConflictError) StatusCode() int {
+	return http.StatusConflict
+}
@@ -0,0 +1,17 @@
+package ddl
+
+import (
+	"github.com/pkg/errors"
+	"testing"
+)
+
+func TestConflictError(t *testing.T) {
+	err := errors.Wrap(ConflictError{Table: "user", Id: 1, Version: 2}, "failed to update user")
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("%v should be ErrConflict", err)
+	}
+	var conflict ConflictError
+	if !errors.As(err, &conflict) || conflict.Version != 2 {
+		t.Errorf("%v should be ConflictError of version 2", err)
+	}
+}
@@ -0,0 +1,42 @@
+package ddl
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLRUCache(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRUCache(2)
+	c.Set(ctx, "a", []byte("1"), 0)
+	c.Set(ctx, "b", []byte("2"), 0)
+	c.Get(ctx, "a")
+	c.Set(ctx, "c", []byte("3"), 0)
+	c.Set(ctx, "d", []byte("4"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	tests := []struct {
+		key    string
+		want   string
+		wantOk bool
+	}{
+		{"a", "", false},
+		{"b", "", false},
+		{"c", "3", true},
+		{"d", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, ok, err := c.Get(ctx, tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.wantOk || string(got) != tt.want {
+				t.Errorf("Get() = %s, %v, want %s, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+	if c.Len() != 1 {
+		t.Errorf("Len() = %v, want 1", c.Len())
+	}
+}
@@ -0,0 +1,349 @@
+package migrate
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/unionj-cloud/go-doudou/ddl/columnenum"
+	"github.com/unionj-cloud/go-doudou/ddl/dialect"
+	"github.com/unionj-cloud/go-doudou/ddl/extraenum"
+	"github.com/unionj-cloud/go-doudou/ddl/table"
+	"github.com/unionj-cloud/go-doudou/sliceutils"
+	"github.com/unionj-cloud/go-doudou/stringutils"
+	"regexp"
+	"strings"
+)
+
+const (
+	KindCreate    = "create"
+	KindAdd       = "add"
+	KindChange    = "change"
+	KindRename    = "rename"
+	KindDrop      = "drop"
+	KindAddIndex  = "addIndex"
+	KindDropIndex = "dropIndex"
+	KindAddFk     = "addFk"
+	KindDropFk    = "dropFk"
+)
+
+// Change is a difference between domain structs and database schema with statements to apply and revert it.
+// Column is index name for index changes and foreign key name for foreign key changes.
+type Change struct {
+	Kind   string
+	Table  string
+	Column string
+	Up     string
+	Down   string
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case KindCreate:
+		return fmt.Sprintf("create table %s", c.Table)
+	case KindAdd:
+		return fmt.Sprintf("add column %s.%s", c.Table, c.Column)
+	case KindRename:
+		return fmt.Sprintf("rename column %s.%s", c.Table, c.Column)
+	case KindDrop:
+		return fmt.Sprintf("drop column %s.%s", c.Table, c.Column)
+	case KindAddIndex:
+		return fmt.Sprintf("add index %s.%s", c.Table, c.Column)
+	case KindDropIndex:
+		return fmt.Sprintf("drop index %s.%s", c.Table, c.Column)
+	case KindAddFk:
+		return fmt.Sprintf("add foreign key %s.%s", c.Table, c.Column)
+	case KindDropFk:
+		return fmt.Sprintf("drop foreign key %s.%s", c.Table, c.Column)
+	default:
+		return fmt.Sprintf("change column %s.%s", c.Table, c.Column)
+	}
+}
+
+// Diff compares tables from domain structs with database. Missing tables are created, missing columns are added,
+// columns with rename tag are renamed, and columns with different type, nullability or default value are changed.
+// Indexes and foreign keys of existing tables are synchronized, tables are created after tables they reference.
+// Columns not in domain are dropped only if drop is true, tables not in domain are kept, changes of extra and comments are not detected.
+// Error wrapping dialect.ErrUnsupported is returned if a change can't be done by the dialect, e.g. changing column type
+// or adding foreign key to existing table on sqlite, so that schema never drifts silently.
+func Diff(db *sqlx.DB, d dialect.Dialect, tables []table.Table, drop bool) ([]Change, error) {
+	existTables, err := d.Tables(db)
+	if err != nil {
+		return nil, err
+	}
+	var changes []Change
+	for _, t := range table.SortByFk(tables) {
+		if !sliceutils.StringContains(existTables, t.Name) {
+			up, err := t.CreateSql(d)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to render create statement")
+			}
+			changes = append(changes, Change{
+				Kind:  KindCreate,
+				Table: t.Name,
+				Up:    up,
+				Down:  fmt.Sprintf("DROP TABLE %s;", d.Quote(t.Name)),
+			})
+			continue
+		}
+		tableChanges, err := diffTable(db, d, t, drop)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, tableChanges...)
+	}
+	return changes, nil
+}
+
+// diffTable lists changes of existing table in the order they can be applied: stale foreign keys and indexes are
+// dropped first, then columns are renamed, changed, added and dropped, at last new indexes and foreign keys are added.
+func diffTable(db *sqlx.DB, d dialect.Dialect, t table.Table, drop bool) ([]Change, error) {
+	columns, err := d.Columns(db, t.Name)
+	if err != nil {
+		return nil, err
+	}
+	dbIndice, err := d.Indexes(db, t.Name)
+	if err != nil {
+		return nil, err
+	}
+	dbFks, err := d.ForeignKeys(db, t.Name)
+	if err != nil {
+		return nil, err
+	}
+	existColumns := make(map[string]dialect.Column)
+	for _, col := range columns {
+		existColumns[col.Name] = col
+	}
+
+	var changes []Change
+	addFks, dropFks := table.DiffFks(t.Fks, table.FksFromDb(dbFks))
+	for _, fk := range dropFks {
+		up, err := t.DropFkSql(fk, d)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to render drop foreign key statement")
+		}
+		down, err := t.AddFkSql(fk, d)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to render add foreign key statement")
+		}
+		if strings.TrimSpace(up) == "" {
+			return nil, errors.Wrapf(dialect.ErrUnsupported, "foreign key %s of table %s is not in domain struct, but dropping it is unsupported on %s", fk.Name, t.Name, d.Name())
+		}
+		changes = append(changes, Change{
+			Kind:   KindDropFk,
+			Table:  t.Name,
+			Column: fk.Name,
+			Up:     strings.TrimSpace(up),
+			Down:   strings.TrimSpace(down),
+		})
+	}
+
+	addIndexes, dropIndexes := table.DiffIndexes(t.Indexes, table.IndexesFromDb(dbIndice, dbFks))
+	for _, idx := range dropIndexes {
+		up, err := t.DropIndexSql(idx, d)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to render drop index statement")
+		}
+		down, err := t.AddIndexSql(idx, d)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to render add index statement")
+		}
+		changes = append(changes, Change{
+			Kind:   KindDropIndex,
+			Table:  t.Name,
+			Column: idx.Name,
+			Up:     strings.TrimSpace(up),
+			Down:   strings.TrimSpace(down),
+		})
+	}
+
+	kept := make(map[string]bool)
+	for _, col := range t.Columns {
+		kept[col.Name] = true
+		exist, ok := existColumns[col.Name]
+		if !ok && stringutils.IsNotEmpty(col.OldName) {
+			if exist, ok = existColumns[col.OldName]; ok {
+				kept[col.OldName] = true
+				up, err := col.RenameColumnSql(d)
+				if err != nil {
+					return nil, errors.Wrap(err, "failed to render rename column statement")
+				}
+				old := fromDb(t.Name, exist)
+				old.Name, old.OldName = col.OldName, col.Name
+				down, err := old.RenameColumnSql(d)
+				if err != nil {
+					return nil, errors.Wrap(err, "failed to render rename column statement")
+				}
+				changes = append(changes, Change{
+					Kind:   KindRename,
+					Table:  t.Name,
+					Column: col.Name,
+					Up:     strings.TrimSpace(up),
+					Down:   strings.TrimSpace(down),
+				})
+				exist.Name = col.Name
+			}
+		}
+		if !ok {
+			up, err := col.AddColumnSql(d)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to render add column statement")
+			}
+			down, err := col.DropColumnSql(d)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to render drop column statement")
+			}
+			changes = append(changes, Change{
+				Kind:   KindAdd,
+				Table:  t.Name,
+				Column: col.Name,
+				Up:     strings.TrimSpace(up),
+				Down:   strings.TrimSpace(down),
+			})
+			continue
+		}
+		if same(d, col, exist) {
+			continue
+		}
+		up, err := col.ChangeColumnSql(d)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to render change column statement")
+		}
+		old := fromDb(t.Name, exist)
+		down, err := old.ChangeColumnSql(d)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to render change column statement")
+		}
+		if strings.TrimSpace(up) == "" {
+			return nil, errors.Wrapf(dialect.ErrUnsupported, "column %s of table %s differs from database, but changing it is unsupported on %s", col.Name, t.Name, d.Name())
+		}
+		changes = append(changes, Change{
+			Kind:   KindChange,
+			Table:  t.Name,
+			Column: col.Name,
+			Up:     strings.TrimSpace(up),
+			Down:   strings.TrimSpace(down),
+		})
+	}
+
+	for _, exist := range columns {
+		if kept[exist.Name] {
+			continue
+		}
+		if !drop {
+			logrus.Warnf("column %s of table %s is not in domain struct, use --drop to drop it", exist.Name, t.Name)
+			continue
+		}
+		old := fromDb(t.Name, exist)
+		up, err := old.DropColumnSql(d)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to render drop column statement")
+		}
+		// data of dropped column can't be restored
+		down, err := old.AddColumnSql(d)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to render add column statement")
+		}
+		changes = append(changes, Change{
+			Kind:   KindDrop,
+			Table:  t.Name,
+			Column: exist.Name,
+			Up:     strings.TrimSpace(up),
+			Down:   strings.TrimSpace(down),
+		})
+	}
+
+	for _, idx := range addIndexes {
+		up, err := t.AddIndexSql(idx, d)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to render add index statement")
+		}
+		down, err := t.DropIndexSql(idx, d)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to render drop index statement")
+		}
+		changes = append(changes, Change{
+			Kind:   KindAddIndex,
+			Table:  t.Name,
+			Column: idx.Name,
+			Up:     strings.TrimSpace(up),
+			Down:   strings.TrimSpace(down),
+		})
+	}
+
+	for _, fk := range addFks {
+		up, err := t.AddFkSql(fk, d)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to render add foreign key statement")
+		}
+		down, err := t.DropFkSql(fk, d)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to render drop foreign key statement")
+		}
+		if strings.TrimSpace(up) == "" {
+			return nil, errors.Wrapf(dialect.ErrUnsupported, "foreign key %s of table %s is not in database, but adding it to existing table is unsupported on %s", fk.Name, t.Name, d.Name())
+		}
+		changes = append(changes, Change{
+			Kind:   KindAddFk,
+			Table:  t.Name,
+			Column: fk.Name,
+			Up:     strings.TrimSpace(up),
+			Down:   strings.TrimSpace(down),
+		})
+	}
+	return changes, nil
+}
+
+var intWidth = regexp.MustCompile(`(int)\(\d+\)`)
+
+// normType makes native types comparable, e.g. INT(11) and int, VARCHAR(255) and varchar(255)
+func normType(ct columnenum.ColumnType) string {
+	typ := strings.ToLower(string(ct))
+	typ = intWidth.ReplaceAllString(typ, "$1")
+	typ = strings.Replace(typ, "unsigned", "", 1)
+	return strings.Join(strings.Fields(typ), " ")
+}
+
+func same(d dialect.Dialect, col table.Column, exist dialect.Column) bool {
+	goType := strings.TrimPrefix(col.Meta.Type, "*")
+	if normType(d.ColumnType(col.Type, goType, col.Autoincrement)) != normType(d.ColumnType(columnenum.ColumnType(exist.Type), goType, exist.Autoincrement)) {
+		return false
+	}
+	if col.Nullable != exist.Nullable {
+		return false
+	}
+	var want string
+	if col.Default != nil {
+		want = dialect.Unquote(fmt.Sprint(col.Default))
+	}
+	var got string
+	if exist.Default != nil {
+		got = *exist.Default
+	}
+	return strings.EqualFold(want, got)
+}
+
+// fromDb converts column read from database to table column for rendering statements
+func fromDb(tableName string, col dialect.Column) table.Column {
+	var def interface{}
+	if col.Default != nil {
+		val := *col.Default
+		if strings.EqualFold(val, "CURRENT_TIMESTAMP") || strings.HasPrefix(val, "(") {
+			def = val
+		} else {
+			def = "'" + strings.ReplaceAll(val, "'", "''") + "'"
+		}
+	}
+	return table.Column{
+		Table:         tableName,
+		Name:          col.Name,
+		Type:          columnenum.ColumnType(col.Type),
+		Default:       def,
+		Pk:            col.Pk,
+		Nullable:      col.Nullable,
+		Unsigned:      col.Unsigned,
+		Autoincrement: col.Autoincrement,
+		Extra:         extraenum.Extra(col.Extra),
+		Comment:       col.Comment,
+	}
+}
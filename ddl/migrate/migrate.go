@@ -0,0 +1,323 @@
+package migrate
+
+import (
+	"fmt"
+	"github.com/iancoleman/strcase"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/unionj-cloud/go-doudou/ddl/dialect"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Table records applied migrations
+const Table = "schema_migrations"
+
+const versionFormat = "20060102150405"
+
+// Migration is a pair of up and down sql files named like 20210615093000_add_user_email.up.sql
+type Migration struct {
+	Version string
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration with its applied time, AppliedAt is nil for pending migrations
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Write writes changes to a new pair of migration files in dir. Down statements are in reverse order of up ones.
+func Write(dir, name string, changes []Change) (Migration, error) {
+	var (
+		m    Migration
+		up   []string
+		down []string
+		err  error
+	)
+	if len(changes) == 0 {
+		return m, errors.New("no changes to write")
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return m, errors.Wrap(err, "error")
+	}
+	m.Version = time.Now().Format(versionFormat)
+	m.Name = strcase.ToSnake(name)
+	m.Up = filepath.Join(dir, fmt.Sprintf("%s_%s.up.sql", m.Version, m.Name))
+	m.Down = filepath.Join(dir, fmt.Sprintf("%s_%s.down.sql", m.Version, m.Name))
+	for i := range changes {
+		up = append(up, fmt.Sprintf("-- %s\n%s", changes[i], changes[i].Up))
+		j := len(changes) - 1 - i
+		down = append(down, fmt.Sprintf("-- revert %s\n%s", changes[j], changes[j].Down))
+	}
+	if err = ioutil.WriteFile(m.Up, []byte(strings.Join(up, "\n\n")+"\n"), 0644); err != nil {
+		return m, errors.Wrap(err, "error")
+	}
+	if err = ioutil.WriteFile(m.Down, []byte(strings.Join(down, "\n\n")+"\n"), 0644); err != nil {
+		return m, errors.Wrap(err, "error")
+	}
+	return m, nil
+}
+
+var fileRe = regexp.MustCompile(`^(\d+)_(.*)\.(up|down)\.sql$`)
+
+// Load lists migrations in dir ordered by version
+func Load(dir string) ([]Migration, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "error")
+	}
+	migrations := make(map[string]*Migration)
+	for _, f := range files {
+		matches := fileRe.FindStringSubmatch(f.Name())
+		if f.IsDir() || matches == nil {
+			continue
+		}
+		m, ok := migrations[matches[1]]
+		if !ok {
+			m = &Migration{Version: matches[1], Name: matches[2]}
+			migrations[matches[1]] = m
+		}
+		if matches[3] == "up" {
+			m.Up = filepath.Join(dir, f.Name())
+		} else {
+			m.Down = filepath.Join(dir, f.Name())
+		}
+	}
+	var list []Migration
+	for _, m := range migrations {
+		if m.Up == "" {
+			return nil, errors.Errorf("up file of migration %s_%s not found", m.Version, m.Name)
+		}
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+	return list, nil
+}
+
+// Migrator applies and reverts migrations in dir, applied versions are recorded in schema_migrations table
+type Migrator struct {
+	db  *sqlx.DB
+	d   dialect.Dialect
+	dir string
+}
+
+func NewMigrator(db *sqlx.DB, dir string) (*Migrator, error) {
+	d, err := dialect.Get(db.DriverName())
+	if err != nil {
+		return nil, err
+	}
+	m := &Migrator{
+		db:  db,
+		d:   d,
+		dir: dir,
+	}
+	if _, err = db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+%s VARCHAR(32) NOT NULL PRIMARY KEY,
+%s VARCHAR(255) NOT NULL,
+%s TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)`, d.Quote(Table), d.Quote("version"), d.Quote("name"), d.Quote("applied_at"))); err != nil {
+		return nil, errors.Wrap(err, "failed to create schema_migrations table")
+	}
+	return m, nil
+}
+
+func (m *Migrator) applied() (map[string]time.Time, error) {
+	var rows []struct {
+		Version   string    `db:"version"`
+		AppliedAt time.Time `db:"applied_at"`
+	}
+	if err := m.db.Select(&rows, fmt.Sprintf("select %s, %s from %s", m.d.Quote("version"), m.d.Quote("applied_at"), m.d.Quote(Table))); err != nil {
+		return nil, errors.Wrap(err, "failed to list applied migrations")
+	}
+	result := make(map[string]time.Time)
+	for _, row := range rows {
+		result[row.Version] = row.AppliedAt
+	}
+	return result, nil
+}
+
+// Status lists all migrations in dir, and applied ones whose files are missing
+func (m *Migrator) Status() ([]Status, error) {
+	migrations, err := Load(m.dir)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var list []Status
+	for _, item := range migrations {
+		s := Status{Migration: item}
+		if at, ok := applied[item.Version]; ok {
+			s.AppliedAt = &at
+			delete(applied, item.Version)
+		}
+		list = append(list, s)
+	}
+	for version, at := range applied {
+		at := at
+		list = append(list, Status{Migration: Migration{Version: version}, AppliedAt: &at})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+	return list, nil
+}
+
+// Up applies pending migrations in version order, at most steps migrations if steps is positive
+func (m *Migrator) Up(steps int) ([]Migration, error) {
+	list, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, s := range list {
+		if s.AppliedAt != nil {
+			continue
+		}
+		if steps > 0 && len(done) >= steps {
+			break
+		}
+		if err = m.run(func(q sqlx.Execer) error {
+			if err := m.exec(q, s.Up); err != nil {
+				return errors.Wrapf(err, "failed to apply migration %s_%s", s.Version, s.Name)
+			}
+			if _, err := q.Exec(m.db.Rebind(fmt.Sprintf("insert into %s (%s, %s) values (?, ?)", m.d.Quote(Table), m.d.Quote("version"), m.d.Quote("name"))), s.Version, s.Name); err != nil {
+				return errors.Wrap(err, "failed to record migration")
+			}
+			return nil
+		}); err != nil {
+			return done, err
+		}
+		done = append(done, s.Migration)
+	}
+	return done, nil
+}
+
+// Down reverts applied migrations in reverse version order, the last one if steps is not positive
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	if steps <= 0 {
+		steps = 1
+	}
+	list, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for i := len(list) - 1; i >= 0 && len(done) < steps; i-- {
+		s := list[i]
+		if s.AppliedAt == nil {
+			continue
+		}
+		if s.Down == "" {
+			return done, errors.Errorf("down file of migration %s not found", s.Version)
+		}
+		if err = m.run(func(q sqlx.Execer) error {
+			if err := m.exec(q, s.Down); err != nil {
+				return errors.Wrapf(err, "failed to revert migration %s_%s", s.Version, s.Name)
+			}
+			if _, err := q.Exec(m.db.Rebind(fmt.Sprintf("delete from %s where %s = ?", m.d.Quote(Table), m.d.Quote("version"))), s.Version); err != nil {
+				return errors.Wrap(err, "failed to delete migration record")
+			}
+			return nil
+		}); err != nil {
+			return done, err
+		}
+		done = append(done, s.Migration)
+	}
+	return done, nil
+}
+
+// run runs fn in a transaction if the database supports transactional ddl, so a failed migration leaves neither
+// part of its schema changes nor its record behind. Otherwise fn runs on db directly, e.g. mysql.
+func (m *Migrator) run(fn func(q sqlx.Execer) error) (err error) {
+	if !m.d.TransactionalDDL() {
+		return fn(m.db)
+	}
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		r := recover()
+		if r != nil || err != nil {
+			if _err := tx.Rollback(); _err != nil {
+				logrus.Errorf("failed to rollback transaction: %+v", _err)
+			}
+		}
+		if r != nil {
+			panic(r)
+		}
+	}()
+	if err = fn(tx); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+	return nil
+}
+
+// exec runs statements of file one by one, because not all drivers accept multiple statements in one call
+func (m *Migrator) exec(q sqlx.Execer, file string) error {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return errors.Wrap(err, "error")
+	}
+	for _, statement := range Split(string(content)) {
+		if _, err = q.Exec(statement); err != nil {
+			return errors.Wrapf(err, "failed to execute %s", statement)
+		}
+	}
+	return nil
+}
+
+// Split splits sql script into statements by semicolons out of quotes. Line comments starting with -- are removed.
+func Split(script string) []string {
+	var (
+		statements []string
+		sb         strings.Builder
+		inStr      byte
+	)
+	flush := func() {
+		if statement := strings.TrimSpace(sb.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		sb.Reset()
+	}
+	for i := 0; i < len(script); i++ {
+		ch := script[i]
+		switch {
+		case inStr != 0:
+			if ch == inStr {
+				inStr = 0
+			}
+			sb.WriteByte(ch)
+		case ch == '\'' || ch == '"' || ch == '`':
+			inStr = ch
+			sb.WriteByte(ch)
+		case ch == '-' && i+1 < len(script) && script[i+1] == '-':
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+			sb.WriteByte('\n')
+		case ch == ';':
+			flush()
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	flush()
+	return statements
+}
@@ -0,0 +1,416 @@
+package migrate
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/unionj-cloud/go-doudou/astutils"
+	"github.com/unionj-cloud/go-doudou/ddl/columnenum"
+	"github.com/unionj-cloud/go-doudou/ddl/ddlast"
+	"github.com/unionj-cloud/go-doudou/ddl/dialect"
+	"github.com/unionj-cloud/go-doudou/ddl/dialect/sqlite"
+	"github.com/unionj-cloud/go-doudou/ddl/sortenum"
+	"github.com/unionj-cloud/go-doudou/ddl/table"
+	"github.com/unionj-cloud/go-doudou/pathutils"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "single",
+			script: "DROP TABLE \"user\";\n",
+			want:   []string{`DROP TABLE "user"`},
+		},
+		{
+			name:   "comments",
+			script: "-- create table user\nCREATE TABLE a (id INT);\n\n-- add column a.b\nALTER TABLE a ADD b INT;\n",
+			want:   []string{"CREATE TABLE a (id INT)", "ALTER TABLE a ADD b INT"},
+		},
+		{
+			name:   "quoted",
+			script: "ALTER TABLE a ADD b VARCHAR(10) DEFAULT 'x;y' comment '-- not a comment';",
+			want:   []string{"ALTER TABLE a ADD b VARCHAR(10) DEFAULT 'x;y' comment '-- not a comment'"},
+		},
+		{
+			name:   "empty",
+			script: "-- nothing\n;\n",
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Split(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func userTable(t *testing.T) table.Table {
+	sc := astutils.NewStructCollector(astutils.ExprString)
+	for _, file := range []string{"user.go", "base.go"} {
+		root, err := parser.ParseFile(token.NewFileSet(), pathutils.Abs(filepath.Join("../testfiles/domain", file)), nil, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Walk(sc, root)
+	}
+	for _, sm := range ddlast.FlatEmbed(sc.Structs) {
+		if sm.Name == "User" {
+			return table.NewTableFromStruct(sm)
+		}
+	}
+	t.Fatal("User not found")
+	return table.Table{}
+}
+
+func backdate(m Migration, version string) (Migration, error) {
+	up := filepath.Join(filepath.Dir(m.Up), version+"_"+m.Name+".up.sql")
+	down := filepath.Join(filepath.Dir(m.Down), version+"_"+m.Name+".down.sql")
+	if err := os.Rename(m.Up, up); err != nil {
+		return m, err
+	}
+	if err := os.Rename(m.Down, down); err != nil {
+		return m, err
+	}
+	return Migration{Version: version, Name: m.Name, Up: up, Down: down}, nil
+}
+
+func TestMigrator(t *testing.T) {
+	dir := t.TempDir()
+	db, err := sqlx.Connect("sqlite3", filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	d := sqlite.Sqlite{}
+	migrations := filepath.Join(dir, "migrations")
+	ut := userTable(t)
+
+	changes, err := Diff(db, d, []table.Table{ut}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Kind != KindCreate {
+		t.Fatalf("Diff() got = %+v, want create table", changes)
+	}
+	init, err := Write(migrations, "init", changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// versions have second precision, backdate the first migration to keep the next one after it
+	if init, err = backdate(init, "20000101000000"); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewMigrator(db, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done, err := m.Up(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 1 || done[0].Version != init.Version {
+		t.Fatalf("Up() got = %+v", done)
+	}
+
+	changes, err = Diff(db, d, []table.Table{ut}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("Diff() got = %+v, want no changes", changes)
+	}
+
+	ut.Columns = append(ut.Columns, table.Column{
+		Table:    "user",
+		Name:     "email",
+		Type:     columnenum.VarcharType,
+		Nullable: true,
+		Meta:     astutils.FieldMeta{Name: "Email", Type: "*string"},
+	})
+	changes, err = Diff(db, d, []table.Table{ut}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{
+			Kind:   KindAdd,
+			Table:  "user",
+			Column: "email",
+			Up:     "ALTER TABLE \"user\"\nADD COLUMN \"email\" VARCHAR(255) NULL;",
+			Down:   `ALTER TABLE "user" DROP COLUMN "email";`,
+		},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("Diff() got = %#v, want %#v", changes, want)
+	}
+	written, err := Write(migrations, "addEmail", changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written.Name != "add_email" {
+		t.Errorf("Write() name got = %s, want add_email", written.Name)
+	}
+
+	list, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].AppliedAt == nil || list[1].AppliedAt != nil {
+		t.Fatalf("Status() got = %+v", list)
+	}
+
+	if done, err = m.Up(0); err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 1 || done[0].Name != "add_email" {
+		t.Fatalf("Up() got = %+v", done)
+	}
+	columns, err := d.Columns(db, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if columns[len(columns)-1].Name != "email" {
+		t.Errorf("column email not added")
+	}
+
+	if done, err = m.Down(2); err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 2 || done[0].Name != "add_email" || done[1].Name != "init" {
+		t.Fatalf("Down() got = %+v", done)
+	}
+	tables, err := d.Tables(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tables, []string{Table}) {
+		t.Errorf("Tables() got = %v, want [%s]", tables, Table)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	db, err := sqlx.Connect("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	d := sqlite.Sqlite{}
+	if err = table.CreateTable(db, userTable(t)); err != nil {
+		t.Fatal(err)
+	}
+	column := func(ut table.Table, name string) int {
+		for i, col := range ut.Columns {
+			if col.Name == name {
+				return i
+			}
+		}
+		t.Fatalf("column %s not found", name)
+		return -1
+	}
+	index := func(ut table.Table, name string) int {
+		for i, idx := range ut.Indexes {
+			if idx.Name == name {
+				return i
+			}
+		}
+		t.Fatalf("index %s not found", name)
+		return -1
+	}
+	tests := []struct {
+		name    string
+		modify  func(ut *table.Table)
+		drop    bool
+		want    []Change
+		wantErr error
+	}{
+		{
+			name:   "same",
+			modify: func(ut *table.Table) {},
+		},
+		{
+			name: "rename",
+			modify: func(ut *table.Table) {
+				i := column(*ut, "school")
+				ut.Columns[i].Name = "college"
+				ut.Columns[i].OldName = "school"
+			},
+			want: []Change{
+				{
+					Kind:   KindRename,
+					Table:  "user",
+					Column: "college",
+					Up:     `ALTER TABLE "user" RENAME COLUMN "school" TO "college";`,
+					Down:   `ALTER TABLE "user" RENAME COLUMN "college" TO "school";`,
+				},
+			},
+		},
+		{
+			name: "keep removed column",
+			modify: func(ut *table.Table) {
+				i := column(*ut, "school")
+				ut.Columns = append(ut.Columns[:i], ut.Columns[i+1:]...)
+			},
+		},
+		{
+			name: "drop removed column",
+			modify: func(ut *table.Table) {
+				i := column(*ut, "school")
+				ut.Columns = append(ut.Columns[:i], ut.Columns[i+1:]...)
+			},
+			drop: true,
+			want: []Change{
+				{
+					Kind:   KindDrop,
+					Table:  "user",
+					Column: "school",
+					Up:     `ALTER TABLE "user" DROP COLUMN "school";`,
+					Down:   "ALTER TABLE \"user\"\nADD COLUMN \"school\" VARCHAR(255) NULL DEFAULT 'harvard';",
+				},
+			},
+		},
+		{
+			name: "change index sort",
+			modify: func(ut *table.Table) {
+				i := index(*ut, "age_idx")
+				ut.Indexes[i] = table.Index{
+					Name:  "age_idx",
+					Items: []table.IndexItem{{Column: "age", Order: 1, Sort: sortenum.Desc}},
+				}
+			},
+			want: []Change{
+				{
+					Kind:   KindDropIndex,
+					Table:  "user",
+					Column: "age_idx",
+					Up:     `DROP INDEX "user_age_idx";`,
+					Down:   `CREATE INDEX "user_age_idx" ON "user" ("age" asc);`,
+				},
+				{
+					Kind:   KindAddIndex,
+					Table:  "user",
+					Column: "age_idx",
+					Up:     `CREATE INDEX "user_age_idx" ON "user" ("age" desc);`,
+					Down:   `DROP INDEX "user_age_idx";`,
+				},
+			},
+		},
+		{
+			name: "composite index",
+			modify: func(ut *table.Table) {
+				i := index(*ut, "name_phone_idx")
+				ut.Indexes = append(ut.Indexes[:i], ut.Indexes[i+1:]...)
+				ut.Indexes = append(ut.Indexes, table.Index{
+					Unique: true,
+					Name:   "school_age_idx",
+					Items: []table.IndexItem{
+						{Column: "age", Order: 2, Sort: sortenum.Asc},
+						{Column: "school", Order: 1, Sort: sortenum.Asc},
+					},
+				})
+			},
+			want: []Change{
+				{
+					Kind:   KindDropIndex,
+					Table:  "user",
+					Column: "name_phone_idx",
+					Up:     `DROP INDEX "user_name_phone_idx";`,
+					Down:   `CREATE INDEX "user_name_phone_idx" ON "user" ("phone" asc,"name" asc);`,
+				},
+				{
+					Kind:   KindAddIndex,
+					Table:  "user",
+					Column: "school_age_idx",
+					Up:     `CREATE UNIQUE INDEX "user_school_age_idx" ON "user" ("school" asc,"age" asc);`,
+					Down:   `DROP INDEX "user_school_age_idx";`,
+				},
+			},
+		},
+		{
+			name: "change column on sqlite",
+			modify: func(ut *table.Table) {
+				ut.Columns[column(*ut, "age")].Type = "BIGINT"
+			},
+			wantErr: dialect.ErrUnsupported,
+		},
+		{
+			name: "add fk on sqlite",
+			modify: func(ut *table.Table) {
+				ut.Fks = append(ut.Fks, table.ForeignKey{Name: "fk_user_no", Column: "no", RefTable: "user", RefColumn: "id"})
+			},
+			wantErr: dialect.ErrUnsupported,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ut := userTable(t)
+			tt.modify(&ut)
+			got, err := Diff(db, d, []table.Table{ut}, tt.drop)
+			if errors.Cause(err) != tt.wantErr {
+				t.Fatalf("Diff() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() got = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrator_Rollback(t *testing.T) {
+	dir := t.TempDir()
+	db, err := sqlx.Connect("sqlite3", filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	migrations := filepath.Join(dir, "migrations")
+	written, err := Write(migrations, "broken", []Change{
+		{Kind: KindCreate, Table: "a", Up: "CREATE TABLE a (id INT);", Down: "DROP TABLE a;"},
+		{Kind: KindCreate, Table: "b", Up: "CREATE TABLE b (id INT", Down: "DROP TABLE b;"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(written.Up)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0111 != 0 {
+		t.Errorf("mode of %s got = %v, want not executable", written.Up, info.Mode())
+	}
+
+	m, err := NewMigrator(db, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.Up(0); err == nil {
+		t.Fatal("Up() error = nil, want error")
+	}
+	// sqlite supports transactional ddl, table a created by the first statement is rolled back
+	tables, err := sqlite.Sqlite{}.Tables(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tables, []string{Table}) {
+		t.Errorf("Tables() got = %v, want [%s]", tables, Table)
+	}
+	list, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].AppliedAt != nil {
+		t.Errorf("Status() got = %+v, want pending migration", list)
+	}
+}
@@ -0,0 +1,101 @@
+package ddl
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/olekukonko/tablewriter"
+	"github.com/sirupsen/logrus"
+	"github.com/unionj-cloud/go-doudou/ddl/dialect"
+	"github.com/unionj-cloud/go-doudou/ddl/migrate"
+	"github.com/unionj-cloud/go-doudou/ddl/table"
+	"github.com/unionj-cloud/go-doudou/stringutils"
+	"strings"
+)
+
+func (d Ddl) diff(db *sqlx.DB, dia dialect.Dialect, tables []table.Table) {
+	changes, err := migrate.Diff(db, dia, tables, d.Drop)
+	if err != nil {
+		logrus.Panicln(err)
+	}
+	if len(changes) == 0 {
+		logrus.Infoln("database schema is up to date")
+		return
+	}
+	if d.DryRun {
+		for _, change := range changes {
+			logrus.Infof("-- %s\n%s\n", change, change.Up)
+		}
+		return
+	}
+	name := d.MigrationName
+	if stringutils.IsEmpty(name) {
+		name = "migration"
+	}
+	m, err := migrate.Write(d.MigrationDir, name, changes)
+	if err != nil {
+		logrus.Panicln(err)
+	}
+	logrus.Infof("migration files %s and %s are written", m.Up, m.Down)
+}
+
+// RunMigration applies or reverts migration files in MigrationDir, or prints their status. action is up, down or status.
+// steps limits how many migrations are applied or reverted, zero means all pending ones for up and the last one for down.
+func (d Ddl) RunMigration(action string, steps int) {
+	db, _ := connect(d.Conf)
+	defer db.Close()
+	m, err := migrate.NewMigrator(db, d.MigrationDir)
+	if err != nil {
+		logrus.Panicln(err)
+	}
+	var (
+		done []migrate.Migration
+		verb string
+	)
+	switch action {
+	case "up":
+		done, err = m.Up(steps)
+		verb = "applied"
+	case "down":
+		done, err = m.Down(steps)
+		verb = "reverted"
+	case "status":
+		printStatus(m)
+		return
+	default:
+		logrus.Panicf("unknown migrate action %s, up, down or status expected", action)
+	}
+	for _, item := range done {
+		logrus.Infof("migration %s_%s is %s", item.Version, item.Name, verb)
+	}
+	if err != nil {
+		logrus.Panicln(err)
+	}
+	if len(done) == 0 {
+		logrus.Infof("no migration %s", verb)
+	}
+}
+
+func printStatus(m *migrate.Migrator) {
+	list, err := m.Status()
+	if err != nil {
+		logrus.Panicln(err)
+	}
+	tableString := &strings.Builder{}
+	tw := tablewriter.NewWriter(tableString)
+	tw.SetHeader([]string{"Version", "Name", "Applied At"})
+	for _, s := range list {
+		appliedAt := "pending"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		name := s.Name
+		if stringutils.IsEmpty(s.Up) {
+			name = fmt.Sprintf("%s (file missing)", name)
+		}
+		tw.Append([]string{s.Version, name, appliedAt})
+	}
+	tw.Render()
+	for _, row := range strings.Split(strings.TrimSpace(tableString.String()), "\n") {
+		logrus.Infoln(row)
+	}
+}
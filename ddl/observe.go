@@ -0,0 +1,298 @@
+package ddl
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+var queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name: "ddl_query_duration_seconds",
+	Help: "Duration of sql statements.",
+}, []string{"statement"})
+
+var queryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "ddl_query_errors_total",
+	Help: "Number of failed sql statements.",
+}, []string{"statement"})
+
+func init() {
+	prometheus.Register(queryDuration)
+	prometheus.Register(queryErrors)
+}
+
+type statementKey struct{}
+
+var unquoter = strings.NewReplacer("`", "", `"`, "")
+
+// WithStatementName returns a copy of ctx which names statements run through it in logs and metrics of observed
+// queriers. Statements are named by their verb and table by default, e.g. "select user".
+func WithStatementName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, statementKey{}, name)
+}
+
+// StatementName returns name set by WithStatementName, otherwise verb and table of query
+func StatementName(ctx context.Context, query string) string {
+	if name, ok := ctx.Value(statementKey{}).(string); ok && name != "" {
+		return name
+	}
+	verb, table := parseStatement(query)
+	if table == "" {
+		return verb
+	}
+	return verb + " " + table
+}
+
+// parseStatement returns lower case verb and the table read or written by query, table is empty if it's unknown
+func parseStatement(query string) (verb, table string) {
+	verb, tables := statementTables(query)
+	if len(tables) > 0 {
+		table = tables[0]
+	}
+	return verb, table
+}
+
+// tableKeywords are keywords followed by table names
+var tableKeywords = map[string]bool{
+	"from":   true,
+	"join":   true,
+	"into":   true,
+	"update": true,
+}
+
+// clauseKeywords are keywords which may follow table names, so they are not aliases
+var clauseKeywords = map[string]bool{
+	"where": true, "join": true, "inner": true, "left": true, "right": true, "full": true, "cross": true,
+	"natural": true, "straight_join": true, "on": true, "using": true, "group": true, "order": true, "limit": true,
+	"offset": true, "having": true, "union": true, "except": true, "intersect": true, "set": true, "values": true,
+	"value": true, "for": true, "lock": true, "returning": true, "window": true, "select": true,
+}
+
+var statementSpacer = strings.NewReplacer("(", " ( ", ")", " ) ", ",", " , ", ";", " ; ")
+
+// statementTables returns lower case verb and all tables referenced by query, including tables of joins and
+// subqueries. The table read or written by the statement comes first, e.g. user of insert into user select * from
+// staff. Tables are unquoted and unqualified, so that `test`.`user` and user are the same table.
+func statementTables(query string) (verb string, tables []string) {
+	fields := strings.Fields(statementSpacer.Replace(strings.ToLower(query)))
+	if len(fields) == 0 {
+		return "", nil
+	}
+	verb = fields[0]
+	switch verb {
+	case "select", "delete", "insert", "replace", "update":
+	default:
+		return verb, nil
+	}
+	seen := make(map[string]bool)
+	for i, field := range fields {
+		// update of on duplicate key update and for update is not followed by table
+		if !tableKeywords[field] || (field == "update" && i > 0) {
+			continue
+		}
+		// from a, b x, c as y
+		for j := i + 1; j < len(fields); j++ {
+			table := tableName(fields[j])
+			if table == "" {
+				// tables of subquery are collected by its own keywords
+				break
+			}
+			if !seen[table] {
+				seen[table] = true
+				tables = append(tables, table)
+			}
+			if j+1 < len(fields) && fields[j+1] == "as" {
+				j += 2
+			} else if j+1 < len(fields) && tableName(fields[j+1]) != "" {
+				j++
+			}
+			if j+1 >= len(fields) || fields[j+1] != "," {
+				break
+			}
+			j++
+		}
+	}
+	return verb, tables
+}
+
+// tableName unquotes and unqualifies table identifier, it returns empty string if field is not an identifier
+func tableName(field string) string {
+	name := unquoter.Replace(field)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "" || clauseKeywords[name] || strings.ContainsAny(name, "(),;?") {
+		return ""
+	}
+	return name
+}
+
+type observeConfig struct {
+	slowThreshold time.Duration
+	redact        func(args []interface{}) []interface{}
+}
+
+type ObserveOption func(*observeConfig)
+
+// WithSlowThreshold sets duration over which statements are logged as warning, default 1 second.
+// Non positive threshold disables it.
+func WithSlowThreshold(threshold time.Duration) ObserveOption {
+	return func(conf *observeConfig) {
+		conf.slowThreshold = threshold
+	}
+}
+
+// WithRedact sets function masking args in logs, e.g. RedactArgs
+func WithRedact(redact func(args []interface{}) []interface{}) ObserveOption {
+	return func(conf *observeConfig) {
+		conf.redact = redact
+	}
+}
+
+// RedactArgs masks all args
+func RedactArgs(args []interface{}) []interface{} {
+	masked := make([]interface{}, len(args))
+	for i := range masked {
+		masked[i] = "***"
+	}
+	return masked
+}
+
+// ObservedQuerier decorates a Querier. Statements are logged with their args at debug level, statements slower than
+// the threshold are logged at warning level, latency and errors are recorded to prometheus by statement name.
+type ObservedQuerier struct {
+	Querier
+	conf *observeConfig
+}
+
+// NewObservedQuerier decorates q by options
+func NewObservedQuerier(q Querier, options ...ObserveOption) ObservedQuerier {
+	conf := &observeConfig{
+		slowThreshold: time.Second,
+	}
+	for _, opt := range options {
+		opt(conf)
+	}
+	return ObservedQuerier{q, conf}
+}
+
+func (o ObservedQuerier) observe(ctx context.Context, query string, args []interface{}, start time.Time, err error) {
+	elapsed := time.Since(start)
+	name := StatementName(ctx, query)
+	queryDuration.WithLabelValues(name).Observe(elapsed.Seconds())
+	if err != nil {
+		queryErrors.WithLabelValues(name).Inc()
+	}
+	slow := o.conf.slowThreshold > 0 && elapsed > o.conf.slowThreshold
+	if !slow && !logrus.IsLevelEnabled(logrus.DebugLevel) {
+		return
+	}
+	if o.conf.redact != nil {
+		args = o.conf.redact(args)
+	}
+	entry := logrus.WithFields(logrus.Fields{
+		"statement": name,
+		"elapsed":   elapsed.String(),
+	})
+	if err != nil {
+		entry = entry.WithError(err)
+	}
+	if slow {
+		entry.Warnf("slow query: %s %v", query, args)
+	} else {
+		entry.Debugf("%s %v", query, args)
+	}
+}
+
+// namedArg formats arg of named statements lazily
+type namedArg struct {
+	arg interface{}
+}
+
+func (n namedArg) String() string {
+	return fmt.Sprintf("%+v", n.arg)
+}
+
+func (o ObservedQuerier) NamedExecContext(ctx context.Context, query string, arg interface{}) (result sql.Result, err error) {
+	defer func(start time.Time) {
+		o.observe(ctx, query, []interface{}{namedArg{arg}}, start, err)
+	}(time.Now())
+	return o.Querier.NamedExecContext(ctx, query, arg)
+}
+
+func (o ObservedQuerier) ExecContext(ctx context.Context, query string, args ...interface{}) (result sql.Result, err error) {
+	defer func(start time.Time) {
+		o.observe(ctx, query, args, start, err)
+	}(time.Now())
+	return o.Querier.ExecContext(ctx, query, args...)
+}
+
+func (o ObservedQuerier) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) (err error) {
+	defer func(start time.Time) {
+		o.observe(ctx, query, args, start, err)
+	}(time.Now())
+	return o.Querier.GetContext(ctx, dest, query, args...)
+}
+
+func (o ObservedQuerier) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) (err error) {
+	defer func(start time.Time) {
+		o.observe(ctx, query, args, start, err)
+	}(time.Now())
+	return o.Querier.SelectContext(ctx, dest, query, args...)
+}
+
+func (o ObservedQuerier) QueryxContext(ctx context.Context, query string, args ...interface{}) (rows *sqlx.Rows, err error) {
+	defer func(start time.Time) {
+		o.observe(ctx, query, args, start, err)
+	}(time.Now())
+	return o.Querier.QueryxContext(ctx, query, args...)
+}
+
+// ObservedDB decorates a DB like ObservedQuerier, statements of transactions begun by it are observed too
+type ObservedDB struct {
+	ObservedQuerier
+	db DB
+}
+
+// NewObservedDB decorates db by options
+func NewObservedDB(db DB, options ...ObserveOption) ObservedDB {
+	return ObservedDB{NewObservedQuerier(db, options...), db}
+}
+
+func (o ObservedDB) BeginTxx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	tx, err := o.db.BeginTxx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return observedTx{ObservedQuerier{tx, o.conf}, tx}, nil
+}
+
+func (o ObservedDB) Close() error {
+	return o.db.Close()
+}
+
+// DriverName returns driver name of the decorated db if any, RunInTx decides whether to retry transactions by it
+func (o ObservedDB) DriverName() string {
+	if named, ok := o.db.(interface{ DriverName() string }); ok {
+		return named.DriverName()
+	}
+	return ""
+}
+
+type observedTx struct {
+	ObservedQuerier
+	tx Tx
+}
+
+func (o observedTx) Commit() error {
+	return o.tx.Commit()
+}
+
+func (o observedTx) Rollback() error {
+	return o.tx.Rollback()
+}
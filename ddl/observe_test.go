@@ -0,0 +1,104 @@
+package ddl
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatementName(t *testing.T) {
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		query string
+		want  string
+	}{
+		{"select", context.Background(), "SELECT * FROM `user` WHERE id = ?", "select user"},
+		{"insert", context.Background(), "INSERT INTO \"ddl\".\"user\" (name) VALUES (?)", "insert user"},
+		{"update", context.Background(), "update user set name = ?", "update user"},
+		{"delete", context.Background(), "delete from user where id = ?", "delete user"},
+		{"subquery", context.Background(), "select count(1) from (select * from user) t", "select user"},
+		{"other", context.Background(), "SAVEPOINT sp_1", "savepoint"},
+		{"empty", context.Background(), "", ""},
+		{"named", WithStatementName(context.Background(), "UserDao.Get"), "select * from user", "UserDao.Get"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StatementName(tt.ctx, tt.query); got != tt.want {
+				t.Errorf("StatementName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatementTables(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantVerb   string
+		wantTables []string
+	}{
+		{"qualified", "INSERT INTO `test`.`user` (`name`) VALUES (?)", "insert", []string{"user"}},
+		{"bare", "select * from `user` where `id` = ?", "select", []string{"user"}},
+		{"update", "update \"public\".\"user\" set name = ? where id = ?", "update", []string{"user"}},
+		{"upsert", "insert into user (id, name) values (?, ?) on duplicate key update name = values(name)", "insert", []string{"user"}},
+		{"join", "select u.* from user u left join purchase as p on p.user_id = u.id", "select", []string{"user", "purchase"}},
+		{"comma", "select * from user u, `test`.purchase p, item where u.id = p.user_id", "select", []string{"user", "purchase", "item"}},
+		{"exists", "select * from user where exists (select 1 from purchase where purchase.user_id = user.id)", "select", []string{"user", "purchase"}},
+		{"in", "select count(1) from user where id in (select user_id from purchase)", "select", []string{"user", "purchase"}},
+		{"insert select", "insert into user select * from staff", "insert", []string{"user", "staff"}},
+		{"derived", "select count(1) from (select * from user) t", "select", []string{"user"}},
+		{"no table", "select 1", "select", nil},
+		{"other", "SAVEPOINT sp_1", "savepoint", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verb, tables := statementTables(tt.query)
+			if verb != tt.wantVerb || !reflect.DeepEqual(tables, tt.wantTables) {
+				t.Errorf("statementTables() = %v, %v, want %v, %v", verb, tables, tt.wantVerb, tt.wantTables)
+			}
+		})
+	}
+}
+
+func TestObservedDB(t *testing.T) {
+	hook := test.NewGlobal()
+	defer hook.Reset()
+	defer logrus.SetLevel(logrus.GetLevel())
+	logrus.SetLevel(logrus.DebugLevel)
+
+	db := NewObservedDB(newTestDB(t), WithRedact(RedactArgs), WithSlowThreshold(time.Nanosecond))
+	ctx := WithStatementName(context.Background(), "TestObservedDB.insert")
+	if err := RunInTx(ctx, db, func(ctx context.Context, tx Tx) error {
+		return insert(ctx, db, "secret")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var warned bool
+	for _, entry := range hook.AllEntries() {
+		if entry.Data["statement"] != "TestObservedDB.insert" {
+			continue
+		}
+		if strings.Contains(entry.Message, "secret") {
+			t.Errorf("args should be redacted: %s", entry.Message)
+		}
+		if entry.Level == logrus.WarnLevel && strings.HasPrefix(entry.Message, "slow query") {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Error("slow query should be warned")
+	}
+
+	if _, err := db.ExecContext(WithStatementName(context.Background(), "TestObservedDB.bad"), "insert into nothing values (1)"); err == nil {
+		t.Fatal("error expected")
+	}
+	if got := testutil.ToFloat64(queryErrors.WithLabelValues("TestObservedDB.bad")); got != 1 {
+		t.Errorf("errors = %v, want 1", got)
+	}
+}
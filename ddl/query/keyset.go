@@ -0,0 +1,174 @@
+package query
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/unionj-cloud/go-doudou/ddl/sortenum"
+	"github.com/unionj-cloud/go-doudou/reflectutils"
+	"strings"
+	"time"
+)
+
+// Keyset is a cursor based page. Rows are sorted by Orders, After is the cursor returned in KeysetRet.Next by the
+// previous page, empty for the first page. Unlike offset paging, database seeks to the cursor by index instead of
+// scanning skipped rows. Sort columns should not be null, generated dao appends primary key to Orders to make the
+// sort key unique.
+type Keyset struct {
+	Orders []Order
+	After  string
+	Size   int
+}
+
+func K() Keyset {
+	return Keyset{
+		Orders: make([]Order, 0),
+	}
+}
+
+func (k Keyset) Order(o Order) Keyset {
+	k.Orders = append(k.Orders, o)
+	return k
+}
+
+// Limit sets cursor of the page and how many rows it has
+func (k Keyset) Limit(after string, size int) Keyset {
+	k.After = after
+	k.Size = size
+	return k
+}
+
+// Unique appends col in ascending order if Orders doesn't have it, col should be unique such as primary key
+func (k Keyset) Unique(col string) Keyset {
+	for _, o := range k.Orders {
+		if o.Col == col {
+			return k
+		}
+	}
+	orders := make([]Order, len(k.Orders), len(k.Orders)+1)
+	copy(orders, k.Orders)
+	k.Orders = append(orders, Order{Col: col, Sort: sortenum.Asc})
+	return k
+}
+
+// Where returns condition of rows after the cursor, nil for the first page.
+// For orders a asc, b desc it is (a > ? or (a = ? and b < ?)).
+func (k Keyset) Where() (Q, error) {
+	if k.After == "" {
+		return nil, nil
+	}
+	values, err := DecodeCursor(k.After)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != len(k.Orders) {
+		return nil, errors.Errorf("cursor has %d values, but rows are sorted by %d columns", len(values), len(k.Orders))
+	}
+	var ors []Q
+	for i, o := range k.Orders {
+		var ands []Q
+		for j := 0; j < i; j++ {
+			ands = append(ands, C().Col(k.Orders[j].Col).Eq(Literal(values[j])))
+		}
+		if o.Sort == sortenum.Desc {
+			ands = append(ands, C().Col(o.Col).Lt(Literal(values[i])))
+		} else {
+			ands = append(ands, C().Col(o.Col).Gt(Literal(values[i])))
+		}
+		if len(ands) == 1 {
+			ors = append(ors, ands[0])
+		} else {
+			ors = append(ors, And(ands...))
+		}
+	}
+	if len(ors) == 1 {
+		return ors[0], nil
+	}
+	return Or(ors...), nil
+}
+
+// Sql returns order by and limit clause, one more row than Size is selected to tell whether there is a next page,
+// e.g. order by `age` desc,`id` asc limit 11
+func (k Keyset) Sql() string {
+	var stmts []string
+	if len(k.Orders) > 0 {
+		var orders []string
+		for _, o := range k.Orders {
+			sort := o.Sort
+			if sort == "" {
+				sort = sortenum.Asc
+			}
+			orders = append(orders, fmt.Sprintf("%s %s", quoteCol(o.Col), sort))
+		}
+		stmts = append(stmts, "order by "+strings.Join(orders, ","))
+	}
+	if k.Size > 0 {
+		stmts = append(stmts, fmt.Sprintf("limit %d", k.Size+1))
+	}
+	return strings.Join(stmts, " ")
+}
+
+type KeysetRet struct {
+	Items interface{}
+	// Next is cursor of the next page, empty if this is the last page
+	Next    string
+	HasNext bool
+}
+
+// cursorValue keeps time values apart from strings, so that they are bound as time after decoding
+type cursorValue struct {
+	T *time.Time  `json:"t,omitempty"`
+	V interface{} `json:"v"`
+}
+
+// EncodeCursor encodes sort key values of the last row of a page into an opaque cursor
+func EncodeCursor(values ...interface{}) (string, error) {
+	var cvs []cursorValue
+	for _, value := range values {
+		value = arg(reflectutils.ValueOf(value))
+		if t, ok := value.(time.Time); ok {
+			cvs = append(cvs, cursorValue{T: &t})
+			continue
+		}
+		cvs = append(cvs, cursorValue{V: value})
+	}
+	b, err := json.Marshal(cvs)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode cursor")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// DecodeCursor decodes sort key values from cursor returned by EncodeCursor
+func DecodeCursor(cursor string) ([]interface{}, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid cursor")
+	}
+	var cvs []cursorValue
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err = decoder.Decode(&cvs); err != nil {
+		return nil, errors.Wrap(err, "invalid cursor")
+	}
+	var values []interface{}
+	for _, cv := range cvs {
+		if cv.T != nil {
+			values = append(values, *cv.T)
+			continue
+		}
+		if n, ok := cv.V.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				values = append(values, i)
+			} else {
+				f, _ := n.Float64()
+				values = append(values, f)
+			}
+			continue
+		}
+		values = append(values, cv.V)
+	}
+	return values, nil
+}
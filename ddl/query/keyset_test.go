@@ -0,0 +1,151 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/unionj-cloud/go-doudou/ddl/sortenum"
+)
+
+func TestCursor(t *testing.T) {
+	now := time.Date(2021, 8, 1, 10, 30, 0, 0, time.UTC)
+	name := "jack"
+	tests := []struct {
+		name   string
+		values []interface{}
+		want   []interface{}
+	}{
+		{
+			name:   "int",
+			values: []interface{}{18, int64(3)},
+			want:   []interface{}{int64(18), int64(3)},
+		},
+		{
+			name:   "zero values",
+			values: []interface{}{"", 0, false},
+			want:   []interface{}{"", int64(0), false},
+		},
+		{
+			name:   "float",
+			values: []interface{}{1.5},
+			want:   []interface{}{1.5},
+		},
+		{
+			name:   "time",
+			values: []interface{}{now},
+			want:   []interface{}{now},
+		},
+		{
+			name:   "pointer",
+			values: []interface{}{&name, (*string)(nil)},
+			want:   []interface{}{"jack", nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, err := EncodeCursor(tt.values...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := DecodeCursor(cursor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeCursor() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if _, err := DecodeCursor("not a cursor"); err == nil {
+		t.Error("DecodeCursor() want error for invalid cursor")
+	}
+}
+
+func TestKeyset_Sql(t *testing.T) {
+	cursor, _ := EncodeCursor(18, 3)
+	tests := []struct {
+		name      string
+		keyset    Keyset
+		wantWhere string
+		wantArgs  []interface{}
+		wantSql   string
+		wantErr   bool
+	}{
+		{
+			name:    "first page",
+			keyset:  K().Order(Order{Col: "age", Sort: sortenum.Desc}).Unique("id").Limit("", 10),
+			wantSql: "order by `age` desc,`id` asc limit 11",
+		},
+		{
+			name:      "after",
+			keyset:    K().Order(Order{Col: "age", Sort: sortenum.Desc}).Unique("id").Limit(cursor, 10),
+			wantWhere: "(`age` < ? or (`age` = ? and `id` > ?))",
+			wantArgs:  []interface{}{int64(18), int64(18), int64(3)},
+			wantSql:   "order by `age` desc,`id` asc limit 11",
+		},
+		{
+			name:    "unique already sorted",
+			keyset:  K().Order(Order{Col: "id", Sort: sortenum.Desc}).Unique("id").Limit("", 0),
+			wantSql: "order by `id` desc",
+		},
+		{
+			name:    "cursor mismatch",
+			keyset:  K().Order(Order{Col: "id"}).Limit(cursor, 10),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := tt.keyset.Where()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Where() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var (
+				gotWhere string
+				gotArgs  []interface{}
+			)
+			if q != nil {
+				gotWhere, gotArgs = q.Sql()
+			}
+			if gotWhere != tt.wantWhere {
+				t.Errorf("Where() gotSql = %v, want %v", gotWhere, tt.wantWhere)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("Where() gotArgs = %v, want %v", gotArgs, tt.wantArgs)
+			}
+			if got := tt.keyset.Sql(); got != tt.wantSql {
+				t.Errorf("Sql() = %v, want %v", got, tt.wantSql)
+			}
+		})
+	}
+}
+
+func TestNewPageRet(t *testing.T) {
+	tests := []struct {
+		name string
+		page Page
+		want PageRet
+	}{
+		{
+			name: "offset",
+			page: P().Limit(20, 10),
+			want: PageRet{PageNo: 3, PageSize: 10},
+		},
+		{
+			name: "no limit",
+			page: P(),
+			want: PageRet{PageNo: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewPageRet(tt.page); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewPageRet() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -8,11 +8,14 @@ import (
 	"github.com/unionj-cloud/go-doudou/ddl/valtypeenum"
 	"github.com/unionj-cloud/go-doudou/reflectutils"
 	"reflect"
+	"regexp"
 	"strings"
 )
 
+// Q is a where condition. Sql returns the statement with ? placeholders for literal values
+// together with the bind arguments in order.
 type Q interface {
-	Sql() string
+	Sql() (string, []interface{})
 	And(q Q) Q
 	Or(q Q) Q
 }
@@ -47,42 +50,78 @@ type criteria struct {
 	col  string
 	val  Val
 	asym arithsymbol.ArithSymbol
+	// to is the upper bound of between
+	to Val
+	// escape is true if val is a like pattern escaped by escapeLike
+	escape bool
 }
 
-func (c criteria) Sql() string {
-	if c.asym == arithsymbol.In {
-		var sb strings.Builder
-		sb.WriteString(fmt.Sprintf("`%s` %s (", c.col, c.asym))
+// likeEscape is the escape character of patterns built by Contains, HasPrefix and HasSuffix.
+// It is not backslash because backslash has different meanings in string literals of different databases.
+const likeEscape = "!"
 
-		var vals []string
-		switch reflect.TypeOf(c.val.Data).Kind() {
-		case reflect.Slice:
+// escapeLike escapes wildcards % and _ and the escape character itself in s
+func escapeLike(s string) string {
+	return strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_").Replace(s)
+}
+
+// placeholder returns ? and the bind argument for literal values, or the value itself for functions and null
+func placeholder(val Val) (string, []interface{}) {
+	if val.Type != valtypeenum.Literal {
+		return fmt.Sprintf("%v", reflectutils.ValueOf(val.Data)), nil
+	}
+	return "?", []interface{}{arg(reflectutils.ValueOf(val.Data))}
+}
+
+func (c criteria) Sql() (string, []interface{}) {
+	switch c.asym {
+	case arithsymbol.In, arithsymbol.NotIn:
+		var (
+			vals []string
+			args []interface{}
+		)
+		if reflect.TypeOf(c.val.Data).Kind() == reflect.Slice {
 			data := reflect.ValueOf(c.val.Data)
 			for i := 0; i < data.Len(); i++ {
 				if c.val.Type != valtypeenum.Literal {
 					vals = append(vals, fmt.Sprintf("%v", reflectutils.ValueOfValue(data.Index(i))))
 				} else {
-					vals = append(vals, fmt.Sprintf("'%v'", reflectutils.ValueOfValue(data.Index(i))))
+					vals = append(vals, "?")
+					args = append(args, arg(reflectutils.ValueOfValue(data.Index(i))))
 				}
 			}
-		default:
-			if c.val.Type != valtypeenum.Literal {
-				vals = append(vals, fmt.Sprintf("%v", reflectutils.ValueOf(c.val.Data)))
-			} else {
-				vals = append(vals, fmt.Sprintf("'%v'", reflectutils.ValueOf(c.val.Data)))
+			// in () is a syntax error, nothing is in an empty set
+			if len(vals) == 0 {
+				if c.asym == arithsymbol.In {
+					return "1 = 0", nil
+				}
+				return "1 = 1", nil
 			}
+		} else {
+			val, valArgs := placeholder(c.val)
+			vals = append(vals, val)
+			args = append(args, valArgs...)
 		}
-
-		sb.WriteString(strings.Join(vals, ","))
-		sb.WriteString(")")
-
-		return sb.String()
-	} else {
-		if c.val.Type != valtypeenum.Literal {
-			return fmt.Sprintf("`%s` %s %v", c.col, c.asym, reflectutils.ValueOf(c.val.Data))
+		return fmt.Sprintf("`%s` %s (%s)", c.col, c.asym, strings.Join(vals, ",")), args
+	case arithsymbol.Between:
+		from, args := placeholder(c.val)
+		to, toArgs := placeholder(c.to)
+		return fmt.Sprintf("`%s` %s %s and %s", c.col, c.asym, from, to), append(args, toArgs...)
+	default:
+		val, args := placeholder(c.val)
+		if c.escape {
+			return fmt.Sprintf("`%s` %s %s escape '%s'", c.col, c.asym, val, likeEscape), args
 		}
-		return fmt.Sprintf("`%s` %s '%v'", c.col, c.asym, reflectutils.ValueOf(c.val.Data))
+		return fmt.Sprintf("`%s` %s %s", c.col, c.asym, val), args
+	}
+}
+
+// arg returns the underlying value of a dereferenced literal as bind argument, nil pointer becomes nil
+func arg(val reflect.Value) interface{} {
+	if !val.IsValid() {
+		return nil
 	}
+	return val.Interface()
 }
 
 func C() criteria {
@@ -148,22 +187,64 @@ func (c criteria) In(val Val) criteria {
 	return c
 }
 
+func (c criteria) NotIn(val Val) criteria {
+	c.val = val
+	c.asym = arithsymbol.NotIn
+	return c
+}
+
+// Like matches val as a pattern, % and _ in val are wildcards
+func (c criteria) Like(val Val) criteria {
+	c.val = val
+	c.asym = arithsymbol.Like
+	c.escape = false
+	return c
+}
+
+// NotLike is the negation of Like
+func (c criteria) NotLike(val Val) criteria {
+	c.val = val
+	c.asym = arithsymbol.NotLike
+	c.escape = false
+	return c
+}
+
+// Contains matches values containing s. Wildcards in s are escaped.
+func (c criteria) Contains(s string) criteria {
+	return c.likeEscaped("%" + escapeLike(s) + "%")
+}
+
+// HasPrefix matches values starting with s. Wildcards in s are escaped.
+func (c criteria) HasPrefix(s string) criteria {
+	return c.likeEscaped(escapeLike(s) + "%")
+}
+
+// HasSuffix matches values ending with s. Wildcards in s are escaped.
+func (c criteria) HasSuffix(s string) criteria {
+	return c.likeEscaped("%" + escapeLike(s))
+}
+
+func (c criteria) likeEscaped(pattern string) criteria {
+	c.val = Literal(pattern)
+	c.asym = arithsymbol.Like
+	c.escape = true
+	return c
+}
+
+// Between matches values in the closed interval [from, to]
+func (c criteria) Between(from, to Val) criteria {
+	c.val = from
+	c.to = to
+	c.asym = arithsymbol.Between
+	return c
+}
+
 func (c criteria) And(cri Q) Q {
-	w := where{
-		children: make([]Q, 0),
-	}
-	w.children = append(w.children, c, cri)
-	w.lsym = logicsymbol.And
-	return w
+	return And(c, cri)
 }
 
 func (c criteria) Or(cri Q) Q {
-	w := where{
-		children: make([]Q, 0),
-	}
-	w.children = append(w.children, c, cri)
-	w.lsym = logicsymbol.Or
-	return w
+	return Or(c, cri)
 }
 
 type where struct {
@@ -171,26 +252,205 @@ type where struct {
 	children []Q
 }
 
-func (w where) Sql() string {
-	return fmt.Sprintf("(%s %s %s)", w.children[0].Sql(), w.lsym, w.children[1].Sql())
+func (w where) Sql() (string, []interface{}) {
+	if len(w.children) == 0 {
+		// and of nothing is true, or of nothing is false
+		if w.lsym == logicsymbol.Or {
+			return "1 = 0", nil
+		}
+		return "1 = 1", nil
+	}
+	var (
+		stmts []string
+		args  []interface{}
+	)
+	for _, child := range w.children {
+		stmt, childArgs := child.Sql()
+		stmts = append(stmts, stmt)
+		args = append(args, childArgs...)
+	}
+	return fmt.Sprintf("(%s)", strings.Join(stmts, fmt.Sprintf(" %s ", w.lsym))), args
 }
 
 func (w where) And(whe Q) Q {
-	parentW := where{
-		children: make([]Q, 0),
-	}
-	parentW.children = append(parentW.children, w, whe)
-	parentW.lsym = logicsymbol.And
-	return parentW
+	return And(w, whe)
 }
 
 func (w where) Or(whe Q) Q {
-	parentW := where{
-		children: make([]Q, 0),
+	return Or(w, whe)
+}
+
+// And groups qs with and, e.g. (a and b and c)
+func And(qs ...Q) Q {
+	return where{
+		lsym:     logicsymbol.And,
+		children: qs,
+	}
+}
+
+// Or groups qs with or, e.g. (a or b or c)
+func Or(qs ...Q) Q {
+	return where{
+		lsym:     logicsymbol.Or,
+		children: qs,
 	}
-	parentW.children = append(parentW.children, w, whe)
-	parentW.lsym = logicsymbol.Or
-	return parentW
+}
+
+type raw struct {
+	sql  string
+	args []interface{}
+}
+
+func (r raw) Sql() (string, []interface{}) {
+	return r.sql, r.args
+}
+
+func (r raw) And(q Q) Q {
+	return And(r, q)
+}
+
+func (r raw) Or(q Q) Q {
+	return Or(r, q)
+}
+
+// Raw is a sql fragment with ? placeholders and their bind arguments, it is used as is
+func Raw(sql string, args ...interface{}) Q {
+	return raw{
+		sql:  sql,
+		args: args,
+	}
+}
+
+// Exists matches if subquery returns any row, subquery can have ? placeholders bound by args
+func Exists(subquery string, args ...interface{}) Q {
+	return Raw(fmt.Sprintf("exists (%s)", subquery), args...)
+}
+
+// NotExists matches if subquery returns no row, subquery can have ? placeholders bound by args
+func NotExists(subquery string, args ...interface{}) Q {
+	return Raw(fmt.Sprintf("not exists (%s)", subquery), args...)
+}
+
+var identReg = regexp.MustCompile(`^\w+(\.\w+)?$`)
+
+// quoteCol quotes plain column name such as name or user.name, expressions are returned as they are
+func quoteCol(col string) string {
+	if !identReg.MatchString(col) {
+		return col
+	}
+	return "`" + strings.Join(strings.Split(col, "."), "`.`") + "`"
+}
+
+// Count returns count(col) expression, col * counts rows
+func Count(col string) string {
+	return fmt.Sprintf("count(%s)", quoteCol(col))
+}
+
+// CountDistinct returns count(distinct col) expression
+func CountDistinct(col string) string {
+	return fmt.Sprintf("count(distinct %s)", quoteCol(col))
+}
+
+// Sum returns sum(col) expression
+func Sum(col string) string {
+	return fmt.Sprintf("sum(%s)", quoteCol(col))
+}
+
+// Avg returns avg(col) expression
+func Avg(col string) string {
+	return fmt.Sprintf("avg(%s)", quoteCol(col))
+}
+
+// Max returns max(col) expression
+func Max(col string) string {
+	return fmt.Sprintf("max(%s)", quoteCol(col))
+}
+
+// Min returns min(col) expression
+func Min(col string) string {
+	return fmt.Sprintf("min(%s)", quoteCol(col))
+}
+
+// As aliases column or expression, the alias is the name to scan the value into
+func As(expr string, alias string) string {
+	return fmt.Sprintf("%s as %s", quoteCol(expr), quoteCol(alias))
+}
+
+// Sel is the projection of a query: selected columns, distinct, group by and having
+type Sel struct {
+	cols     []string
+	distinct bool
+	groupBy  []string
+	having   Q
+}
+
+// S selects cols. Plain column names are quoted, expressions such as Count("id") or As(Sum("amount"), "total")
+// are used as they are. No cols selects all columns.
+func S(cols ...string) Sel {
+	return Sel{
+		cols: cols,
+	}
+}
+
+// Distinct removes duplicate rows
+func (s Sel) Distinct() Sel {
+	s.distinct = true
+	return s
+}
+
+// GroupBy groups rows by cols
+func (s Sel) GroupBy(cols ...string) Sel {
+	s.groupBy = append(s.groupBy, cols...)
+	return s
+}
+
+// Having filters groups. Column names of criteria are quoted, use Raw for aggregate expressions,
+// e.g. Raw("count(1) > ?", 1)
+func (s Sel) Having(q Q) Sel {
+	s.having = q
+	return s
+}
+
+// Sql returns select statement of table without where clause, e.g. select distinct `name` from user
+func (s Sel) Sql(table string) string {
+	var sb strings.Builder
+	sb.WriteString("select ")
+	if s.distinct {
+		sb.WriteString("distinct ")
+	}
+	if len(s.cols) == 0 {
+		sb.WriteString("*")
+	}
+	for i, col := range s.cols {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(quoteCol(col))
+	}
+	sb.WriteString(" from ")
+	sb.WriteString(table)
+	return sb.String()
+}
+
+// GroupSql returns group by and having clause to be appended after where clause, empty if not grouped
+func (s Sel) GroupSql() (string, []interface{}) {
+	var (
+		stmts []string
+		args  []interface{}
+	)
+	if len(s.groupBy) > 0 {
+		var cols []string
+		for _, col := range s.groupBy {
+			cols = append(cols, quoteCol(col))
+		}
+		stmts = append(stmts, "group by "+strings.Join(cols, ","))
+	}
+	if s.having != nil {
+		having, havingArgs := s.having.Sql()
+		stmts = append(stmts, "having "+having)
+		args = append(args, havingArgs...)
+	}
+	return strings.Join(stmts, " "), args
 }
 
 type Order struct {
@@ -221,7 +481,7 @@ func (p Page) Limit(offset, size int) Page {
 	return p
 }
 
-// order by age desc limit 2,1
+// order by age desc limit 1 offset 2
 func (p Page) Sql() string {
 	var sb strings.Builder
 
@@ -239,7 +499,7 @@ func (p Page) Sql() string {
 	sb.WriteString(" ")
 
 	if p.Size > 0 {
-		sb.WriteString(fmt.Sprintf("limit %d,%d", p.Offset, p.Size))
+		sb.WriteString(fmt.Sprintf("limit %d offset %d", p.Size, p.Offset))
 	}
 
 	return strings.TrimSpace(sb.String())
@@ -253,8 +513,12 @@ type PageRet struct {
 	HasNext  bool
 }
 
+// NewPageRet returns page number and size of page, the only page is number 1 if Size is 0 which means no limit
 func NewPageRet(page Page) PageRet {
-	pageNo := page.Offset/page.Size + 1
+	pageNo := 1
+	if page.Size > 0 {
+		pageNo = page.Offset/page.Size + 1
+	}
 	return PageRet{
 		PageNo:   pageNo,
 		PageSize: page.Size,
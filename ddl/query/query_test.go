@@ -2,10 +2,13 @@ package query
 
 import (
 	"fmt"
+	"reflect"
+	"testing"
+
 	"github.com/unionj-cloud/go-doudou/ddl/sortenum"
 )
 
-func ExampleCriteria() {
+func ExampleC() {
 
 	query := C().Col("name").Eq(Literal("wubin")).Or(C().Col("school").Eq(Literal("havard"))).And(C().Col("age").Eq(Literal(18)))
 	fmt.Println(query.Sql())
@@ -69,17 +72,287 @@ func ExampleCriteria() {
 	fmt.Println(query.Sql())
 
 	// Output:
-	// ((`name` = 'wubin' or `school` = 'havard') and `age` = '18')
-	// ((`name` = 'wubin' or `school` = 'havard') and `delete_at` is not null)
-	// ((`name` = 'wubin' or `school` in ('havard')) and `delete_at` is not null)
-	// ((`name` = 'wubin' or `school` in ('havard','beijing unv')) and `delete_at` is not null)
-	// ((`name` = 'wubin' or `age` in ('10','5')) and `delete_at` is not null)
-	// (`name` != 'wubin' or `create_at` < now())
-	// (`name` != 'wubin' or `create_at` <= now())
-	// (`name` != 'wubin' or `create_at` > now())
-	// (`name` != 'wubin' or `create_at` >= now())
-	// order by create_at desc,score asc limit 30,5
+	// ((`name` = ? or `school` = ?) and `age` = ?) [wubin havard 18]
+	// ((`name` = ? or `school` = ?) and `delete_at` is not null) [wubin havard]
+	// ((`name` = ? or `school` in (?)) and `delete_at` is not null) [wubin havard]
+	// ((`name` = ? or `school` in (?,?)) and `delete_at` is not null) [wubin havard beijing unv]
+	// ((`name` = ? or `age` in (?,?)) and `delete_at` is not null) [wubin 10 5]
+	// (`name` != ? or `create_at` < now()) [wubin]
+	// (`name` != ? or `create_at` <= now()) [wubin]
+	// (`name` != ? or `create_at` > now()) [wubin]
+	// (`name` != ? or `create_at` >= now()) [wubin]
+	// order by create_at desc,score asc limit 5 offset 30
 	// 7
-	// order by score asc limit 20,10
-	// (((`name` = 'wubin' or `school` = 'havard') and `age` = '18') or `score` >= '90')
+	// order by score asc limit 10 offset 20
+	// (((`name` = ? or `school` = ?) and `age` = ?) or `score` >= ?) [wubin havard 18 90]
+}
+
+func TestCriteria_Sql(t *testing.T) {
+	var nilName *string
+	tests := []struct {
+		name     string
+		q        Q
+		wantSql  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "quote",
+			q:        C().Col("name").Eq(Literal("wu'bin")),
+			wantSql:  "`name` = ?",
+			wantArgs: []interface{}{"wu'bin"},
+		},
+		{
+			name:     "injection",
+			q:        C().Col("name").Eq(Literal("' or '1'='1")).And(C().Col("age").Gt(Literal(18))),
+			wantSql:  "(`name` = ? and `age` > ?)",
+			wantArgs: []interface{}{"' or '1'='1", 18},
+		},
+		{
+			name:     "nil pointer",
+			q:        C().Col("name").Eq(Literal(nilName)),
+			wantSql:  "`name` = ?",
+			wantArgs: []interface{}{nil},
+		},
+		{
+			name:     "func",
+			q:        C().Col("create_at").Lt(Func("now()")),
+			wantSql:  "`create_at` < now()",
+			wantArgs: nil,
+		},
+		{
+			name:     "null",
+			q:        C().Col("delete_at").IsNull(),
+			wantSql:  "`delete_at` is null",
+			wantArgs: nil,
+		},
+		{
+			name:     "eq",
+			q:        C().Col("age").Eq(Literal(18)),
+			wantSql:  "`age` = ?",
+			wantArgs: []interface{}{18},
+		},
+		{
+			name:     "ne",
+			q:        C().Col("age").Ne(Literal(18)),
+			wantSql:  "`age` != ?",
+			wantArgs: []interface{}{18},
+		},
+		{
+			name:     "gt",
+			q:        C().Col("age").Gt(Literal(18)),
+			wantSql:  "`age` > ?",
+			wantArgs: []interface{}{18},
+		},
+		{
+			name:     "lt",
+			q:        C().Col("age").Lt(Literal(18)),
+			wantSql:  "`age` < ?",
+			wantArgs: []interface{}{18},
+		},
+		{
+			name:     "gte",
+			q:        C().Col("age").Gte(Literal(18)),
+			wantSql:  "`age` >= ?",
+			wantArgs: []interface{}{18},
+		},
+		{
+			name:     "lte",
+			q:        C().Col("age").Lte(Literal(18)),
+			wantSql:  "`age` <= ?",
+			wantArgs: []interface{}{18},
+		},
+		{
+			name:     "is not null",
+			q:        C().Col("delete_at").IsNotNull(),
+			wantSql:  "`delete_at` is not null",
+			wantArgs: nil,
+		},
+		{
+			name:     "in",
+			q:        C().Col("age").In(Literal([]int{18, 20})),
+			wantSql:  "`age` in (?,?)",
+			wantArgs: []interface{}{18, 20},
+		},
+		{
+			name:     "in single",
+			q:        C().Col("age").In(Literal(18)),
+			wantSql:  "`age` in (?)",
+			wantArgs: []interface{}{18},
+		},
+		{
+			name:     "in empty",
+			q:        C().Col("age").In(Literal([]int{})),
+			wantSql:  "1 = 0",
+			wantArgs: nil,
+		},
+		{
+			name:     "not in",
+			q:        C().Col("school").NotIn(Literal([]string{"havard", "beijing unv"})),
+			wantSql:  "`school` not in (?,?)",
+			wantArgs: []interface{}{"havard", "beijing unv"},
+		},
+		{
+			name:     "not in empty",
+			q:        C().Col("school").NotIn(Literal([]string{})),
+			wantSql:  "1 = 1",
+			wantArgs: nil,
+		},
+		{
+			name:     "like",
+			q:        C().Col("name").Like(Literal("wu%")),
+			wantSql:  "`name` like ?",
+			wantArgs: []interface{}{"wu%"},
+		},
+		{
+			name:     "not like",
+			q:        C().Col("name").NotLike(Literal("%bin")),
+			wantSql:  "`name` not like ?",
+			wantArgs: []interface{}{"%bin"},
+		},
+		{
+			name:     "contains",
+			q:        C().Col("name").Contains("50%_off!"),
+			wantSql:  "`name` like ? escape '!'",
+			wantArgs: []interface{}{"%50!%!_off!!%"},
+		},
+		{
+			name:     "has prefix",
+			q:        C().Col("name").HasPrefix("wu_"),
+			wantSql:  "`name` like ? escape '!'",
+			wantArgs: []interface{}{"wu!_%"},
+		},
+		{
+			name:     "has suffix",
+			q:        C().Col("name").HasSuffix("%bin"),
+			wantSql:  "`name` like ? escape '!'",
+			wantArgs: []interface{}{"%!%bin"},
+		},
+		{
+			name:     "between",
+			q:        C().Col("age").Between(Literal(18), Literal(30)),
+			wantSql:  "`age` between ? and ?",
+			wantArgs: []interface{}{18, 30},
+		},
+		{
+			name:     "between func",
+			q:        C().Col("create_at").Between(Literal("2021-01-01"), Func("now()")),
+			wantSql:  "`create_at` between ? and now()",
+			wantArgs: []interface{}{"2021-01-01"},
+		},
+		{
+			name:     "exists",
+			q:        Exists("select 1 from purchase where purchase.user_id = user.id and purchase.status = ?", 1),
+			wantSql:  "exists (select 1 from purchase where purchase.user_id = user.id and purchase.status = ?)",
+			wantArgs: []interface{}{1},
+		},
+		{
+			name:     "not exists",
+			q:        NotExists("select 1 from purchase where purchase.user_id = user.id"),
+			wantSql:  "not exists (select 1 from purchase where purchase.user_id = user.id)",
+			wantArgs: nil,
+		},
+		{
+			name:     "raw",
+			q:        Raw("json_contains(`tags`, ?)", `"go"`).And(C().Col("age").Gt(Literal(18))),
+			wantSql:  "(json_contains(`tags`, ?) and `age` > ?)",
+			wantArgs: []interface{}{`"go"`, 18},
+		},
+		{
+			name:     "and",
+			q:        And(C().Col("name").Eq(Literal("wubin")), C().Col("age").Gt(Literal(18)), C().Col("delete_at").IsNull()),
+			wantSql:  "(`name` = ? and `age` > ? and `delete_at` is null)",
+			wantArgs: []interface{}{"wubin", 18},
+		},
+		{
+			name: "or of and",
+			q: Or(
+				And(C().Col("name").Eq(Literal("wubin")), C().Col("age").Gt(Literal(18))),
+				C().Col("school").In(Literal([]string{"havard"})),
+				Raw("`score` > ?", 90),
+			),
+			wantSql:  "((`name` = ? and `age` > ?) or `school` in (?) or `score` > ?)",
+			wantArgs: []interface{}{"wubin", 18, "havard", 90},
+		},
+		{
+			name:     "and empty",
+			q:        And(),
+			wantSql:  "1 = 1",
+			wantArgs: nil,
+		},
+		{
+			name:     "or empty",
+			q:        Or(),
+			wantSql:  "1 = 0",
+			wantArgs: nil,
+		},
+		{
+			name:     "chained",
+			q:        And(C().Col("a").Eq(Literal(1)), C().Col("b").Eq(Literal(2))).Or(C().Col("c").Eq(Literal(3))),
+			wantSql:  "((`a` = ? and `b` = ?) or `c` = ?)",
+			wantArgs: []interface{}{1, 2, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSql, gotArgs := tt.q.Sql()
+			if gotSql != tt.wantSql {
+				t.Errorf("Sql() gotSql = %v, want %v", gotSql, tt.wantSql)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("Sql() gotArgs = %v, want %v", gotArgs, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestSel_Sql(t *testing.T) {
+	tests := []struct {
+		name      string
+		sel       Sel
+		wantSql   string
+		wantGroup string
+		wantArgs  []interface{}
+	}{
+		{
+			name:    "all",
+			sel:     S(),
+			wantSql: "select * from user",
+		},
+		{
+			name:    "cols",
+			sel:     S("id", "user.name"),
+			wantSql: "select `id`,`user`.`name` from user",
+		},
+		{
+			name:    "distinct",
+			sel:     S("school").Distinct(),
+			wantSql: "select distinct `school` from user",
+		},
+		{
+			name:    "aggregate",
+			sel:     S(Count("*"), CountDistinct("school"), As(Sum("age"), "total"), Avg("age"), Max("age"), Min("age")),
+			wantSql: "select count(*),count(distinct `school`),sum(`age`) as `total`,avg(`age`),max(`age`),min(`age`) from user",
+		},
+		{
+			name:      "group by",
+			sel:       S("school", As(Count("*"), "n")).GroupBy("school").Having(Raw("count(1) > ?", 1)),
+			wantSql:   "select `school`,count(*) as `n` from user",
+			wantGroup: "group by `school` having count(1) > ?",
+			wantArgs:  []interface{}{1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sel.Sql("user"); got != tt.wantSql {
+				t.Errorf("Sql() = %v, want %v", got, tt.wantSql)
+			}
+			gotGroup, gotArgs := tt.sel.GroupSql()
+			if gotGroup != tt.wantGroup {
+				t.Errorf("GroupSql() gotSql = %v, want %v", gotGroup, tt.wantGroup)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("GroupSql() gotArgs = %v, want %v", gotArgs, tt.wantArgs)
+			}
+		})
+	}
 }
@@ -0,0 +1,64 @@
+package ddl
+
+import (
+	"context"
+	"reflect"
+)
+
+type deletedKey struct{}
+
+type operatorKey struct{}
+
+// WithDeleted returns a copy of ctx with which generated daos select soft deleted rows as well
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deletedKey{}, true)
+}
+
+// DeletedIncluded reports whether ctx is returned by WithDeleted
+func DeletedIncluded(ctx context.Context) bool {
+	included, _ := ctx.Value(deletedKey{}).(bool)
+	return included
+}
+
+// WithOperator returns a copy of ctx carrying operator, e.g. id or name of the current user. Generated daos set
+// fields tagged by createdby and updatedby to it.
+func WithOperator(ctx context.Context, operator interface{}) context.Context {
+	return context.WithValue(ctx, operatorKey{}, operator)
+}
+
+// OperatorFromContext returns the operator carried by ctx
+func OperatorFromContext(ctx context.Context) (interface{}, bool) {
+	operator := ctx.Value(operatorKey{})
+	return operator, operator != nil
+}
+
+// SetOperator sets the field dest points to as operator carried by ctx converted to type of the field, pointer fields
+// are supported. False is returned if ctx has no operator or the operator is not convertible.
+func SetOperator(ctx context.Context, dest interface{}) bool {
+	operator, ok := OperatorFromContext(ctx)
+	if !ok {
+		return false
+	}
+	field := reflect.ValueOf(dest)
+	if field.Kind() != reflect.Ptr || field.IsNil() {
+		return false
+	}
+	field = field.Elem()
+	typ := field.Type()
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	value := reflect.ValueOf(operator)
+	// reflect converts integers to strings as runes
+	if !value.Type().ConvertibleTo(typ) || (typ.Kind() == reflect.String) != (value.Kind() == reflect.String) {
+		return false
+	}
+	value = value.Convert(typ)
+	if field.Kind() == reflect.Ptr {
+		ptr := reflect.New(typ)
+		ptr.Elem().Set(value)
+		value = ptr
+	}
+	field.Set(value)
+	return true
+}
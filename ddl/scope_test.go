@@ -0,0 +1,77 @@
+package ddl
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDeletedIncluded(t *testing.T) {
+	if DeletedIncluded(context.Background()) {
+		t.Error("deleted rows should be excluded by default")
+	}
+	if !DeletedIncluded(WithDeleted(context.Background())) {
+		t.Error("deleted rows should be included")
+	}
+}
+
+func TestSetOperator(t *testing.T) {
+	type row struct {
+		CreatedBy string
+		UpdatedBy *int64
+		Owner     int
+	}
+	tests := []struct {
+		name     string
+		operator interface{}
+		dest     func(r *row) interface{}
+		want     bool
+		check    func(r row) bool
+	}{
+		{
+			name:     "string",
+			operator: "jack",
+			dest:     func(r *row) interface{} { return &r.CreatedBy },
+			want:     true,
+			check:    func(r row) bool { return r.CreatedBy == "jack" },
+		},
+		{
+			name:     "pointer",
+			operator: 1,
+			dest:     func(r *row) interface{} { return &r.UpdatedBy },
+			want:     true,
+			check:    func(r row) bool { return r.UpdatedBy != nil && *r.UpdatedBy == 1 },
+		},
+		{
+			name:     "int to string",
+			operator: 65,
+			dest:     func(r *row) interface{} { return &r.CreatedBy },
+			check:    func(r row) bool { return r.CreatedBy == "" },
+		},
+		{
+			name:     "string to int",
+			operator: "jack",
+			dest:     func(r *row) interface{} { return &r.Owner },
+			check:    func(r row) bool { return r.Owner == 0 },
+		},
+		{
+			name:  "no operator",
+			dest:  func(r *row) interface{} { return &r.CreatedBy },
+			check: func(r row) bool { return r.CreatedBy == "" },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.operator != nil {
+				ctx = WithOperator(ctx, tt.operator)
+			}
+			var r row
+			if got := SetOperator(ctx, tt.dest(&r)); got != tt.want {
+				t.Errorf("SetOperator() = %v, want %v", got, tt.want)
+			}
+			if !tt.check(r) {
+				t.Errorf("unexpected row %+v", r)
+			}
+		})
+	}
+}
@@ -0,0 +1,400 @@
+package ddl
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/goccy/go-yaml"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/unionj-cloud/go-doudou/astutils"
+	"github.com/unionj-cloud/go-doudou/ddl/dialect"
+	"github.com/unionj-cloud/go-doudou/ddl/table"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Fixtures maps name of domain struct to rows to seed, keys of a row are field names or column names, e.g.
+//
+//	User:
+//	  - id: 1
+//	    name: jack
+type Fixtures map[string][]map[string]interface{}
+
+// LoadFixtures reads yaml and json fixture files. Folders are walked for .yml, .yaml and .json files, rows of the
+// same struct in different files are appended in file name order.
+func LoadFixtures(paths ...string) (Fixtures, error) {
+	var files []string
+	for _, path := range paths {
+		err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			switch strings.ToLower(filepath.Ext(path)) {
+			case ".yml", ".yaml", ".json":
+				if !info.IsDir() {
+					files = append(files, path)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to walk %s", path)
+		}
+	}
+	sort.Strings(files)
+	fixtures := make(Fixtures)
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", file)
+		}
+		var loaded Fixtures
+		if strings.EqualFold(filepath.Ext(file), ".json") {
+			decoder := json.NewDecoder(bytes.NewReader(data))
+			decoder.UseNumber()
+			err = decoder.Decode(&loaded)
+		} else {
+			err = yaml.Unmarshal(data, &loaded)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s", file)
+		}
+		for name, rows := range loaded {
+			fixtures[name] = append(fixtures[name], rows...)
+		}
+	}
+	return fixtures, nil
+}
+
+type seedConfig struct {
+	truncate bool
+	upsert   bool
+	prefix   string
+}
+
+type SeedOption func(*seedConfig)
+
+// WithTruncate deletes all rows of seeded tables before seeding, tables referencing others are emptied first
+func WithTruncate() SeedOption {
+	return func(conf *seedConfig) {
+		conf.truncate = true
+	}
+}
+
+// WithUpsert upserts rows instead of inserting them, so that seeding can be run again
+func WithUpsert() SeedOption {
+	return func(conf *seedConfig) {
+		conf.upsert = true
+	}
+}
+
+// WithTablePrefix sets table name prefix of domain structs of daos passed to Register, e.g. biz_
+func WithTablePrefix(prefix string) SeedOption {
+	return func(conf *seedConfig) {
+		conf.prefix = prefix
+	}
+}
+
+// Seeder loads fixtures into database in one transaction. Rows of a struct are inserted by Insert or Upsert method
+// of its generated dao registered by Register, so that they are stamped and ids are generated as usual, or by
+// statements built from its domain struct registered by RegisterTables. Tables are seeded in foreign key dependency
+// order.
+type Seeder struct {
+	db     DB
+	conf   *seedConfig
+	tables map[string]table.Table
+	daos   map[string]reflect.Value
+}
+
+// NewSeeder creates a Seeder writing to db by options
+func NewSeeder(db DB, options ...SeedOption) *Seeder {
+	conf := &seedConfig{}
+	for _, opt := range options {
+		opt(conf)
+	}
+	return &Seeder{
+		db:     db,
+		conf:   conf,
+		tables: make(map[string]table.Table),
+		daos:   make(map[string]reflect.Value),
+	}
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Register registers generated daos, e.g. dao.NewUserDao(db). It panics if a dao has no Insert and Upsert methods
+// taking context and pointer to domain struct.
+func (s *Seeder) Register(daos ...interface{}) *Seeder {
+	for _, dao := range daos {
+		v := reflect.ValueOf(dao)
+		var domain reflect.Type
+		for _, name := range []string{"Insert", "Upsert"} {
+			method := v.MethodByName(name)
+			if !method.IsValid() {
+				panic(fmt.Sprintf("%T has no %s method", dao, name))
+			}
+			mt := method.Type()
+			if mt.NumIn() != 2 || mt.In(0) != contextType || mt.In(1).Kind() != reflect.Ptr ||
+				mt.In(1).Elem().Kind() != reflect.Struct || mt.NumOut() != 2 || mt.Out(1) != errorType {
+				panic(fmt.Sprintf("%s method of %T should be like %s(ctx context.Context, data *domain.User) (int64, error)", name, dao, name))
+			}
+			domain = mt.In(1).Elem()
+		}
+		t := table.NewTableFromStruct(structMetaOf(domain), s.conf.prefix)
+		s.tables[domain.Name()] = t
+		s.daos[domain.Name()] = v
+	}
+	return s
+}
+
+// RegisterTables registers tables parsed from domain structs, rows of them are inserted by statements built from
+// columns of the tables
+func (s *Seeder) RegisterTables(tables ...table.Table) *Seeder {
+	for _, t := range tables {
+		s.tables[t.Meta.Name] = t
+	}
+	return s
+}
+
+// structMetaOf converts struct type to StructMeta for parsing dd tags, fields of embedded structs are flattened
+func structMetaOf(t reflect.Type) astutils.StructMeta {
+	var fields []astutils.FieldMeta
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			fields = append(fields, structMetaOf(field.Type).Fields...)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		fields = append(fields, astutils.FieldMeta{
+			Name: field.Name,
+			Type: field.Type.String(),
+			Tag:  string(field.Tag),
+		})
+	}
+	return astutils.StructMeta{
+		Name:   t.Name(),
+		Fields: fields,
+	}
+}
+
+// Seed loads fixtures in one transaction, nothing is written if any row fails
+func (s *Seeder) Seed(ctx context.Context, fixtures Fixtures) error {
+	var driver string
+	if named, ok := s.db.(interface{ DriverName() string }); ok {
+		driver = named.DriverName()
+	}
+	d, err := dialect.Get(driver)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(fixtures))
+	for name := range fixtures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var tables []table.Table
+	for _, name := range names {
+		t, ok := s.tables[name]
+		if !ok {
+			return errors.Errorf("no dao or domain struct registered for fixtures of %s", name)
+		}
+		tables = append(tables, t)
+	}
+	tables = table.SortByFk(tables)
+	return RunInTx(ctx, s.db, func(ctx context.Context, tx Tx) error {
+		if s.conf.truncate {
+			for i := len(tables) - 1; i >= 0; i-- {
+				if _, err := tx.ExecContext(ctx, "DELETE FROM "+d.Quote(tables[i].Name)); err != nil {
+					return errors.Wrapf(err, "failed to truncate table %s", tables[i].Name)
+				}
+			}
+		}
+		for _, t := range tables {
+			rows := fixtures[t.Meta.Name]
+			for i, row := range rows {
+				var err error
+				if dao, ok := s.daos[t.Meta.Name]; ok {
+					err = s.callDao(ctx, dao, t, row)
+				} else {
+					err = s.exec(ctx, tx, d, t, row)
+				}
+				if err != nil {
+					return errors.Wrapf(err, "failed to seed row %d of %s", i, t.Meta.Name)
+				}
+			}
+			logrus.Infof("%d rows are seeded into table %s", len(rows), t.Name)
+		}
+		return nil
+	})
+}
+
+// seedColumn finds column of table by field name or column name
+func seedColumn(t table.Table, key string) (table.Column, bool) {
+	for _, col := range t.Columns {
+		if strings.EqualFold(col.Meta.Name, key) || col.Name == key {
+			return col, true
+		}
+	}
+	return table.Column{}, false
+}
+
+// callDao calls Insert or Upsert method of dao with ctx carrying the transaction
+func (s *Seeder) callDao(ctx context.Context, dao reflect.Value, t table.Table, row map[string]interface{}) error {
+	method := "Insert"
+	if s.conf.upsert {
+		method = "Upsert"
+	}
+	fn := dao.MethodByName(method)
+	data := reflect.New(fn.Type().In(1).Elem())
+	for key, value := range row {
+		col, ok := seedColumn(t, key)
+		if !ok {
+			return errors.Errorf("unknown field %s", key)
+		}
+		field := data.Elem().FieldByName(col.Meta.Name)
+		if err := assign(field, value); err != nil {
+			return errors.Wrapf(err, "failed to set field %s", col.Meta.Name)
+		}
+	}
+	out := fn.Call([]reflect.Value{reflect.ValueOf(ctx), data})
+	if err, _ := out[1].Interface().(error); err != nil {
+		return err
+	}
+	return nil
+}
+
+// assign sets field by value decoded from fixture files, json is used for conversion, e.g. from string to
+// time.Time, sql.Scanner is tried if it fails
+func assign(field reflect.Value, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal value")
+	}
+	err = json.Unmarshal(data, field.Addr().Interface())
+	if err == nil {
+		return nil
+	}
+	if scanner, ok := field.Addr().Interface().(sql.Scanner); ok {
+		return scanner.Scan(value)
+	}
+	return errors.Wrap(err, "failed to unmarshal value")
+}
+
+// exec inserts or upserts row by statement built from columns of table
+func (s *Seeder) exec(ctx context.Context, tx Tx, d dialect.Dialect, t table.Table, row map[string]interface{}) error {
+	keys := make([]string, 0, len(row))
+	for key := range row {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var (
+		cols         []string
+		placeholders []string
+		assignments  []string
+		args         []interface{}
+	)
+	add := func(col table.Column, arg interface{}) {
+		cols = append(cols, d.Quote(col.Name))
+		placeholders = append(placeholders, "?")
+		if !col.Pk {
+			assignments = append(assignments, fmt.Sprintf("%s=%s", d.Quote(col.Name), d.Excluded(col.Name)))
+		}
+		args = append(args, arg)
+	}
+	seeded := make(map[string]bool)
+	for _, key := range keys {
+		col, ok := seedColumn(t, key)
+		if !ok {
+			return errors.Errorf("unknown column %s", key)
+		}
+		arg, err := seedArg(row[key])
+		if err != nil {
+			return errors.Wrapf(err, "invalid value of column %s", col.Name)
+		}
+		add(col, arg)
+		seeded[col.Name] = true
+	}
+	for _, col := range t.Columns {
+		if seeded[col.Name] || col.Nullable || col.Autoincrement || col.Default != nil {
+			continue
+		}
+		// daos insert zero values of fields not set
+		if zero, ok := zeroArg(col.Meta.Type); ok {
+			add(col, zero)
+		}
+	}
+	statement := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", d.Quote(t.Name), strings.Join(cols, ","), strings.Join(placeholders, ","))
+	if s.conf.upsert {
+		if len(assignments) == 0 {
+			assignments = append(assignments, fmt.Sprintf("%s=%s", d.Quote(t.Pk), d.Excluded(t.Pk)))
+		}
+		statement += fmt.Sprintf(" %s %s", d.Upsert(t.Pk), strings.Join(assignments, ","))
+	}
+	_, err := tx.ExecContext(ctx, d.Rebind(statement), args...)
+	return err
+}
+
+// zeroArg returns zero value of basic go type
+func zeroArg(goType string) (interface{}, bool) {
+	switch goType {
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "byte",
+		"float32", "float64":
+		return 0, true
+	case "bool":
+		return false, true
+	case "string":
+		return "", true
+	case "time.Time":
+		return time.Time{}, true
+	case "[]byte", "[]uint8":
+		return []byte{}, true
+	}
+	return nil, false
+}
+
+// seedArg converts value decoded from fixture files to query argument, maps and slices are stored as json
+func seedArg(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	}
+	return value, nil
+}
+
+// Seed loads fixture files or folders into tables of domain structs in Dir. Rows are inserted by statements built
+// from domain structs, use Seeder with generated daos in go code to insert rows through daos.
+func (d Ddl) Seed(paths []string, options ...SeedOption) {
+	db, _ := connect(d.Conf)
+	defer db.Close()
+	fixtures, err := LoadFixtures(paths...)
+	if err != nil {
+		logrus.Panicln(err)
+	}
+	seeder := NewSeeder(&GddDB{db}, options...).RegisterTables(d.domainTables()...)
+	if err = seeder.Seed(context.Background(), fixtures); err != nil {
+		logrus.Panicln(err)
+	}
+}
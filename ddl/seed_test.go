@@ -0,0 +1,156 @@
+package ddl
+
+import (
+	"context"
+	"github.com/unionj-cloud/go-doudou/astutils"
+	"github.com/unionj-cloud/go-doudou/ddl/table"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type seedAuthor struct {
+	ID   int `dd:"pk;auto"`
+	Name string
+}
+
+type seedBook struct {
+	ID        int `dd:"pk;auto"`
+	AuthorID  int `dd:"fk:seed_author.id"`
+	Title     string
+	CreatedAt *time.Time
+}
+
+// seedBookDao is like generated daos, which join the transaction carried by ctx
+type seedBookDao struct {
+	db Querier
+}
+
+func (d seedBookDao) Insert(ctx context.Context, data *seedBook) (int64, error) {
+	result, err := QuerierFromContext(ctx, d.db).ExecContext(ctx, "insert into seed_book (id, author_id, title, created_at) values (?, ?, ?, ?)",
+		data.ID, data.AuthorID, data.Title, data.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (d seedBookDao) Upsert(ctx context.Context, data *seedBook) (int64, error) {
+	result, err := QuerierFromContext(ctx, d.db).ExecContext(ctx, "insert into seed_book (id, author_id, title, created_at) values (?, ?, ?, ?) on conflict (id) do update set title = excluded.title",
+		data.ID, data.AuthorID, data.Title, data.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func seedTable(v interface{}) table.Table {
+	return table.NewTableFromStruct(structMetaOf(reflect.TypeOf(v)))
+}
+
+func writeFixture(t *testing.T, dir, name, content string) {
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadFixtures(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, "a.yml", "seedAuthor:\n  - id: 1\n    name: jack\n")
+	writeFixture(t, dir, "b.json", `{"seedAuthor": [{"id": 2, "name": "rose"}]}`)
+	writeFixture(t, dir, "c.txt", "ignored")
+	fixtures, err := LoadFixtures(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := fixtures["seedAuthor"]
+	if len(fixtures) != 1 || len(rows) != 2 || rows[0]["name"] != "jack" || rows[1]["name"] != "rose" {
+		t.Errorf("LoadFixtures() = %v", fixtures)
+	}
+}
+
+func TestSeeder(t *testing.T) {
+	fixtures := Fixtures{
+		// books come first, but authors are seeded before them
+		"seedBook": {
+			{"id": 1, "AuthorID": 1, "title": "a", "created_at": "2021-01-02T03:04:05Z"},
+			{"id": 2, "author_id": 2, "Title": "b"},
+		},
+		"seedAuthor": {
+			{"id": 1, "name": "jack"},
+			{"ID": 2, "name": "rose"},
+		},
+	}
+	tests := []struct {
+		name    string
+		options []SeedOption
+		// times of seeding
+		times   int
+		wantErr bool
+	}{
+		{name: "insert", times: 1},
+		{name: "insert again", times: 2, wantErr: true},
+		{name: "truncate", options: []SeedOption{WithTruncate()}, times: 2},
+		{name: "upsert", options: []SeedOption{WithUpsert()}, times: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := newTestDB(t)
+			for _, tab := range []table.Table{seedTable(seedAuthor{}), seedTable(seedBook{})} {
+				if err := table.CreateTable(db.DB, tab); err != nil {
+					t.Fatal(err)
+				}
+			}
+			seeder := NewSeeder(db, tt.options...).RegisterTables(seedTable(seedAuthor{})).Register(seedBookDao{db})
+			var err error
+			for i := 0; i < tt.times && err == nil; i++ {
+				err = seeder.Seed(ctx, fixtures)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Seed() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var titles []string
+			if err = db.Select(&titles, "select a.name || ':' || b.title from seed_book b join seed_author a on a.id = b.author_id order by b.id"); err != nil {
+				t.Fatal(err)
+			}
+			if want := []string{"jack:a", "rose:b"}; !reflect.DeepEqual(titles, want) {
+				t.Errorf("seeded = %v, want %v", titles, want)
+			}
+			var createdAt time.Time
+			if err = db.Get(&createdAt, "select created_at from seed_book where id = 1"); err != nil {
+				t.Fatal(err)
+			}
+			if want := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC); !createdAt.Equal(want) {
+				t.Errorf("created_at = %v, want %v", createdAt, want)
+			}
+		})
+	}
+}
+
+func TestSeeder_Invalid(t *testing.T) {
+	db := newTestDB(t)
+	seeder := NewSeeder(db).RegisterTables(seedTable(seedAuthor{}))
+	tests := []struct {
+		name     string
+		fixtures Fixtures
+	}{
+		{"unknown struct", Fixtures{"seedBook": {{"id": 1}}}},
+		{"unknown column", Fixtures{"seedAuthor": {{"age": 1}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := seeder.Seed(context.Background(), tt.fixtures); err == nil {
+				t.Error("error expected")
+			}
+		})
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a value without Insert and Upsert methods should panic")
+		}
+	}()
+	seeder.Register(astutils.StructMeta{})
+}
@@ -3,6 +3,8 @@ package table
 import (
 	"github.com/jmoiron/sqlx"
 	"github.com/sirupsen/logrus"
+	"github.com/unionj-cloud/go-doudou/ddl/dialect"
+	"github.com/unionj-cloud/go-doudou/stringutils"
 )
 
 func CreateTable(db *sqlx.DB, t Table) error {
@@ -10,7 +12,11 @@ func CreateTable(db *sqlx.DB, t Table) error {
 		statement string
 		err       error
 	)
-	if statement, err = t.CreateSql(); err != nil {
+	d, err := dialect.Get(db.DriverName())
+	if err != nil {
+		return err
+	}
+	if statement, err = t.CreateSql(d); err != nil {
 		return err
 	}
 	logrus.Infoln(statement)
@@ -25,9 +31,17 @@ func ChangeColumn(db *sqlx.DB, col Column) error {
 		statement string
 		err       error
 	)
-	if statement, err = col.ChangeColumnSql(); err != nil {
+	d, err := dialect.Get(db.DriverName())
+	if err != nil {
+		return err
+	}
+	if statement, err = col.ChangeColumnSql(d); err != nil {
 		return err
 	}
+	if stringutils.IsEmpty(statement) {
+		logrus.Warnf("changing column %s of table %s is not supported by %s, skipped", col.Name, col.Table, d.Name())
+		return nil
+	}
 	logrus.Infoln(statement)
 	if _, err = db.Exec(statement); err != nil {
 		return err
@@ -40,12 +54,122 @@ func AddColumn(db *sqlx.DB, col Column) error {
 		statement string
 		err       error
 	)
-	if statement, err = col.AddColumnSql(); err != nil {
+	d, err := dialect.Get(db.DriverName())
+	if err != nil {
+		return err
+	}
+	if statement, err = col.AddColumnSql(d); err != nil {
 		return err
 	}
+	if stringutils.IsEmpty(statement) {
+		logrus.Warnf("adding column %s to table %s is not supported by %s, skipped", col.Name, col.Table, d.Name())
+		return nil
+	}
 	logrus.Infoln(statement)
 	if _, err = db.Exec(statement); err != nil {
 		return err
 	}
 	return err
 }
+
+func RenameColumn(db *sqlx.DB, col Column) error {
+	d, err := dialect.Get(db.DriverName())
+	if err != nil {
+		return err
+	}
+	statement, err := col.RenameColumnSql(d)
+	if err != nil {
+		return err
+	}
+	return execSql(db, statement)
+}
+
+func DropColumn(db *sqlx.DB, col Column) error {
+	d, err := dialect.Get(db.DriverName())
+	if err != nil {
+		return err
+	}
+	statement, err := col.DropColumnSql(d)
+	if err != nil {
+		return err
+	}
+	return execSql(db, statement)
+}
+
+func AddIndex(db *sqlx.DB, t Table, idx Index) error {
+	d, err := dialect.Get(db.DriverName())
+	if err != nil {
+		return err
+	}
+	statement, err := t.AddIndexSql(idx, d)
+	if err != nil {
+		return err
+	}
+	return execSql(db, statement)
+}
+
+func DropIndex(db *sqlx.DB, t Table, idx Index) error {
+	d, err := dialect.Get(db.DriverName())
+	if err != nil {
+		return err
+	}
+	statement, err := t.DropIndexSql(idx, d)
+	if err != nil {
+		return err
+	}
+	return execSql(db, statement)
+}
+
+func execSql(db *sqlx.DB, statement string) error {
+	logrus.Infoln(statement)
+	_, err := db.Exec(statement)
+	return err
+}
+
+func AddFk(db *sqlx.DB, t Table, fk ForeignKey) error {
+	d, err := dialect.Get(db.DriverName())
+	if err != nil {
+		return err
+	}
+	statement, err := t.AddFkSql(fk, d)
+	if err != nil {
+		return err
+	}
+	if stringutils.IsEmpty(statement) {
+		logrus.Warnf("adding foreign key %s to existing table %s is not supported by %s, skipped", fk.Name, t.Name, d.Name())
+		return nil
+	}
+	return execSql(db, statement)
+}
+
+func DropFk(db *sqlx.DB, t Table, fk ForeignKey) error {
+	d, err := dialect.Get(db.DriverName())
+	if err != nil {
+		return err
+	}
+	statement, err := t.DropFkSql(fk, d)
+	if err != nil {
+		return err
+	}
+	if stringutils.IsEmpty(statement) {
+		logrus.Warnf("dropping foreign key %s of existing table %s is not supported by %s, skipped", fk.Name, t.Name, d.Name())
+		return nil
+	}
+	return execSql(db, statement)
+}
+
+// CommentTable sets comment of existing table, databases without table comments like sqlite are skipped silently
+func CommentTable(db *sqlx.DB, t Table) error {
+	d, err := dialect.Get(db.DriverName())
+	if err != nil {
+		return err
+	}
+	statement, err := t.CommentSql(d)
+	if err != nil {
+		return err
+	}
+	if stringutils.IsEmpty(statement) {
+		return nil
+	}
+	return execSql(db, statement)
+}
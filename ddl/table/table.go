@@ -5,11 +5,13 @@ import (
 	"github.com/iancoleman/strcase"
 	"github.com/unionj-cloud/go-doudou/astutils"
 	"github.com/unionj-cloud/go-doudou/ddl/columnenum"
+	"github.com/unionj-cloud/go-doudou/ddl/dialect"
+	_ "github.com/unionj-cloud/go-doudou/ddl/dialect/mysql"
 	"github.com/unionj-cloud/go-doudou/ddl/extraenum"
 	"github.com/unionj-cloud/go-doudou/ddl/keyenum"
 	"github.com/unionj-cloud/go-doudou/ddl/nullenum"
 	"github.com/unionj-cloud/go-doudou/ddl/sortenum"
-	"github.com/unionj-cloud/go-doudou/pathutils"
+	"github.com/unionj-cloud/go-doudou/sliceutils"
 	"github.com/unionj-cloud/go-doudou/stringutils"
 	"github.com/unionj-cloud/go-doudou/templateutils"
 	"reflect"
@@ -49,54 +51,139 @@ type Index struct {
 	Items  []IndexItem
 }
 
+// ForeignKey references a column of another table, declared by fk tag, e.g. dd:"fk:user.id,ondelete:cascade".
+// OnDelete and OnUpdate are upper case referential actions, empty for the default NO ACTION.
+type ForeignKey struct {
+	Name      string
+	Column    string
+	RefTable  string
+	RefColumn string
+	OnDelete  string
+	OnUpdate  string
+}
+
+// parseFk parses value of fk tag, e.g. user.id,ondelete:cascade,onupdate:set null
+func parseFk(table, column, value string) ForeignKey {
+	props := strings.Split(value, ",")
+	ref := strings.Split(props[0], ".")
+	if len(ref) != 2 || stringutils.IsEmpty(ref[0]) || stringutils.IsEmpty(ref[1]) {
+		panic(fmt.Sprintf("invalid fk %s of column %s, table.column expected", value, column))
+	}
+	fk := ForeignKey{
+		Name:      fmt.Sprintf("fk_%s_%s", table, column),
+		Column:    column,
+		RefTable:  ref[0],
+		RefColumn: ref[1],
+	}
+	for _, prop := range props[1:] {
+		kv := strings.SplitN(prop, ":", 2)
+		if len(kv) != 2 {
+			panic(fmt.Sprintf("invalid fk %s of column %s, ondelete:action or onupdate:action expected", value, column))
+		}
+		switch strings.TrimSpace(kv[0]) {
+		case "ondelete":
+			fk.OnDelete = dialect.Action(kv[1])
+		case "onupdate":
+			fk.OnUpdate = dialect.Action(kv[1])
+		default:
+			panic(fmt.Sprintf("invalid fk %s of column %s, unknown option %s", value, column, kv[0]))
+		}
+	}
+	return fk
+}
+
+// decimalType is column type of decimal.Decimal fields, DECIMAL without precision keeps no fractional digits in mysql
+const decimalType columnenum.ColumnType = "DECIMAL(20,6)"
+
+// toColumnType maps go type without pointer to column type. Other types, e.g. custom types implementing
+// driver.Valuer and sql.Scanner, need a type tag.
 func toColumnType(goType string) columnenum.ColumnType {
 	switch goType {
-	case "int", "int16", "int32":
+	case "int", "int16", "int32", "sql.NullInt16", "sql.NullInt32":
 		return columnenum.IntType
-	case "int64":
+	case "uint", "uint16", "uint32":
+		return columnenum.IntType + " unsigned"
+	case "int64", "sql.NullInt64":
 		return columnenum.BigintType
+	case "uint64":
+		return columnenum.BigintType + " unsigned"
 	case "float32":
 		return columnenum.FloatType
-	case "float64":
+	case "float64", "sql.NullFloat64":
 		return columnenum.DoubleType
-	case "string":
+	case "string", "sql.NullString":
 		return columnenum.VarcharType
-	case "bool", "int8":
+	case "bool", "int8", "sql.NullBool":
 		return columnenum.TinyintType
-	case "time.Time":
+	case "uint8", "byte", "sql.NullByte":
+		return columnenum.TinyintType + " unsigned"
+	case "time.Time", "sql.NullTime":
 		return columnenum.DatetimeType
+	case "[]byte", "[]uint8":
+		return columnenum.BlobType
+	case "json.RawMessage":
+		return columnenum.JsonType
+	case "decimal.Decimal", "decimal.NullDecimal":
+		return decimalType
 	}
-	panic(fmt.Sprintf("no available type %s", goType))
+	panic(fmt.Sprintf("no available type %s, declare column type by type tag, e.g. dd:\"type:json\"", goType))
+}
+
+// isNullType checks if go type is a nullable wrapper like sql.NullString or decimal.NullDecimal
+func isNullType(goType string) bool {
+	return strings.HasPrefix(goType[strings.LastIndex(goType, ".")+1:], "Null")
 }
 
+// isUnsignedType checks if go type is an unsigned integer
+func isUnsignedType(goType string) bool {
+	return strings.HasPrefix(goType, "uint") || goType == "byte"
+}
+
+// toGoType maps column type read from database to go type. Nullable columns are mapped to pointer types
+// except slice types, which are nil for null.
 func toGoType(colType columnenum.ColumnType, nullable bool) string {
+	typ := strings.ToLower(strings.TrimSpace(string(colType)))
+	unsigned := strings.Contains(typ, "unsigned")
+	base := typ
+	if i := strings.IndexAny(base, "( "); i >= 0 {
+		base = base[:i]
+	}
 	var goType string
-	if nullable {
-		goType += "*"
-	}
-	if stringutils.HasPrefixI(string(colType), strings.ToLower(string(columnenum.IntType))) {
-		goType += "int"
-	} else if stringutils.HasPrefixI(string(colType), strings.ToLower(string(columnenum.BigintType))) {
-		goType += "int64"
-	} else if stringutils.HasPrefixI(string(colType), strings.ToLower(string(columnenum.FloatType))) {
-		goType += "float32"
-	} else if stringutils.HasPrefixI(string(colType), strings.ToLower(string(columnenum.DoubleType))) {
-		goType += "float64"
-	} else if stringutils.HasPrefixI(string(colType), "varchar") {
-		goType += "string"
-	} else if stringutils.HasPrefixI(string(colType), strings.ToLower(string(columnenum.TextType))) {
-		goType += "string"
-	} else if stringutils.HasPrefixI(string(colType), strings.ToLower(string(columnenum.TinyintType))) {
-		goType += "int8"
-	} else if stringutils.HasPrefixI(string(colType), strings.ToLower(string(columnenum.DatetimeType))) {
-		goType += "time.Time"
-	} else if stringutils.HasPrefixI(string(colType), strings.ToLower(string(columnenum.MediumtextType))) {
-		goType += "string"
-	} else if stringutils.HasPrefixI(string(colType), strings.ToLower(string(columnenum.DecimalType))) {
-		goType += "float32"
-	} else {
+	switch base {
+	case "tinyint":
+		goType = "int8"
+	case "smallint":
+		goType = "int16"
+	case "mediumint", "int", "integer", "serial", "year":
+		goType = "int"
+	case "bigint", "bigserial":
+		goType = "int64"
+	case "float", "real":
+		goType = "float32"
+	case "double":
+		goType = "float64"
+	case "decimal", "numeric":
+		// string keeps precision of decimal values
+		goType = "string"
+	case "char", "varchar", "character", "tinytext", "text", "mediumtext", "longtext", "enum", "set", "uuid", "time":
+		goType = "string"
+	case "date", "datetime", "timestamp", "timestamptz":
+		goType = "time.Time"
+	case "bool", "boolean":
+		goType = "bool"
+	case "json", "jsonb":
+		goType = "json.RawMessage"
+	case "bit", "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob", "bytea":
+		goType = "[]byte"
+	default:
 		panic(fmt.Sprintf("no available type %s", colType))
 	}
+	if unsigned && strings.HasPrefix(goType, "int") {
+		goType = "u" + goType
+	}
+	if nullable && !strings.HasPrefix(goType, "[]") && goType != "json.RawMessage" {
+		goType = "*" + goType
+	}
 	return goType
 }
 
@@ -137,14 +224,85 @@ type Column struct {
 	Meta          astutils.FieldMeta
 	AutoSet       bool
 	Indexes       []IndexItem
+	// OldName is the previous name of a renamed column, set by rename tag, e.g. dd:"rename:user_name"
+	OldName string
+	Fk      *ForeignKey
+	// SoftDelete marks the column keeping deletion time of soft deleted rows, set by softdelete tag
+	SoftDelete bool
+	// CreatedBy and UpdatedBy columns are set from operator in context by generated dao, set by createdby and
+	// updatedby tag
+	CreatedBy bool
+	UpdatedBy bool
+	// Version marks the integer column for optimistic locking, set by version tag
+	Version bool
+	// Comment is set from doc comment of the field
+	Comment string
+	// Enum is values of an enum or set column read from database
+	Enum []string
+}
+
+// QuotedComment returns Comment as a sql string literal
+func (c Column) QuotedComment() string {
+	return quoteLiteral(c.Comment)
+}
+
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// docComment joins lines of doc comment except directives like dd:table into one line
+func docComment(lines []string) string {
+	var result []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "dd:") {
+			continue
+		}
+		result = append(result, line)
+	}
+	return strings.Join(result, " ")
+}
+
+func getDialect(d []dialect.Dialect) dialect.Dialect {
+	if len(d) > 0 && d[0] != nil {
+		return d[0]
+	}
+	return dialect.MustGet(dialect.Default)
+}
+
+// native returns a copy of the column with type translated by the dialect
+func (c Column) native(d dialect.Dialect) Column {
+	c.Type = d.ColumnType(c.Type, strings.TrimPrefix(c.Meta.Type, "*"), c.Autoincrement)
+	return c
+}
+
+// ChangeColumnSql renders alter statement for changing column, dialect defaults to mysql.
+// Empty string is returned if the dialect doesn't support changing the column.
+func (c *Column) ChangeColumnSql(d ...dialect.Dialect) (string, error) {
+	dia := getDialect(d)
+	col := c.native(dia)
+	return templateutils.StringBlock(dia.Template("alter.tmpl"), "change", col)
+}
+
+// AddColumnSql renders alter statement for adding column, dialect defaults to mysql
+func (c *Column) AddColumnSql(d ...dialect.Dialect) (string, error) {
+	dia := getDialect(d)
+	col := c.native(dia)
+	return templateutils.StringBlock(dia.Template("alter.tmpl"), "add", col)
 }
 
-func (c *Column) ChangeColumnSql() (string, error) {
-	return templateutils.StringBlock(pathutils.Abs("alter.tmpl"), "change", c)
+// RenameColumnSql renders alter statement for renaming column from OldName to Name, dialect defaults to mysql.
+// Definition of the column is changed at the same time for mysql.
+func (c *Column) RenameColumnSql(d ...dialect.Dialect) (string, error) {
+	dia := getDialect(d)
+	col := c.native(dia)
+	return templateutils.StringBlock(dia.Template("alter.tmpl"), "rename", col)
 }
 
-func (c *Column) AddColumnSql() (string, error) {
-	return templateutils.StringBlock(pathutils.Abs("alter.tmpl"), "add", c)
+// DropColumnSql renders alter statement for dropping column, dialect defaults to mysql
+func (c *Column) DropColumnSql(d ...dialect.Dialect) (string, error) {
+	dia := getDialect(d)
+	return templateutils.StringBlock(dia.Template("alter.tmpl"), "drop", c)
 }
 
 type DbColumn struct {
@@ -172,14 +330,26 @@ type Table struct {
 	Columns []Column
 	Pk      string
 	Indexes []Index
+	Fks     []ForeignKey
 	Meta    astutils.StructMeta
+	// Comment is set from doc comment of the struct
+	Comment string
+}
+
+// QuotedComment returns Comment as a sql string literal
+func (t Table) QuotedComment() string {
+	return quoteLiteral(t.Comment)
 }
 
+// versionTypes are types of version field, pointers are excluded because version increments in sql
+var versionTypes = []string{"int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64"}
+
 func NewTableFromStruct(structMeta astutils.StructMeta, prefix ...string) Table {
 	var (
 		columns       []Column
 		uniqueindexes []Index
 		indexes       []Index
+		fks           []ForeignKey
 		pkColumn      Column
 		table         string
 	)
@@ -200,6 +370,12 @@ func NewTableFromStruct(structMeta astutils.StructMeta, prefix ...string) Table
 			index         Index
 			pk            bool
 			autoSet       bool
+			oldName       string
+			fk            *ForeignKey
+			softDelete    bool
+			createdBy     bool
+			updatedBy     bool
+			version       bool
 		)
 		columnName = strcase.ToSnake(field.Name)
 		if stringutils.IsNotEmpty(field.Tag) {
@@ -231,6 +407,13 @@ func NewTableFromStruct(structMeta astutils.StructMeta, prefix ...string) Table
 						case "extra":
 							extra = extraenum.Extra(value)
 							break
+						case "rename":
+							oldName = value
+							break
+						case "fk":
+							parsed := parseFk(table, columnName, strings.TrimPrefix(kv, "fk:"))
+							fk = &parsed
+							break
 						case "index":
 							props := strings.Split(value, ",")
 							indexName := props[0]
@@ -295,6 +478,18 @@ func NewTableFromStruct(structMeta astutils.StructMeta, prefix ...string) Table
 						case "auto":
 							autoincrement = true
 							break
+						case "softdelete":
+							softDelete = true
+							break
+						case "createdby":
+							createdBy = true
+							break
+						case "updatedby":
+							updatedBy = true
+							break
+						case "version":
+							version = true
+							break
 						case "index":
 							index = Index{
 								Name: strcase.ToSnake(field.Name) + "_idx",
@@ -323,12 +518,24 @@ func NewTableFromStruct(structMeta astutils.StructMeta, prefix ...string) Table
 			}
 		}
 
-		if strings.HasPrefix(field.Type, "*") {
+		goType := strings.TrimPrefix(field.Type, "*")
+		if strings.HasPrefix(field.Type, "*") || isNullType(goType) {
 			nullable = true
 		}
 
+		if softDelete && !strings.HasPrefix(field.Type, "*") {
+			panic(fmt.Sprintf("softdelete field %s of %s should be a pointer such as *time.Time", field.Name, structMeta.Name))
+		}
+
+		if version && !sliceutils.StringContains(versionTypes, field.Type) {
+			panic(fmt.Sprintf("version field %s of %s should be an integer", field.Name, structMeta.Name))
+		}
+
 		if stringutils.IsEmpty(string(columnType)) {
-			columnType = toColumnType(strings.TrimPrefix(field.Type, "*"))
+			columnType = toColumnType(goType)
+			if isUnsignedType(goType) {
+				unsigned = true
+			}
 		}
 
 		if stringutils.IsNotEmpty(uniqueindex.Name) {
@@ -341,6 +548,16 @@ func NewTableFromStruct(structMeta astutils.StructMeta, prefix ...string) Table
 			indexes = append(indexes, index)
 		}
 
+		if fk != nil {
+			fks = append(fks, *fk)
+		}
+
+		var comment string
+		// comment clause in extra tag takes precedence over doc comment
+		if !strings.Contains(strings.ToLower(string(extra)), "comment") {
+			comment = docComment(field.Comments)
+		}
+
 		columns = append(columns, Column{
 			Table:         table,
 			Name:          columnName,
@@ -353,6 +570,13 @@ func NewTableFromStruct(structMeta astutils.StructMeta, prefix ...string) Table
 			Pk:            pk,
 			Meta:          field,
 			AutoSet:       autoSet,
+			OldName:       oldName,
+			Fk:            fk,
+			SoftDelete:    softDelete,
+			CreatedBy:     createdBy,
+			UpdatedBy:     updatedBy,
+			Version:       version,
+			Comment:       comment,
 		})
 	}
 
@@ -412,7 +636,9 @@ func NewTableFromStruct(structMeta astutils.StructMeta, prefix ...string) Table
 		Columns: columns,
 		Pk:      pkColumn.Name,
 		Indexes: indexesResult,
+		Fks:     fks,
 		Meta:    structMeta,
+		Comment: docComment(structMeta.Comments),
 	}
 }
 
@@ -425,7 +651,16 @@ func NewFieldFromColumn(col Column) astutils.FieldMeta {
 	if col.Autoincrement {
 		feats = append(feats, "auto")
 	}
-	goType := toGoType(col.Type, col.Nullable)
+	var goType string
+	if len(col.Enum) > 0 {
+		// native enum types of postgres are unknown to toGoType
+		goType = "string"
+		if col.Nullable {
+			goType = "*" + goType
+		}
+	} else {
+		goType = toGoType(col.Type, col.Nullable)
+	}
 	if col.Nullable && !strings.HasPrefix(goType, "*") {
 		feats = append(feats, "null")
 	}
@@ -448,6 +683,16 @@ func NewFieldFromColumn(col Column) astutils.FieldMeta {
 	if stringutils.IsNotEmpty(string(col.Extra)) {
 		feats = append(feats, fmt.Sprintf("extra:%s", string(col.Extra)))
 	}
+	if col.Fk != nil {
+		fkClause := fmt.Sprintf("fk:%s.%s", col.Fk.RefTable, col.Fk.RefColumn)
+		if stringutils.IsNotEmpty(col.Fk.OnDelete) {
+			fkClause += ",ondelete:" + strings.ToLower(col.Fk.OnDelete)
+		}
+		if stringutils.IsNotEmpty(col.Fk.OnUpdate) {
+			fkClause += ",onupdate:" + strings.ToLower(col.Fk.OnUpdate)
+		}
+		feats = append(feats, fkClause)
+	}
 	for _, idx := range col.Indexes {
 		var indexClause string
 		if idx.Name == "PRIMARY" {
@@ -462,13 +707,231 @@ func NewFieldFromColumn(col Column) astutils.FieldMeta {
 		feats = append(feats, indexClause)
 	}
 
+	var comments []string
+	if stringutils.IsNotEmpty(col.Comment) {
+		comments = strings.Split(col.Comment, "\n")
+	}
 	return astutils.FieldMeta{
-		Name: strcase.ToCamel(col.Name),
-		Type: goType,
-		Tag:  fmt.Sprintf(`%s"%s"`, tag, strings.Join(feats, ";")),
+		Name:     strcase.ToCamel(col.Name),
+		Type:     goType,
+		Tag:      fmt.Sprintf(`%s"%s"`, tag, strings.Join(feats, ";")),
+		Comments: comments,
 	}
 }
 
-func (t *Table) CreateSql() (string, error) {
-	return templateutils.String(pathutils.Abs("create.tmpl"), t)
+// CreateSql renders create table statement, dialect defaults to mysql
+func (t *Table) CreateSql(d ...dialect.Dialect) (string, error) {
+	dia := getDialect(d)
+	tab := *t
+	tab.Columns = make([]Column, len(t.Columns))
+	for i, col := range t.Columns {
+		tab.Columns[i] = col.native(dia)
+	}
+	return templateutils.String(dia.Template("create.tmpl"), tab)
+}
+
+// CommentSql renders statement for setting comment of the table, dialect defaults to mysql.
+// Empty string is returned if the dialect doesn't support table comments.
+func (t *Table) CommentSql(d ...dialect.Dialect) (string, error) {
+	dia := getDialect(d)
+	return templateutils.StringBlock(dia.Template("alter.tmpl"), "comment", *t)
+}
+
+type tableIndex struct {
+	Table string
+	Index
+}
+
+// AddIndexSql renders statement for adding index to the table, dialect defaults to mysql
+func (t *Table) AddIndexSql(idx Index, d ...dialect.Dialect) (string, error) {
+	dia := getDialect(d)
+	return templateutils.StringBlock(dia.Template("alter.tmpl"), "addIndex", tableIndex{t.Name, idx})
+}
+
+// DropIndexSql renders statement for dropping index of the table, dialect defaults to mysql
+func (t *Table) DropIndexSql(idx Index, d ...dialect.Dialect) (string, error) {
+	dia := getDialect(d)
+	return templateutils.StringBlock(dia.Template("alter.tmpl"), "dropIndex", tableIndex{t.Name, idx})
+}
+
+// IndexesFromDb groups index columns read from database by index name. Primary key and indexes named after
+// foreign keys, which are created implicitly by mysql, are excluded.
+func IndexesFromDb(rows []dialect.Index, fks []dialect.ForeignKey) []Index {
+	idxMap := make(map[string][]IndexItem)
+	uniqueMap := make(map[string]bool)
+	fkNames := make(map[string]bool)
+	for _, fk := range fks {
+		fkNames[fk.Name] = true
+	}
+	var names []string
+	for _, row := range rows {
+		if row.Name == "PRIMARY" || fkNames[row.Name] {
+			continue
+		}
+		if _, exists := idxMap[row.Name]; !exists {
+			names = append(names, row.Name)
+		}
+		sort := sortenum.Asc
+		if row.Desc {
+			sort = sortenum.Desc
+		}
+		idxMap[row.Name] = append(idxMap[row.Name], IndexItem{
+			Unique: row.Unique,
+			Name:   row.Name,
+			Column: row.Column,
+			Order:  row.Seq,
+			Sort:   sort,
+		})
+		uniqueMap[row.Name] = row.Unique
+	}
+	sort.Strings(names)
+	var indexes []Index
+	for _, name := range names {
+		it := IndexItems(idxMap[name])
+		sort.Stable(it)
+		indexes = append(indexes, Index{
+			Unique: uniqueMap[name],
+			Name:   name,
+			Items:  it,
+		})
+	}
+	return indexes
+}
+
+func sameIndex(a, b Index) bool {
+	if a.Unique != b.Unique || len(a.Items) != len(b.Items) {
+		return false
+	}
+	ai := append(IndexItems(nil), a.Items...)
+	bi := append(IndexItems(nil), b.Items...)
+	sort.Stable(ai)
+	sort.Stable(bi)
+	for i := range ai {
+		if ai[i].Column != bi[i].Column || !strings.EqualFold(string(ai[i].Sort), string(bi[i].Sort)) {
+			return false
+		}
+	}
+	return true
+}
+
+// DiffIndexes compares indexes declared by struct tags with indexes in database by name. Indexes to add and
+// to drop are returned in name order, a changed index is dropped and added again.
+func DiffIndexes(want, exist []Index) (add []Index, drop []Index) {
+	existMap := make(map[string]Index)
+	for _, idx := range exist {
+		existMap[idx.Name] = idx
+	}
+	wantMap := make(map[string]Index)
+	for _, idx := range want {
+		it := append(IndexItems(nil), idx.Items...)
+		sort.Stable(it)
+		idx.Items = it
+		wantMap[idx.Name] = idx
+		if old, ok := existMap[idx.Name]; !ok {
+			add = append(add, idx)
+		} else if !sameIndex(idx, old) {
+			drop = append(drop, old)
+			add = append(add, idx)
+		}
+	}
+	for _, idx := range exist {
+		if _, ok := wantMap[idx.Name]; !ok {
+			drop = append(drop, idx)
+		}
+	}
+	sort.Slice(add, func(i, j int) bool {
+		return add[i].Name < add[j].Name
+	})
+	sort.Slice(drop, func(i, j int) bool {
+		return drop[i].Name < drop[j].Name
+	})
+	return
+}
+
+type tableFk struct {
+	Table string
+	ForeignKey
+}
+
+// AddFkSql renders statement for adding foreign key to the table, dialect defaults to mysql.
+// Empty string is returned if the dialect doesn't support adding foreign key to existing table.
+func (t *Table) AddFkSql(fk ForeignKey, d ...dialect.Dialect) (string, error) {
+	dia := getDialect(d)
+	return templateutils.StringBlock(dia.Template("alter.tmpl"), "addFk", tableFk{t.Name, fk})
+}
+
+// DropFkSql renders statement for dropping foreign key of the table, dialect defaults to mysql.
+// Empty string is returned if the dialect doesn't support dropping foreign key from existing table.
+func (t *Table) DropFkSql(fk ForeignKey, d ...dialect.Dialect) (string, error) {
+	dia := getDialect(d)
+	return templateutils.StringBlock(dia.Template("alter.tmpl"), "dropFk", tableFk{t.Name, fk})
+}
+
+// FksFromDb converts foreign keys read from database
+func FksFromDb(rows []dialect.ForeignKey) []ForeignKey {
+	var fks []ForeignKey
+	for _, row := range rows {
+		fks = append(fks, ForeignKey(row))
+	}
+	return fks
+}
+
+// DiffFks compares foreign keys declared by struct tags with foreign keys in database by name like DiffIndexes
+func DiffFks(want, exist []ForeignKey) (add []ForeignKey, drop []ForeignKey) {
+	existMap := make(map[string]ForeignKey)
+	for _, fk := range exist {
+		existMap[fk.Name] = fk
+	}
+	wantMap := make(map[string]ForeignKey)
+	for _, fk := range want {
+		wantMap[fk.Name] = fk
+		if old, ok := existMap[fk.Name]; !ok {
+			add = append(add, fk)
+		} else if old != fk {
+			drop = append(drop, old)
+			add = append(add, fk)
+		}
+	}
+	for _, fk := range exist {
+		if _, ok := wantMap[fk.Name]; !ok {
+			drop = append(drop, fk)
+		}
+	}
+	sort.Slice(add, func(i, j int) bool {
+		return add[i].Name < add[j].Name
+	})
+	sort.Slice(drop, func(i, j int) bool {
+		return drop[i].Name < drop[j].Name
+	})
+	return
+}
+
+// SortByFk orders tables so that referenced tables come before tables referencing them, other tables keep
+// their order. Tables in a reference cycle are kept in their order.
+func SortByFk(tables []Table) []Table {
+	tableMap := make(map[string]Table)
+	for _, t := range tables {
+		tableMap[t.Name] = t
+	}
+	var (
+		sorted []Table
+		visit  func(t Table)
+	)
+	visited := make(map[string]bool)
+	visit = func(t Table) {
+		if visited[t.Name] {
+			return
+		}
+		visited[t.Name] = true
+		for _, fk := range t.Fks {
+			if ref, ok := tableMap[fk.RefTable]; ok {
+				visit(ref)
+			}
+		}
+		sorted = append(sorted, t)
+	}
+	for _, t := range tables {
+		visit(t)
+	}
+	return sorted
 }
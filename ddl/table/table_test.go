@@ -5,6 +5,7 @@ import (
 	"github.com/unionj-cloud/go-doudou/astutils"
 	"github.com/unionj-cloud/go-doudou/ddl/columnenum"
 	"github.com/unionj-cloud/go-doudou/ddl/ddlast"
+	"github.com/unionj-cloud/go-doudou/ddl/dialect"
 	"github.com/unionj-cloud/go-doudou/ddl/extraenum"
 	"github.com/unionj-cloud/go-doudou/ddl/keyenum"
 	"github.com/unionj-cloud/go-doudou/ddl/nullenum"
@@ -43,6 +44,131 @@ func TestNewTableFromStruct(t *testing.T) {
 	}
 }
 
+func TestNewTableFromStruct_Types(t *testing.T) {
+	sm := astutils.StructMeta{
+		Name: "Account",
+		Fields: []astutils.FieldMeta{
+			{Name: "ID", Type: "uint64", Tag: `dd:"pk;auto"`},
+			{Name: "Nickname", Type: "sql.NullString"},
+			{Name: "Balance", Type: "decimal.Decimal"},
+			{Name: "Avatar", Type: "[]byte", Tag: `dd:"null"`},
+			{Name: "Profile", Type: "Profile", Tag: `dd:"type:json"`},
+			{Name: "Gender", Type: "Gender", Tag: `dd:"type:enum('male','female')"`},
+		},
+	}
+	tests := []struct {
+		name     string
+		typ      columnenum.ColumnType
+		nullable bool
+		unsigned bool
+	}{
+		{name: "id", typ: "BIGINT unsigned", unsigned: true},
+		{name: "nickname", typ: columnenum.VarcharType, nullable: true},
+		{name: "balance", typ: "DECIMAL(20,6)"},
+		{name: "avatar", typ: columnenum.BlobType, nullable: true},
+		{name: "profile", typ: "json"},
+		{name: "gender", typ: "enum('male','female')"},
+	}
+	table := NewTableFromStruct(sm)
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			col := table.Columns[i]
+			if col.Name != tt.name || col.Type != tt.typ || col.Nullable != tt.nullable || col.Unsigned != tt.unsigned {
+				t.Errorf("NewTableFromStruct() column = %+v, want %+v", col, tt)
+			}
+		})
+	}
+}
+
+func TestNewTableFromStruct_Audit(t *testing.T) {
+	sm := astutils.StructMeta{
+		Name: "Order",
+		Fields: []astutils.FieldMeta{
+			{Name: "ID", Type: "int", Tag: `dd:"pk;auto"`},
+			{Name: "CreatedBy", Type: "string", Tag: `dd:"createdby"`},
+			{Name: "UpdatedBy", Type: "*int64", Tag: `dd:"updatedby"`},
+			{Name: "DeletedAt", Type: "*time.Time", Tag: `dd:"softdelete"`},
+			{Name: "Version", Type: "int64", Tag: `dd:"version"`},
+		},
+	}
+	table := NewTableFromStruct(sm)
+	tests := []struct {
+		name       string
+		createdBy  bool
+		updatedBy  bool
+		softDelete bool
+		version    bool
+	}{
+		{name: "id"},
+		{name: "created_by", createdBy: true},
+		{name: "updated_by", updatedBy: true},
+		{name: "deleted_at", softDelete: true},
+		{name: "version", version: true},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			col := table.Columns[i]
+			if col.Name != tt.name || col.CreatedBy != tt.createdBy || col.UpdatedBy != tt.updatedBy || col.SoftDelete != tt.softDelete || col.Version != tt.version {
+				t.Errorf("NewTableFromStruct() column = %+v, want %+v", col, tt)
+			}
+		})
+	}
+	invalid := []astutils.FieldMeta{
+		{Name: "DeletedAt", Type: "time.Time", Tag: `dd:"softdelete"`},
+		{Name: "Version", Type: "*int", Tag: `dd:"version"`},
+	}
+	for _, field := range invalid {
+		t.Run("invalid "+field.Name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s field of type %s should panic", field.Tag, field.Type)
+				}
+			}()
+			NewTableFromStruct(astutils.StructMeta{
+				Name:   "Order",
+				Fields: []astutils.FieldMeta{field},
+			})
+		})
+	}
+}
+
+func TestNewTableFromStruct_Comment(t *testing.T) {
+	sm := astutils.StructMeta{
+		Name: "User",
+		Fields: []astutils.FieldMeta{
+			{Name: "ID", Type: "int", Tag: `dd:"pk;auto"`},
+			{Name: "Name", Type: "string", Comments: []string{"user's name", "not unique"}},
+			{Name: "School", Type: "string", Tag: `dd:"extra:comment 'school'"`, Comments: []string{"ignored"}},
+		},
+		Comments: []string{"用户", "dd:table"},
+	}
+	tab := NewTableFromStruct(sm)
+	if tab.Comment != "用户" {
+		t.Errorf("Comment = %v, want 用户", tab.Comment)
+	}
+	if tab.Columns[1].Comment != "user's name not unique" || tab.Columns[2].Comment != "" {
+		t.Errorf("column comments = %q, %q", tab.Columns[1].Comment, tab.Columns[2].Comment)
+	}
+	got, err := tab.CreateSql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "CREATE TABLE `user` (\n" +
+		"`id` INT NOT NULL AUTO_INCREMENT,\n" +
+		"`name` VARCHAR(255) NOT NULL COMMENT 'user''s name not unique',\n" +
+		"`school` VARCHAR(255) NOT NULL comment 'school',\n" +
+		"PRIMARY KEY (`id`)) COMMENT='用户';"
+	if got != want {
+		t.Errorf("CreateSql() got = %v, want %v", got, want)
+	}
+	if got, _ = tab.Columns[1].ChangeColumnSql(); got != "ALTER TABLE `user`\nCHANGE COLUMN `name` `name` VARCHAR(255) NOT NULL COMMENT 'user''s name not unique';" {
+		t.Errorf("ChangeColumnSql() got = %v", got)
+	}
+	if got, _ = tab.CommentSql(); got != "ALTER TABLE `user` COMMENT='用户';" {
+		t.Errorf("CommentSql() got = %v", got)
+	}
+}
+
 func TestTable_CreateSql(t1 *testing.T) {
 	type fields struct {
 		Name          string
@@ -341,6 +467,60 @@ func Test_toColumnType(t *testing.T) {
 				goType: "string",
 			},
 			want: columnenum.VarcharType,
+		}, {
+			name: "8",
+			args: args{
+				goType: "uint",
+			},
+			want: columnenum.IntType + " unsigned",
+		}, {
+			name: "9",
+			args: args{
+				goType: "uint64",
+			},
+			want: columnenum.BigintType + " unsigned",
+		}, {
+			name: "10",
+			args: args{
+				goType: "uint8",
+			},
+			want: columnenum.TinyintType + " unsigned",
+		}, {
+			name: "11",
+			args: args{
+				goType: "[]byte",
+			},
+			want: columnenum.BlobType,
+		}, {
+			name: "12",
+			args: args{
+				goType: "json.RawMessage",
+			},
+			want: columnenum.JsonType,
+		}, {
+			name: "13",
+			args: args{
+				goType: "decimal.Decimal",
+			},
+			want: "DECIMAL(20,6)",
+		}, {
+			name: "14",
+			args: args{
+				goType: "sql.NullString",
+			},
+			want: columnenum.VarcharType,
+		}, {
+			name: "15",
+			args: args{
+				goType: "sql.NullInt64",
+			},
+			want: columnenum.BigintType,
+		}, {
+			name: "16",
+			args: args{
+				goType: "sql.NullTime",
+			},
+			want: columnenum.DatetimeType,
 		},
 	}
 	for _, tt := range tests {
@@ -426,6 +606,102 @@ func Test_toGoType(t *testing.T) {
 			},
 			want: "time.Time",
 		},
+		{
+			name: "9",
+			args: args{
+				colType:  "int(10) unsigned",
+				nullable: false,
+			},
+			want: "uint",
+		},
+		{
+			name: "10",
+			args: args{
+				colType:  "bigint unsigned",
+				nullable: true,
+			},
+			want: "*uint64",
+		},
+		{
+			name: "11",
+			args: args{
+				colType:  "smallint",
+				nullable: false,
+			},
+			want: "int16",
+		},
+		{
+			name: "12",
+			args: args{
+				colType:  "decimal(10,2)",
+				nullable: false,
+			},
+			want: "string",
+		},
+		{
+			name: "13",
+			args: args{
+				colType:  "enum('a','b')",
+				nullable: false,
+			},
+			want: "string",
+		},
+		{
+			name: "14",
+			args: args{
+				colType:  "json",
+				nullable: true,
+			},
+			want: "json.RawMessage",
+		},
+		{
+			name: "15",
+			args: args{
+				colType:  "blob",
+				nullable: true,
+			},
+			want: "[]byte",
+		},
+		{
+			name: "16",
+			args: args{
+				colType:  "bit(1)",
+				nullable: false,
+			},
+			want: "[]byte",
+		},
+		{
+			name: "17",
+			args: args{
+				colType:  "date",
+				nullable: true,
+			},
+			want: "*time.Time",
+		},
+		{
+			name: "18",
+			args: args{
+				colType:  "double precision",
+				nullable: false,
+			},
+			want: "float64",
+		},
+		{
+			name: "19",
+			args: args{
+				colType:  "character varying(255)",
+				nullable: false,
+			},
+			want: "string",
+		},
+		{
+			name: "20",
+			args: args{
+				colType:  "boolean",
+				nullable: false,
+			},
+			want: "bool",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -496,6 +772,65 @@ func TestNewFieldFromColumn(t *testing.T) {
 				Tag:      `dd:"auto;type:VARCHAR(255);extra:comment '学校';index:my_index,1,asc"`,
 				Comments: nil,
 			},
+		}, {
+			name: "3",
+			args: args{
+				col: Column{
+					Table: "purchase",
+					Name:  "user_id",
+					Type:  columnenum.IntType,
+					// columns read from database have nil *string default
+					Default: (*string)(nil),
+					Fk: &ForeignKey{
+						Name:      "fk_purchase_user_id",
+						Column:    "user_id",
+						RefTable:  "user",
+						RefColumn: "id",
+						OnDelete:  "CASCADE",
+						OnUpdate:  "SET NULL",
+					},
+				},
+			},
+			want: astutils.FieldMeta{
+				Name:     "UserId",
+				Type:     "int",
+				Tag:      `dd:"type:INT;fk:user.id,ondelete:cascade,onupdate:set null"`,
+				Comments: nil,
+			},
+		}, {
+			name: "comment",
+			args: args{
+				col: Column{
+					Table:   "users",
+					Name:    "name",
+					Type:    columnenum.VarcharType,
+					Default: (*string)(nil),
+					Comment: "姓名\nfull name",
+				},
+			},
+			want: astutils.FieldMeta{
+				Name:     "Name",
+				Type:     "string",
+				Tag:      `dd:"type:VARCHAR(255)"`,
+				Comments: []string{"姓名", "full name"},
+			},
+		}, {
+			name: "native enum",
+			args: args{
+				col: Column{
+					Table:    "users",
+					Name:     "mood",
+					Type:     "mood",
+					Default:  (*string)(nil),
+					Nullable: true,
+					Enum:     []string{"sad", "happy"},
+				},
+			},
+			want: astutils.FieldMeta{
+				Name: "Mood",
+				Type: "*string",
+				Tag:  `dd:"type:mood"`,
+			},
 		},
 	}
 	for _, tt := range tests {
@@ -636,4 +971,265 @@ func TestCheckAutoSet(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestColumn_RenameColumnSql(t *testing.T) {
+	c := &Column{
+		Table:   "users",
+		Name:    "college",
+		Type:    columnenum.VarcharType,
+		Default: "'harvard'",
+		OldName: "school",
+	}
+	got, err := c.RenameColumnSql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "ALTER TABLE `users`\nCHANGE COLUMN `school` `college` VARCHAR(255) NOT NULL DEFAULT 'harvard';"
+	if got != want {
+		t.Errorf("RenameColumnSql() got = %v, want %v", got, want)
+	}
+}
+
+func TestColumn_DropColumnSql(t *testing.T) {
+	c := &Column{
+		Table: "users",
+		Name:  "school",
+	}
+	got, err := c.DropColumnSql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "ALTER TABLE `users` DROP COLUMN `school`;"
+	if got != want {
+		t.Errorf("DropColumnSql() got = %v, want %v", got, want)
+	}
+}
+
+func TestTable_IndexSql(t *testing.T) {
+	tab := &Table{Name: "users"}
+	idx := Index{
+		Unique: true,
+		Name:   "name_phone_idx",
+		Items: []IndexItem{
+			{Column: "phone", Order: 1, Sort: sortenum.Asc},
+			{Column: "name", Order: 2, Sort: sortenum.Desc},
+		},
+	}
+	got, err := tab.AddIndexSql(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "ALTER TABLE `users` ADD UNIQUE INDEX `name_phone_idx` (`phone` asc,`name` desc);"
+	if got != want {
+		t.Errorf("AddIndexSql() got = %v, want %v", got, want)
+	}
+	if got, err = tab.DropIndexSql(idx); err != nil {
+		t.Fatal(err)
+	}
+	want = "ALTER TABLE `users` DROP INDEX `name_phone_idx`;"
+	if got != want {
+		t.Errorf("DropIndexSql() got = %v, want %v", got, want)
+	}
+}
+
+func TestIndexesFromDb(t *testing.T) {
+	rows := []dialect.Index{
+		{Name: "PRIMARY", Unique: true, Column: "id", Seq: 1},
+		{Name: "name_phone_idx", Column: "name", Seq: 2, Desc: true},
+		{Name: "age_idx", Column: "age", Seq: 1},
+		{Name: "name_phone_idx", Column: "phone", Seq: 1},
+		{Name: "fk_users_dept_id", Column: "dept_id", Seq: 1},
+	}
+	fks := []dialect.ForeignKey{
+		{Name: "fk_users_dept_id", Column: "dept_id", RefTable: "dept", RefColumn: "id"},
+	}
+	want := []Index{
+		{
+			Name:  "age_idx",
+			Items: []IndexItem{{Name: "age_idx", Column: "age", Order: 1, Sort: sortenum.Asc}},
+		},
+		{
+			Name: "name_phone_idx",
+			Items: []IndexItem{
+				{Name: "name_phone_idx", Column: "phone", Order: 1, Sort: sortenum.Asc},
+				{Name: "name_phone_idx", Column: "name", Order: 2, Sort: sortenum.Desc},
+			},
+		},
+	}
+	if got := IndexesFromDb(rows, fks); !reflect.DeepEqual(got, want) {
+		t.Errorf("IndexesFromDb() got = %+v, want %+v", got, want)
+	}
+}
+
+func TestDiffIndexes(t *testing.T) {
+	age := Index{Name: "age_idx", Items: []IndexItem{{Column: "age", Order: 1, Sort: sortenum.Asc}}}
+	ageDesc := Index{Name: "age_idx", Items: []IndexItem{{Column: "age", Order: 1, Sort: sortenum.Desc}}}
+	ageUnique := Index{Unique: true, Name: "age_idx", Items: []IndexItem{{Column: "age", Order: 1, Sort: sortenum.Asc}}}
+	no := Index{Name: "no_idx", Items: []IndexItem{{Column: "no", Order: 1, Sort: sortenum.Asc}}}
+	tests := []struct {
+		name     string
+		want     []Index
+		exist    []Index
+		wantAdd  []Index
+		wantDrop []Index
+	}{
+		{
+			name:  "same",
+			want:  []Index{age},
+			exist: []Index{age},
+		},
+		{
+			name:    "add",
+			want:    []Index{age, no},
+			exist:   []Index{age},
+			wantAdd: []Index{no},
+		},
+		{
+			name:     "drop",
+			want:     []Index{age},
+			exist:    []Index{age, no},
+			wantDrop: []Index{no},
+		},
+		{
+			name:     "sort changed",
+			want:     []Index{ageDesc},
+			exist:    []Index{age},
+			wantAdd:  []Index{ageDesc},
+			wantDrop: []Index{age},
+		},
+		{
+			name:     "unique changed",
+			want:     []Index{ageUnique},
+			exist:    []Index{age},
+			wantAdd:  []Index{ageUnique},
+			wantDrop: []Index{age},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotAdd, gotDrop := DiffIndexes(tt.want, tt.exist)
+			if !reflect.DeepEqual(gotAdd, tt.wantAdd) {
+				t.Errorf("DiffIndexes() gotAdd = %+v, want %+v", gotAdd, tt.wantAdd)
+			}
+			if !reflect.DeepEqual(gotDrop, tt.wantDrop) {
+				t.Errorf("DiffIndexes() gotDrop = %+v, want %+v", gotDrop, tt.wantDrop)
+			}
+		})
+	}
+}
+
+func Test_parseFk(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  ForeignKey
+	}{
+		{
+			name:  "plain",
+			value: "user.id",
+			want:  ForeignKey{Name: "fk_purchase_user_id", Column: "user_id", RefTable: "user", RefColumn: "id"},
+		},
+		{
+			name:  "actions",
+			value: "user.id,ondelete:cascade,onupdate:set null",
+			want:  ForeignKey{Name: "fk_purchase_user_id", Column: "user_id", RefTable: "user", RefColumn: "id", OnDelete: "CASCADE", OnUpdate: "SET NULL"},
+		},
+		{
+			name:  "restrict",
+			value: "user.id,ondelete:restrict",
+			want:  ForeignKey{Name: "fk_purchase_user_id", Column: "user_id", RefTable: "user", RefColumn: "id"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseFk("purchase", "user_id", tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFk() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTable_FkSql(t *testing.T) {
+	tab := &Table{Name: "purchase"}
+	fk := ForeignKey{Name: "fk_purchase_user_id", Column: "user_id", RefTable: "user", RefColumn: "id", OnDelete: "CASCADE"}
+	got, err := tab.AddFkSql(fk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "ALTER TABLE `purchase` ADD CONSTRAINT `fk_purchase_user_id` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`) ON DELETE CASCADE;"
+	if got != want {
+		t.Errorf("AddFkSql() got = %v, want %v", got, want)
+	}
+	if got, err = tab.DropFkSql(fk); err != nil {
+		t.Fatal(err)
+	}
+	want = "ALTER TABLE `purchase` DROP FOREIGN KEY `fk_purchase_user_id`;"
+	if got != want {
+		t.Errorf("DropFkSql() got = %v, want %v", got, want)
+	}
+}
+
+func TestDiffFks(t *testing.T) {
+	user := ForeignKey{Name: "fk_purchase_user_id", Column: "user_id", RefTable: "user", RefColumn: "id"}
+	userCascade := ForeignKey{Name: "fk_purchase_user_id", Column: "user_id", RefTable: "user", RefColumn: "id", OnDelete: "CASCADE"}
+	shop := ForeignKey{Name: "fk_purchase_shop_id", Column: "shop_id", RefTable: "shop", RefColumn: "id"}
+	tests := []struct {
+		name     string
+		want     []ForeignKey
+		exist    []ForeignKey
+		wantAdd  []ForeignKey
+		wantDrop []ForeignKey
+	}{
+		{
+			name:  "same",
+			want:  []ForeignKey{user},
+			exist: []ForeignKey{user},
+		},
+		{
+			name:    "add",
+			want:    []ForeignKey{user, shop},
+			exist:   []ForeignKey{user},
+			wantAdd: []ForeignKey{shop},
+		},
+		{
+			name:     "drop",
+			want:     []ForeignKey{user},
+			exist:    []ForeignKey{user, shop},
+			wantDrop: []ForeignKey{shop},
+		},
+		{
+			name:     "action changed",
+			want:     []ForeignKey{userCascade},
+			exist:    []ForeignKey{user},
+			wantAdd:  []ForeignKey{userCascade},
+			wantDrop: []ForeignKey{user},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotAdd, gotDrop := DiffFks(tt.want, tt.exist)
+			if !reflect.DeepEqual(gotAdd, tt.wantAdd) {
+				t.Errorf("DiffFks() gotAdd = %+v, want %+v", gotAdd, tt.wantAdd)
+			}
+			if !reflect.DeepEqual(gotDrop, tt.wantDrop) {
+				t.Errorf("DiffFks() gotDrop = %+v, want %+v", gotDrop, tt.wantDrop)
+			}
+		})
+	}
+}
+
+func TestSortByFk(t *testing.T) {
+	item := Table{Name: "item", Fks: []ForeignKey{{RefTable: "purchase"}}}
+	purchase := Table{Name: "purchase", Fks: []ForeignKey{{RefTable: "user"}, {RefTable: "shop"}}}
+	user := Table{Name: "user"}
+	log := Table{Name: "log"}
+	got := SortByFk([]Table{item, log, purchase, user})
+	var names []string
+	for _, tab := range got {
+		names = append(names, tab.Name)
+	}
+	want := []string{"user", "purchase", "item", "log"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("SortByFk() got = %v, want %v", names, want)
+	}
+}
@@ -5,5 +5,5 @@ import "time"
 type Base struct {
 	CreateAt *time.Time `dd:"default:CURRENT_TIMESTAMP"`
 	UpdateAt *time.Time `dd:"default:CURRENT_TIMESTAMP;extra:ON UPDATE CURRENT_TIMESTAMP"`
-	DeleteAt *time.Time
+	DeleteAt *time.Time `dd:"softdelete"`
 }
@@ -5,6 +5,7 @@ import "time"
 //dd:table
 type Purchase struct {
 	Id         int        `dd:"pk;auto;type:int(11)"`
+	UserId     int        `dd:"index;fk:user.id,ondelete:cascade"`
 	PurchaseAt *time.Time `dd:"type:datetime;extra:comment '采购时间'"`
 	CreateAt   *time.Time `dd:"type:datetime;default:CURRENT_TIMESTAMP"`
 	UpdateAt   *time.Time `dd:"type:datetime;default:CURRENT_TIMESTAMP;extra:on update CURRENT_TIMESTAMP"`
@@ -14,4 +15,5 @@ type Purchase struct {
 1: 完结
 2: 取消'"`
 	Note string `dd:"type:text;extra:comment '备注'"`
+	Version int `dd:"version;default:0"`
 }
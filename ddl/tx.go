@@ -0,0 +1,141 @@
+package ddl
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/unionj-cloud/go-doudou/ddl/dialect"
+	"time"
+)
+
+type txKey struct{}
+
+// txState is the transaction carried by context, depth counts savepoints of nested RunInTx calls
+type txState struct {
+	tx    Tx
+	depth int
+}
+
+// WithTx returns a copy of ctx carrying tx. Generated daos run statements in the transaction carried by ctx
+// instead of their own querier.
+func WithTx(ctx context.Context, tx Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, txState{tx: tx})
+}
+
+// TxFromContext returns the transaction carried by ctx
+func TxFromContext(ctx context.Context) (Tx, bool) {
+	state, ok := ctx.Value(txKey{}).(txState)
+	return state.tx, ok
+}
+
+// QuerierFromContext returns the transaction carried by ctx if any, otherwise q
+func QuerierFromContext(ctx context.Context, q Querier) Querier {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return q
+}
+
+type txConfig struct {
+	opts       *sql.TxOptions
+	maxRetries int
+}
+
+type TxOption func(*txConfig)
+
+// WithTxOptions sets isolation level and read only mode of the transaction
+func WithTxOptions(opts *sql.TxOptions) TxOption {
+	return func(conf *txConfig) {
+		conf.opts = opts
+	}
+}
+
+// WithMaxRetries sets how many times the transaction is run again after deadlock, default 3
+func WithMaxRetries(n int) TxOption {
+	return func(conf *txConfig) {
+		conf.maxRetries = n
+	}
+}
+
+// RunInTx runs fn in a transaction. The transaction is committed if fn returns nil, otherwise it is rolled back,
+// a panic in fn rolls it back and is propagated to the caller. ctx passed to fn carries the transaction, daos called with it
+// join the transaction. If ctx already carries a transaction, fn runs in a savepoint of it, which is rolled back
+// alone when fn fails. Top level transaction failed for deadlock is run again, so fn may be called more than once.
+func RunInTx(ctx context.Context, db DB, fn func(ctx context.Context, tx Tx) error, options ...TxOption) error {
+	if state, ok := ctx.Value(txKey{}).(txState); ok {
+		return runInSavepoint(ctx, state, fn)
+	}
+	conf := txConfig{maxRetries: 3}
+	for _, opt := range options {
+		opt(&conf)
+	}
+	var d dialect.Dialect
+	if named, ok := db.(interface{ DriverName() string }); ok {
+		d, _ = dialect.Get(named.DriverName())
+	}
+	for attempt := 1; ; attempt++ {
+		err := runInTx(ctx, db, conf.opts, fn)
+		if err == nil || d == nil || attempt > conf.maxRetries || !d.Retryable(err) {
+			return err
+		}
+		logrus.Warnf("transaction failed for %v, retry %d", err, attempt)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * 10 * time.Millisecond):
+		}
+	}
+}
+
+func runInTx(ctx context.Context, db DB, opts *sql.TxOptions, fn func(ctx context.Context, tx Tx) error) (err error) {
+	tx, err := db.BeginTxx(ctx, opts)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		r := recover()
+		if r != nil || err != nil {
+			if _err := tx.Rollback(); _err != nil && !errors.Is(_err, sql.ErrTxDone) {
+				logrus.Errorf("failed to rollback transaction: %+v", _err)
+			}
+		}
+		if r != nil {
+			panic(r)
+		}
+	}()
+	if err = fn(context.WithValue(ctx, txKey{}, txState{tx: tx}), tx); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+	return nil
+}
+
+func runInSavepoint(ctx context.Context, state txState, fn func(ctx context.Context, tx Tx) error) (err error) {
+	state.depth++
+	savepoint := fmt.Sprintf("sp_%d", state.depth)
+	if _, err = state.tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return errors.Wrap(err, "failed to create savepoint")
+	}
+	defer func() {
+		r := recover()
+		if r != nil || err != nil {
+			if _, _err := state.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint); _err != nil {
+				logrus.Errorf("failed to rollback to savepoint %s: %+v", savepoint, _err)
+			}
+		}
+		if r != nil {
+			panic(r)
+		}
+	}()
+	if err = fn(context.WithValue(ctx, txKey{}, state), state.tx); err != nil {
+		return err
+	}
+	if _, err = state.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+savepoint); err != nil {
+		return errors.Wrap(err, "failed to release savepoint")
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package ddl
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newTestDB(t *testing.T) *GddDB {
+	db, err := sqlx.Connect("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+	db.MustExec("create table item (name varchar(255))")
+	return &GddDB{db}
+}
+
+func names(t *testing.T, db *GddDB) []string {
+	var result []string
+	if err := db.Select(&result, "select name from item order by name"); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func insert(ctx context.Context, q Querier, name string) error {
+	_, err := QuerierFromContext(ctx, q).ExecContext(ctx, "insert into item (name) values (?)", name)
+	return err
+}
+
+func TestRunInTx(t *testing.T) {
+	errFail := errors.New("fail")
+	tests := []struct {
+		name      string
+		fn        func(db *GddDB) func(ctx context.Context, tx Tx) error
+		wantErr   bool
+		wantPanic bool
+		want      []string
+	}{
+		{
+			name: "commit",
+			fn: func(db *GddDB) func(ctx context.Context, tx Tx) error {
+				return func(ctx context.Context, tx Tx) error {
+					return insert(ctx, db, "a")
+				}
+			},
+			want: []string{"a"},
+		},
+		{
+			name: "rollback",
+			fn: func(db *GddDB) func(ctx context.Context, tx Tx) error {
+				return func(ctx context.Context, tx Tx) error {
+					if err := insert(ctx, db, "a"); err != nil {
+						return err
+					}
+					return errFail
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name: "panic",
+			fn: func(db *GddDB) func(ctx context.Context, tx Tx) error {
+				return func(ctx context.Context, tx Tx) error {
+					if err := insert(ctx, db, "a"); err != nil {
+						return err
+					}
+					panic("boom")
+				}
+			},
+			wantPanic: true,
+		},
+		{
+			name: "panic in savepoint",
+			fn: func(db *GddDB) func(ctx context.Context, tx Tx) error {
+				return func(ctx context.Context, tx Tx) error {
+					if err := insert(ctx, db, "a"); err != nil {
+						return err
+					}
+					return RunInTx(ctx, db, func(ctx context.Context, tx Tx) error {
+						if err := insert(ctx, db, "b"); err != nil {
+							return err
+						}
+						var items []string
+						_ = items[1]
+						return nil
+					})
+				}
+			},
+			wantPanic: true,
+		},
+		{
+			name: "savepoint",
+			fn: func(db *GddDB) func(ctx context.Context, tx Tx) error {
+				return func(ctx context.Context, tx Tx) error {
+					if err := insert(ctx, db, "a"); err != nil {
+						return err
+					}
+					if err := RunInTx(ctx, db, func(ctx context.Context, tx Tx) error {
+						if err := insert(ctx, db, "b"); err != nil {
+							return err
+						}
+						return errFail
+					}); err != errFail {
+						return errors.Errorf("nested RunInTx() error = %v, want %v", err, errFail)
+					}
+					return RunInTx(ctx, db, func(ctx context.Context, tx Tx) error {
+						return insert(ctx, db, "c")
+					})
+				}
+			},
+			want: []string{"a", "c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			func() {
+				defer func() {
+					if r := recover(); (r != nil) != tt.wantPanic {
+						t.Errorf("RunInTx() panic = %v, wantPanic %v", r, tt.wantPanic)
+					}
+				}()
+				if err := RunInTx(context.Background(), db, tt.fn(db)); (err != nil) != tt.wantErr {
+					t.Errorf("RunInTx() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			}()
+			if got := names(t, db); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RunInTx() rows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunInTx_Retry(t *testing.T) {
+	db := newTestDB(t)
+	calls := 0
+	err := RunInTx(context.Background(), db, func(ctx context.Context, tx Tx) error {
+		calls++
+		if err := insert(ctx, db, "a"); err != nil {
+			return err
+		}
+		if calls < 3 {
+			return errors.Wrap(sqlite3.Error{Code: sqlite3.ErrBusy}, "error returned from calling db.Exec")
+		}
+		return nil
+	}, WithMaxRetries(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Errorf("RunInTx() calls = %d, want 3", calls)
+	}
+	if got := names(t, db); len(got) != 1 {
+		t.Errorf("RunInTx() rows = %v, want [a]", got)
+	}
+
+	calls = 0
+	err = RunInTx(context.Background(), db, func(ctx context.Context, tx Tx) error {
+		calls++
+		return sqlite3.Error{Code: sqlite3.ErrBusy}
+	}, WithMaxRetries(1))
+	if err == nil || calls != 2 {
+		t.Errorf("RunInTx() error = %v, calls = %d, want error after 2 calls", err, calls)
+	}
+}
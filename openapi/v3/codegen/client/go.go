@@ -141,6 +141,7 @@ func (receiver *{{.Meta.Name}}Client) SetClient(client *resty.Client) {
 		{{- end }}
 
 		_resp, _err := _req.{{$m.Name | restyMethod}}(_server + "{{$m.Path}}")
+		ddhttp.ReportResult(receiver.provider, _server, _resp, _err)
 		if _err != nil {
 			err = errors.Wrap(_err, "")
 			return
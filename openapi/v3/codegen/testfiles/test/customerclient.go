@@ -42,6 +42,7 @@ func (receiver *CustomerClient) GetCustomerValidateToken(ctx context.Context,
 	_req.SetQueryParamsFromValues(_queryParams)
 
 	_resp, _err := _req.Get(_server + "/customer/validateToken")
+	ddhttp.ReportResult(receiver.provider, _server, _resp, _err)
 	if _err != nil {
 		err = errors.Wrap(_err, "")
 		return
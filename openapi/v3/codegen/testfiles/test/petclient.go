@@ -46,6 +46,7 @@ func (receiver *PetClient) GetPetFindByTags(ctx context.Context,
 	_req.SetQueryParamsFromValues(_queryParams)
 
 	_resp, _err := _req.Get(_server + "/pet/findByTags")
+	ddhttp.ReportResult(receiver.provider, _server, _resp, _err)
 	if _err != nil {
 		err = errors.Wrap(_err, "")
 		return
@@ -82,6 +83,7 @@ func (receiver *PetClient) GetPetFindByStatus(ctx context.Context,
 	_req.SetQueryParamsFromValues(_queryParams)
 
 	_resp, _err := _req.Get(_server + "/pet/findByStatus")
+	ddhttp.ReportResult(receiver.provider, _server, _resp, _err)
 	if _err != nil {
 		err = errors.Wrap(_err, "")
 		return
@@ -128,6 +130,7 @@ func (receiver *PetClient) PostPetPetIdUploadImage(ctx context.Context,
 	_req.SetFileReader("_uploadFile", _uploadFile.Filename, _f)
 
 	_resp, _err := _req.Post(_server + "/pet/{petId}/uploadImage")
+	ddhttp.ReportResult(receiver.provider, _server, _resp, _err)
 	if _err != nil {
 		err = errors.Wrap(_err, "")
 		return
@@ -163,6 +166,7 @@ func (receiver *PetClient) GetPetPetId(ctx context.Context,
 	_req.SetPathParam("petId", fmt.Sprintf("%v", petId))
 
 	_resp, _err := _req.Get(_server + "/pet/{petId}")
+	ddhttp.ReportResult(receiver.provider, _server, _resp, _err)
 	if _err != nil {
 		err = errors.Wrap(_err, "")
 		return
@@ -196,6 +200,7 @@ func (receiver *PetClient) PostPet(ctx context.Context,
 	_req.SetBody(bodyJson)
 
 	_resp, _err := _req.Post(_server + "/pet")
+	ddhttp.ReportResult(receiver.provider, _server, _resp, _err)
 	if _err != nil {
 		err = errors.Wrap(_err, "")
 		return
@@ -229,6 +234,7 @@ func (receiver *PetClient) PutPet(ctx context.Context,
 	_req.SetBody(bodyJson)
 
 	_resp, _err := _req.Put(_server + "/pet")
+	ddhttp.ReportResult(receiver.provider, _server, _resp, _err)
 	if _err != nil {
 		err = errors.Wrap(_err, "")
 		return
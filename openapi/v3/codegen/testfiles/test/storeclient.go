@@ -39,6 +39,7 @@ func (receiver *StoreClient) GetStoreInventory(ctx context.Context) (ret map[str
 	_req.SetContext(ctx)
 
 	_resp, _err := _req.Get(_server + "/store/inventory")
+	ddhttp.ReportResult(receiver.provider, _server, _resp, _err)
 	if _err != nil {
 		err = errors.Wrap(_err, "")
 		return
@@ -72,6 +73,7 @@ func (receiver *StoreClient) PostStoreOrder(ctx context.Context,
 	_req.SetBody(bodyJson)
 
 	_resp, _err := _req.Post(_server + "/store/order")
+	ddhttp.ReportResult(receiver.provider, _server, _resp, _err)
 	if _err != nil {
 		err = errors.Wrap(_err, "")
 		return
@@ -107,6 +109,7 @@ func (receiver *StoreClient) GetStoreOrderOrderId(ctx context.Context,
 	_req.SetPathParam("orderId", fmt.Sprintf("%v", orderId))
 
 	_resp, _err := _req.Get(_server + "/store/order/{orderId}")
+	ddhttp.ReportResult(receiver.provider, _server, _resp, _err)
 	if _err != nil {
 		err = errors.Wrap(_err, "")
 		return
@@ -47,6 +47,7 @@ func (receiver *UnipayClient) GetUnipayStartUnionPay(ctx context.Context,
 	_req.SetQueryParamsFromValues(_queryParams)
 
 	_resp, _err := _req.Get(_server + "/unipay/startUnionPay")
+	ddhttp.ReportResult(receiver.provider, _server, _resp, _err)
 	if _err != nil {
 		err = errors.Wrap(_err, "")
 		return
@@ -43,6 +43,7 @@ func (receiver *UserClient) GetUserUsername(ctx context.Context,
 	_req.SetPathParam("username", fmt.Sprintf("%v", username))
 
 	_resp, _err := _req.Get(_server + "/user/{username}")
+	ddhttp.ReportResult(receiver.provider, _server, _resp, _err)
 	if _err != nil {
 		err = errors.Wrap(_err, "")
 		return
@@ -76,6 +77,7 @@ func (receiver *UserClient) PostUserCreateWithList(ctx context.Context,
 	_req.SetBody(bodyJson)
 
 	_resp, _err := _req.Post(_server + "/user/createWithList")
+	ddhttp.ReportResult(receiver.provider, _server, _resp, _err)
 	if _err != nil {
 		err = errors.Wrap(_err, "")
 		return
@@ -112,6 +114,7 @@ func (receiver *UserClient) GetUserLogin(ctx context.Context,
 	_req.SetQueryParamsFromValues(_queryParams)
 
 	_resp, _err := _req.Get(_server + "/user/login")
+	ddhttp.ReportResult(receiver.provider, _server, _resp, _err)
 	if _err != nil {
 		err = errors.Wrap(_err, "")
 		return
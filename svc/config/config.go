@@ -24,7 +24,27 @@ const (
 	GddPort     envVariable = "GDD_PORT"
 	GddMemPort  envVariable = "GDD_MEM_PORT"
 	GddBaseUrl  envVariable = "GDD_BASE_URL"
-	GddSeed     envVariable = "GDD_SEED"
+	// GddSeed comma separated seed addresses. Each address can be host:port, or dns+host:port for resolving
+	// all A/AAAA records of host, or dnssrv+_service._proto.name for resolving SRV records
+	GddSeed envVariable = "GDD_SEED"
+	// GddMemJoinRetry max attempts for joining cluster, default 3
+	GddMemJoinRetry envVariable = "GDD_MEM_JOIN_RETRY"
+	// GddMemJoinBackoff initial backoff duration between join attempts, doubled after each failure, default 1s
+	GddMemJoinBackoff envVariable = "GDD_MEM_JOIN_BACKOFF"
+	// GddMemRejoinInterval interval for checking whether local node is isolated and rejoining seeds, default 30s
+	GddMemRejoinInterval envVariable = "GDD_MEM_REJOIN_INTERVAL"
+	// GddMemProfile memberlist default config profile. Accept 'lan', 'wan' or 'local', default 'wan'
+	GddMemProfile envVariable = "GDD_MEM_PROFILE"
+	// GddMemProbeInterval overrides the interval between random node probes of the chosen profile
+	GddMemProbeInterval envVariable = "GDD_MEM_PROBE_INTERVAL"
+	// GddMemProbeTimeout overrides the timeout to wait for an ack from a probed node of the chosen profile
+	GddMemProbeTimeout envVariable = "GDD_MEM_PROBE_TIMEOUT"
+	// GddMemSuspicionMult overrides the multiplier for determining the time an inaccessible node
+	// is considered suspect before declaring it dead
+	GddMemSuspicionMult envVariable = "GDD_MEM_SUSPICION_MULT"
+	// GddMemKey comma separated base64 encoded AES keys (16, 24 or 32 bytes) for encrypting gossip messages.
+	// The first one is the primary key used for encrypting, all of them are used for decrypting
+	GddMemKey envVariable = "GDD_MEM_KEY"
 	// Accept 'mono' for monolith mode or 'micro' for microservice mode
 	GddMode envVariable = "GDD_MODE"
 	// GddManage if true, it will add built-in apis with /go-doudou path prefix for online api document and service status monitor etc.
@@ -3,12 +3,15 @@ package ddhttp
 import (
 	"github.com/go-resty/resty/v2"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/unionj-cloud/go-doudou/stringutils"
 	"github.com/unionj-cloud/go-doudou/svc/registry"
 	"net"
 	"net/http"
 	"os"
+	"reflect"
 	"runtime"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -85,11 +88,14 @@ func NewClient() *resty.Client {
 	return client
 }
 
+// MemberlistServiceProvider selects server from service discovery in round robin way, skipping servers
+// ejected by passive outlier detection
 type MemberlistServiceProvider struct {
 	// Name of the service that dependent on
 	name     string
 	registry registry.IRegistry
 	current  uint64
+	outlier  *outlierDetector
 }
 
 func (m *MemberlistServiceProvider) SelectServer() (string, error) {
@@ -97,10 +103,20 @@ func (m *MemberlistServiceProvider) SelectServer() (string, error) {
 	if err != nil {
 		return "", errors.Wrap(err, "SelectServer() fail")
 	}
-	next := int(atomic.AddUint64(&m.current, uint64(1)) % uint64(len(nodes)))
-	m.current = uint64(next)
-	selected := nodes[next]
-	return selected.BaseUrl(), nil
+	if len(nodes) == 0 {
+		return "", errors.Errorf("SelectServer() fail: no %s service found", m.name)
+	}
+	var servers []string
+	for _, node := range nodes {
+		servers = append(servers, node.BaseUrl())
+	}
+	servers = m.outlier.filter(servers)
+	next := int(atomic.AddUint64(&m.current, uint64(1)) % uint64(len(servers)))
+	return servers[next], nil
+}
+
+func (m *MemberlistServiceProvider) Report(server string, latency time.Duration, err error) {
+	m.outlier.report(server, latency, err)
 }
 
 type MemberlistProviderOption func(IServiceProvider)
@@ -109,6 +125,128 @@ func NewMemberlistServiceProvider(name string, registry registry.IRegistry, opts
 	provider := &MemberlistServiceProvider{
 		name:     name,
 		registry: registry,
+		outlier:  newOutlierDetector(name, DefaultOutlierConfig()),
+	}
+
+	for _, opt := range opts {
+		opt(provider)
+	}
+
+	return provider
+}
+
+// CompositeServiceProvider selects server from service discovery when available.
+// Like MemberlistServiceProvider, servers found to be outliers by passive outlier detection are skipped.
+// The last known good endpoint set is cached and used for cacheTTL when discovery fails or finds nothing,
+// then it falls back to the address from environment variable.
+type CompositeServiceProvider struct {
+	// Name of the service that dependent on
+	name     string
+	env      string
+	registry registry.IRegistry
+	current  uint64
+
+	lock     sync.RWMutex
+	cached   []string
+	cachedAt time.Time
+	// cacheTTL is how long the cached endpoints are valid, zero or negative means forever
+	cacheTTL time.Duration
+	// endpoints are fixed endpoints injected by WithEndpoints, discovery and env are ignored if not empty
+	endpoints []string
+	outlier   *outlierDetector
+}
+
+func (c *CompositeServiceProvider) SelectServer() (string, error) {
+	servers, err := c.servers()
+	if err != nil {
+		return "", errors.Wrap(err, "SelectServer() fail")
+	}
+	servers = c.outlier.filter(servers)
+	next := int(atomic.AddUint64(&c.current, uint64(1)) % uint64(len(servers)))
+	return servers[next], nil
+}
+
+func (c *CompositeServiceProvider) Report(server string, latency time.Duration, err error) {
+	c.outlier.report(server, latency, err)
+}
+
+func (c *CompositeServiceProvider) servers() ([]string, error) {
+	if len(c.endpoints) > 0 {
+		return c.endpoints, nil
+	}
+	if c.registry != nil {
+		nodes, err := c.registry.Discover(c.name)
+		if err != nil {
+			logrus.Warnf("Discover %s service failed: %s", c.name, err)
+		}
+		if len(nodes) > 0 {
+			var servers []string
+			for _, node := range nodes {
+				servers = append(servers, node.BaseUrl())
+			}
+			c.lock.Lock()
+			c.cached = servers
+			c.cachedAt = time.Now()
+			c.lock.Unlock()
+			return servers, nil
+		}
+		if cached := c.cachedServers(); len(cached) > 0 {
+			return cached, nil
+		}
+	}
+	address := os.Getenv(c.env)
+	if stringutils.IsEmpty(address) {
+		return nil, errors.Errorf("no %s service found from registry, and no service address found from environment variable %s", c.name, c.env)
+	}
+	return []string{address}, nil
+}
+
+func (c *CompositeServiceProvider) cachedServers() []string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.cacheTTL > 0 && time.Since(c.cachedAt) > c.cacheTTL {
+		return nil
+	}
+	return c.cached
+}
+
+const defaultCacheTTL = time.Minute
+
+type CompositeProviderOption func(IServiceProvider)
+
+// WithCacheTTL sets how long the last known good endpoint set can be used when discovery finds nothing, default 1 minute
+func WithCacheTTL(ttl time.Duration) CompositeProviderOption {
+	return func(provider IServiceProvider) {
+		if c, ok := provider.(*CompositeServiceProvider); ok {
+			c.cacheTTL = ttl
+		}
+	}
+}
+
+// WithEndpoints injects fixed endpoints, mainly for tests. Discovery and env variable will be ignored.
+func WithEndpoints(endpoints ...string) CompositeProviderOption {
+	return func(provider IServiceProvider) {
+		if c, ok := provider.(*CompositeServiceProvider); ok {
+			c.endpoints = endpoints
+		}
+	}
+}
+
+// NewCompositeServiceProvider creates provider for service name. registry can be nil in monolith mode,
+// then address from environment variable env is always used.
+func NewCompositeServiceProvider(name string, env string, registry registry.IRegistry, opts ...CompositeProviderOption) IServiceProvider {
+	// a nil *registry.Node passed as IRegistry is not a nil interface, treat it as no registry
+	if registry != nil {
+		if v := reflect.ValueOf(registry); v.Kind() == reflect.Ptr && v.IsNil() {
+			registry = nil
+		}
+	}
+	provider := &CompositeServiceProvider{
+		name:     name,
+		env:      env,
+		registry: registry,
+		cacheTTL: defaultCacheTTL,
+		outlier:  newOutlierDetector(name, DefaultOutlierConfig()),
 	}
 
 	for _, opt := range opts {
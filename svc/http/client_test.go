@@ -0,0 +1,142 @@
+package ddhttp
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/unionj-cloud/go-doudou/svc/config"
+	"github.com/unionj-cloud/go-doudou/svc/registry"
+)
+
+type stubRegistry struct {
+	nodes []*registry.Node
+	err   error
+}
+
+func (s *stubRegistry) Register() error {
+	return nil
+}
+
+func (s *stubRegistry) Discover(svc string) ([]*registry.Node, error) {
+	return s.nodes, s.err
+}
+
+func newTestNode(t *testing.T) *registry.Node {
+	os.Setenv(config.GddName.String(), "usersvc")
+	os.Setenv(config.GddHostname.String(), "usersvc-1")
+	os.Setenv(config.GddMemPort.String(), "0")
+	os.Setenv(config.GddBaseUrl.String(), "http://usersvc-1:6060")
+	os.Setenv(config.GddSeed.String(), "")
+	defer os.Unsetenv(config.GddBaseUrl.String())
+	node, err := registry.NewNode()
+	if err != nil {
+		t.Fatalf("NewNode() error = %+v", err)
+	}
+	return node
+}
+
+func TestCompositeServiceProvider_SelectServer(t *testing.T) {
+	node := newTestNode(t)
+	defer node.Shutdown()
+	os.Setenv("USERSVC", "http://localhost:6060")
+	defer os.Unsetenv("USERSVC")
+
+	tests := []struct {
+		name     string
+		registry registry.IRegistry
+		opts     []CompositeProviderOption
+		want     string
+	}{
+		{
+			name:     "discovery",
+			registry: node,
+			want:     "http://usersvc-1:6060",
+		},
+		{
+			name:     "empty registry",
+			registry: &stubRegistry{},
+			want:     "http://localhost:6060",
+		},
+		{
+			name:     "discovery failed",
+			registry: &stubRegistry{err: errors.New("Memberlist is nil")},
+			want:     "http://localhost:6060",
+		},
+		{
+			name: "no registry",
+			want: "http://localhost:6060",
+		},
+		{
+			name:     "typed nil registry",
+			registry: (*registry.Node)(nil),
+			want:     "http://localhost:6060",
+		},
+		{
+			name:     "fixed endpoints",
+			registry: node,
+			opts:     []CompositeProviderOption{WithEndpoints("http://fixed:6060")},
+			want:     "http://fixed:6060",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewCompositeServiceProvider("usersvc", "USERSVC", tt.registry, tt.opts...)
+			got, err := provider.SelectServer()
+			if err != nil {
+				t.Fatalf("SelectServer() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("SelectServer() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompositeServiceProvider_Cache(t *testing.T) {
+	node := newTestNode(t)
+	defer node.Shutdown()
+	os.Setenv("USERSVC", "http://localhost:6060")
+	defer os.Unsetenv("USERSVC")
+
+	reg := &stubRegistry{}
+	reg.nodes, _ = node.Discover("usersvc")
+	provider := NewCompositeServiceProvider("usersvc", "USERSVC", reg, WithCacheTTL(50*time.Millisecond))
+	if got, _ := provider.SelectServer(); got != "http://usersvc-1:6060" {
+		t.Fatalf("SelectServer() = %v, want %v", got, "http://usersvc-1:6060")
+	}
+
+	// registry becomes unavailable, the last known good endpoints are used until cache expired
+	reg.nodes, reg.err = nil, errors.New("Memberlist is nil")
+	if got, _ := provider.SelectServer(); got != "http://usersvc-1:6060" {
+		t.Errorf("SelectServer() = %v, want cached %v", got, "http://usersvc-1:6060")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if got, _ := provider.SelectServer(); got != "http://localhost:6060" {
+		t.Errorf("SelectServer() = %v, want %v", got, "http://localhost:6060")
+	}
+}
+
+func TestCompositeServiceProvider_NoServer(t *testing.T) {
+	os.Unsetenv("USERSVC")
+	provider := NewCompositeServiceProvider("usersvc", "USERSVC", &stubRegistry{})
+	if _, err := provider.SelectServer(); err == nil {
+		t.Error("SelectServer() error = nil, want error")
+	}
+}
+
+func TestCompositeServiceProvider_RoundRobin(t *testing.T) {
+	provider := NewCompositeServiceProvider("usersvc", "USERSVC", nil, WithEndpoints("http://a:6060", "http://b:6060"))
+	seen := make(map[string]int)
+	for i := 0; i < 4; i++ {
+		got, err := provider.SelectServer()
+		if err != nil {
+			t.Fatalf("SelectServer() error = %v", err)
+		}
+		seen[got]++
+	}
+	if seen["http://a:6060"] != 2 || seen["http://b:6060"] != 2 {
+		t.Errorf("SelectServer() distribution = %v, want 2 each", seen)
+	}
+}
@@ -0,0 +1,238 @@
+package ddhttp
+
+import (
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	outlierEjected = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ddhttp_outlier_ejected",
+		Help: "Whether the server is ejected from load balancing, 1 for ejected and 0 for not.",
+	}, []string{"service", "server"})
+
+	outlierEjections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ddhttp_outlier_ejections_total",
+		Help: "Number of times the server has been ejected from load balancing.",
+	}, []string{"service", "server"})
+
+	outlierLatency = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ddhttp_outlier_latency_seconds",
+		Help: "Moving average latency of calls to the server.",
+	}, []string{"service", "server"})
+)
+
+// IOutlierReporter is implemented by service providers supporting passive outlier detection
+type IOutlierReporter interface {
+	// Report feeds the result of a call to server back to the provider. err is non-nil for failed calls.
+	Report(server string, latency time.Duration, err error)
+}
+
+// ReportResult reports the result of a call to server to provider if provider implements IOutlierReporter.
+// Transport errors and 5xx responses are failures. Generated clients call it after each request.
+func ReportResult(provider IServiceProvider, server string, resp *resty.Response, err error) {
+	reporter, ok := provider.(IOutlierReporter)
+	if !ok {
+		return
+	}
+	var latency time.Duration
+	if resp != nil {
+		latency = resp.Time()
+		if err == nil && resp.StatusCode() >= http.StatusInternalServerError {
+			err = fmt.Errorf("server responded %s", resp.Status())
+		}
+	}
+	reporter.Report(server, latency, err)
+}
+
+// OutlierConfig configures passive outlier detection
+type OutlierConfig struct {
+	// ConsecutiveFailures is the number of consecutive failures before a server is ejected, zero disables ejection
+	ConsecutiveFailures int
+	// LatencyThreshold calls slower than it are counted as failures, zero means latency is ignored
+	LatencyThreshold time.Duration
+	// BaseEjectionTime is the ejection duration of the first ejection, doubled for each following ejection
+	BaseEjectionTime time.Duration
+	// MaxEjectionTime caps the ejection duration, zero means 10 times of BaseEjectionTime
+	MaxEjectionTime time.Duration
+	// MaxEjectionPercent is the max percentage of servers that can be ejected at the same time
+	MaxEjectionPercent int
+}
+
+// DefaultOutlierConfig returns the config used by providers if WithOutlierDetection is not given
+func DefaultOutlierConfig() OutlierConfig {
+	return OutlierConfig{
+		ConsecutiveFailures: 5,
+		BaseEjectionTime:    30 * time.Second,
+		MaxEjectionTime:     5 * time.Minute,
+		MaxEjectionPercent:  50,
+	}
+}
+
+// WithOutlierDetection overrides the outlier detection config of MemberlistServiceProvider or CompositeServiceProvider
+func WithOutlierDetection(conf OutlierConfig) func(IServiceProvider) {
+	return func(provider IServiceProvider) {
+		switch p := provider.(type) {
+		case *MemberlistServiceProvider:
+			p.outlier = newOutlierDetector(p.name, conf)
+		case *CompositeServiceProvider:
+			p.outlier = newOutlierDetector(p.name, conf)
+		}
+	}
+}
+
+type serverStat struct {
+	failures int
+	latency  time.Duration
+	// ejections is the number of times the server has been ejected, it decides the next ejection duration
+	ejections    int
+	ejectedUntil time.Time
+	// inEjection is true until the server is seen back by filter after ejectedUntil
+	inEjection bool
+	// absent is true if the server is not in the servers of the last filter call
+	absent bool
+}
+
+func (s *serverStat) ejected(now time.Time) bool {
+	return now.Before(s.ejectedUntil)
+}
+
+type outlierDetector struct {
+	service string
+	conf    OutlierConfig
+	lock    sync.Mutex
+	stats   map[string]*serverStat
+	// hosts is the number of servers seen by the last filter call, used for capping ejected percentage
+	hosts int
+	now   func() time.Time
+}
+
+func newOutlierDetector(service string, conf OutlierConfig) *outlierDetector {
+	if conf.MaxEjectionTime <= 0 {
+		conf.MaxEjectionTime = 10 * conf.BaseEjectionTime
+	}
+	return &outlierDetector{
+		service: service,
+		conf:    conf,
+		stats:   make(map[string]*serverStat),
+		now:     time.Now,
+	}
+}
+
+func (o *outlierDetector) stat(server string) *serverStat {
+	s, ok := o.stats[server]
+	if !ok {
+		s = &serverStat{}
+		o.stats[server] = s
+	}
+	return s
+}
+
+// filter removes ejected servers. All servers are returned if every one of them is ejected.
+// Stats of servers not in servers any more are kept until their ejections are forgotten,
+// so a server flapping in and out of discovery doesn't get its ejection backoff reset.
+func (o *outlierDetector) filter(servers []string) []string {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.hosts = len(servers)
+	now := o.now()
+	current := make(map[string]struct{}, len(servers))
+	var healthy []string
+	for _, server := range servers {
+		current[server] = struct{}{}
+		s, ok := o.stats[server]
+		if ok {
+			s.absent = false
+		}
+		if ok && s.ejected(now) {
+			continue
+		}
+		if ok && s.inEjection {
+			s.inEjection = false
+			outlierEjected.WithLabelValues(o.service, server).Set(0)
+			logrus.Infof("Server %s of %s service is back to load balancing", server, o.service)
+		}
+		healthy = append(healthy, server)
+	}
+	for server, s := range o.stats {
+		if _, ok := current[server]; ok {
+			continue
+		}
+		s.absent = true
+		if s.ejections == 0 || now.Sub(s.ejectedUntil) > o.conf.MaxEjectionTime {
+			delete(o.stats, server)
+			outlierEjected.DeleteLabelValues(o.service, server)
+			outlierLatency.DeleteLabelValues(o.service, server)
+		}
+	}
+	if len(healthy) == 0 {
+		return servers
+	}
+	return healthy
+}
+
+func (o *outlierDetector) report(server string, latency time.Duration, err error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	now := o.now()
+	s := o.stat(server)
+	if latency > 0 {
+		if s.latency == 0 {
+			s.latency = latency
+		} else {
+			s.latency = (s.latency*4 + latency) / 5
+		}
+		outlierLatency.WithLabelValues(o.service, server).Set(s.latency.Seconds())
+	}
+	if err == nil && (o.conf.LatencyThreshold <= 0 || latency <= o.conf.LatencyThreshold) {
+		s.failures = 0
+		// forget earlier ejections once the server stays healthy for a whole max ejection time
+		if s.ejections > 0 && now.Sub(s.ejectedUntil) > o.conf.MaxEjectionTime {
+			s.ejections = 0
+		}
+		return
+	}
+	s.failures++
+	if o.conf.ConsecutiveFailures <= 0 || s.failures < o.conf.ConsecutiveFailures || s.ejected(now) {
+		return
+	}
+	if !o.canEject(now) {
+		logrus.Warnf("Server %s of %s service is an outlier, but max ejection percent %d%% reached", server, o.service, o.conf.MaxEjectionPercent)
+		return
+	}
+	s.failures = 0
+	s.ejections++
+	ejectedFor := o.ejectionTime(s.ejections)
+	s.ejectedUntil = now.Add(ejectedFor)
+	s.inEjection = true
+	outlierEjected.WithLabelValues(o.service, server).Set(1)
+	outlierEjections.WithLabelValues(o.service, server).Inc()
+	logrus.Warnf("Server %s of %s service is ejected from load balancing for %s", server, o.service, ejectedFor)
+}
+
+func (o *outlierDetector) ejectionTime(ejections int) time.Duration {
+	d := o.conf.BaseEjectionTime
+	for i := 1; i < ejections && d < o.conf.MaxEjectionTime; i++ {
+		d *= 2
+	}
+	if d > o.conf.MaxEjectionTime {
+		d = o.conf.MaxEjectionTime
+	}
+	return d
+}
+
+func (o *outlierDetector) canEject(now time.Time) bool {
+	var ejected int
+	for _, s := range o.stats {
+		if !s.absent && s.ejected(now) {
+			ejected++
+		}
+	}
+	return (ejected+1)*100 <= o.conf.MaxEjectionPercent*o.hosts
+}
@@ -0,0 +1,211 @@
+package ddhttp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	return f.now
+}
+
+func newTestDetector(conf OutlierConfig) (*outlierDetector, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1600000000, 0)}
+	o := newOutlierDetector("usersvc", conf)
+	o.now = clock.Now
+	return o, clock
+}
+
+func failN(o *outlierDetector, server string, n int) {
+	for i := 0; i < n; i++ {
+		o.report(server, time.Millisecond, errors.New("server responded 500 Internal Server Error"))
+	}
+}
+
+func TestOutlierDetector_Eject(t *testing.T) {
+	o, clock := newTestDetector(OutlierConfig{
+		ConsecutiveFailures: 3,
+		BaseEjectionTime:    10 * time.Second,
+		MaxEjectionTime:     30 * time.Second,
+		MaxEjectionPercent:  50,
+	})
+	servers := []string{"http://a:6060", "http://b:6060"}
+	o.filter(servers)
+
+	failN(o, "http://a:6060", 2)
+	o.report("http://a:6060", time.Millisecond, nil)
+	failN(o, "http://a:6060", 2)
+	if got := o.filter(servers); !reflect.DeepEqual(got, servers) {
+		t.Fatalf("filter() = %v, want %v, failures are not consecutive", got, servers)
+	}
+
+	// ejection durations are 10s, 20s, then capped by 30s
+	assertEjections(t, o, clock, servers, []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second, 30 * time.Second})
+}
+
+func TestOutlierDetector_EjectWithoutMax(t *testing.T) {
+	o, clock := newTestDetector(OutlierConfig{
+		ConsecutiveFailures: 3,
+		BaseEjectionTime:    10 * time.Second,
+		MaxEjectionPercent:  50,
+	})
+	servers := []string{"http://a:6060", "http://b:6060"}
+	o.filter(servers)
+	// a server failing again and again is kept out longer and longer until 10 times of base ejection time
+	assertEjections(t, o, clock, servers, []time.Duration{
+		10 * time.Second, 20 * time.Second, 40 * time.Second, 80 * time.Second, 100 * time.Second, 100 * time.Second,
+	})
+}
+
+// assertEjections ejects server a repeatedly, it should be ejected for durations in order
+func assertEjections(t *testing.T, o *outlierDetector, clock *fakeClock, servers []string, durations []time.Duration) {
+	t.Helper()
+	for _, d := range durations {
+		failN(o, "http://a:6060", 3)
+		if got := o.filter(servers); !reflect.DeepEqual(got, []string{"http://b:6060"}) {
+			t.Fatalf("filter() = %v, want a ejected", got)
+		}
+		if got := testutil.ToFloat64(outlierEjected.WithLabelValues("usersvc", "http://a:6060")); got != 1 {
+			t.Errorf("ddhttp_outlier_ejected = %v, want 1", got)
+		}
+		clock.now = clock.now.Add(d - time.Millisecond)
+		if got := o.filter(servers); len(got) != 1 {
+			t.Fatalf("filter() = %v, want a still ejected before %s", got, d)
+		}
+		clock.now = clock.now.Add(time.Millisecond)
+		if got := o.filter(servers); !reflect.DeepEqual(got, servers) {
+			t.Fatalf("filter() = %v, want a back after %s", got, d)
+		}
+		if got := testutil.ToFloat64(outlierEjected.WithLabelValues("usersvc", "http://a:6060")); got != 0 {
+			t.Errorf("ddhttp_outlier_ejected = %v, want 0", got)
+		}
+	}
+}
+
+func TestOutlierDetector_AbsentServer(t *testing.T) {
+	o, clock := newTestDetector(OutlierConfig{
+		ConsecutiveFailures: 3,
+		BaseEjectionTime:    10 * time.Second,
+		MaxEjectionTime:     30 * time.Second,
+		MaxEjectionPercent:  50,
+	})
+	servers := []string{"http://a:6060", "http://b:6060", "http://c:6060"}
+	o.filter(servers)
+	failN(o, "http://a:6060", 3)
+
+	// a drops out of discovery while ejected, it doesn't count for max ejection percent of the others
+	others := []string{"http://b:6060", "http://c:6060"}
+	clock.now = clock.now.Add(5 * time.Second)
+	o.filter(others)
+	failN(o, "http://b:6060", 3)
+	if got := o.filter(others); !reflect.DeepEqual(got, []string{"http://c:6060"}) {
+		t.Fatalf("filter() = %v, want b ejected", got)
+	}
+
+	// a comes back after its ejection, the next ejection still backs off
+	clock.now = clock.now.Add(5 * time.Second)
+	if got := o.filter(servers); !reflect.DeepEqual(got, []string{"http://a:6060", "http://c:6060"}) {
+		t.Fatalf("filter() = %v, want a back and b still ejected", got)
+	}
+	clock.now = clock.now.Add(5 * time.Second)
+	o.filter(servers)
+	failN(o, "http://a:6060", 3)
+	if got := o.stats["http://a:6060"].ejectedUntil.Sub(clock.now); got != 20*time.Second {
+		t.Errorf("a ejected for %s, want 20s", got)
+	}
+
+	// stats of a are dropped once its ejections are forgotten
+	clock.now = clock.now.Add(20*time.Second + 30*time.Second + time.Millisecond)
+	o.filter(others)
+	if _, ok := o.stats["http://a:6060"]; ok {
+		t.Error("stats of a should be dropped")
+	}
+}
+
+func TestOutlierDetector_MaxEjectionPercent(t *testing.T) {
+	o, _ := newTestDetector(OutlierConfig{
+		ConsecutiveFailures: 1,
+		BaseEjectionTime:    10 * time.Second,
+		MaxEjectionTime:     30 * time.Second,
+		MaxEjectionPercent:  50,
+	})
+	servers := []string{"http://a:6060", "http://b:6060", "http://c:6060", "http://d:6060"}
+	o.filter(servers)
+	for _, server := range servers {
+		failN(o, server, 1)
+	}
+	if got := o.filter(servers); len(got) != 2 {
+		t.Errorf("filter() = %v, want half of servers ejected", got)
+	}
+}
+
+func TestOutlierDetector_Latency(t *testing.T) {
+	o, _ := newTestDetector(OutlierConfig{
+		ConsecutiveFailures: 2,
+		LatencyThreshold:    time.Second,
+		BaseEjectionTime:    10 * time.Second,
+		MaxEjectionTime:     30 * time.Second,
+		MaxEjectionPercent:  50,
+	})
+	servers := []string{"http://a:6060", "http://b:6060"}
+	o.filter(servers)
+	o.report("http://a:6060", 2*time.Second, nil)
+	o.report("http://a:6060", 2*time.Second, nil)
+	if got := o.filter(servers); !reflect.DeepEqual(got, []string{"http://b:6060"}) {
+		t.Errorf("filter() = %v, want slow server a ejected", got)
+	}
+}
+
+func TestOutlierDetector_Disabled(t *testing.T) {
+	o, _ := newTestDetector(OutlierConfig{MaxEjectionPercent: 100})
+	servers := []string{"http://a:6060", "http://b:6060"}
+	o.filter(servers)
+	failN(o, "http://a:6060", 10)
+	if got := o.filter(servers); !reflect.DeepEqual(got, servers) {
+		t.Errorf("filter() = %v, want %v", got, servers)
+	}
+}
+
+func TestReportResult(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	provider := NewCompositeServiceProvider("usersvc", "USERSVC", nil,
+		WithEndpoints(srv.URL, "http://localhost:1"),
+		WithOutlierDetection(OutlierConfig{
+			ConsecutiveFailures: 2,
+			BaseEjectionTime:    time.Minute,
+			MaxEjectionTime:     time.Minute,
+			MaxEjectionPercent:  50,
+		}))
+	client := NewClient()
+	for i := 0; i < 4; i++ {
+		server, err := provider.SelectServer()
+		if err != nil {
+			t.Fatalf("SelectServer() error = %v", err)
+		}
+		resp, err := client.R().Get(server)
+		ReportResult(provider, server, resp, err)
+	}
+	// both servers failed twice, but only one of them can be ejected
+	seen := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		server, _ := provider.SelectServer()
+		seen[server] = true
+	}
+	if len(seen) != 1 {
+		t.Errorf("SelectServer() returned %v, want only one server left", seen)
+	}
+}
@@ -0,0 +1,22 @@
+package ddhttp
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+// ErrorStatus returns http status code for err returned by service method. It is 400 if the request is canceled,
+// the code of err implementing StatusCode() int such as ddl.ConflictError which is 409, 500 otherwise.
+func ErrorStatus(err error) int {
+	if errors.Is(err, context.Canceled) {
+		return http.StatusBadRequest
+	}
+	var coder interface {
+		StatusCode() int
+	}
+	if errors.As(err, &coder) {
+		return coder.StatusCode()
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,40 @@
+package ddhttp
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/unionj-cloud/go-doudou/ddl"
+	"net/http"
+	"testing"
+)
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "canceled",
+			err:  context.Canceled,
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "conflict",
+			err:  errors.Wrap(ddl.ConflictError{Table: "user", Id: 1, Version: 2}, "failed to update user"),
+			want: http.StatusConflict,
+		},
+		{
+			name: "other",
+			err:  errors.New("boom"),
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorStatus(tt.err); got != tt.want {
+				t.Errorf("ErrorStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
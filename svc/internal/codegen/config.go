@@ -9,6 +9,8 @@ import (
 
 var configTmpl = `package config
 
+import "time"
+
 type Configurator interface {
 	Load()
 	Get() Config
@@ -26,6 +28,13 @@ type DbConfig struct {
 	Passwd  string
 	Schema  string
 	Charset string ` + "`" + `default:"utf8mb4"` + "`" + `
+	// Log decorates db of daos by ddl.NewObservedDB, which logs statements at debug level and records their latency
+	// to prometheus
+	Log bool
+	// SlowThreshold logs statements slower than it at warning level if Log is true, 0 disables it
+	SlowThreshold time.Duration ` + "`" + `default:"1s"` + "`" + `
+	// Redact masks args of statements in logs
+	Redact bool
 }
 `
 
@@ -15,30 +15,71 @@ func TestGenDb(t *testing.T) {
 
 import (
 	"testfilesdb/config"
-	"fmt"
-	_ "github.com/go-sql-driver/mysql"
 	"github.com/iancoleman/strcase"
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
+	"github.com/unionj-cloud/go-doudou/ddl"
+	ddlconfig "github.com/unionj-cloud/go-doudou/ddl/config"
+	"github.com/unionj-cloud/go-doudou/ddl/dialect"
+	_ "github.com/unionj-cloud/go-doudou/ddl/dialect/mysql"
+	// _ "github.com/unionj-cloud/go-doudou/ddl/dialect/postgres"
+	// _ "github.com/unionj-cloud/go-doudou/ddl/dialect/sqlite"
 )
 
 func NewDb(conf config.DbConfig) (*sqlx.DB, error) {
-	conn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=%s",
-		conf.User,
-		conf.Passwd,
-		conf.Host,
-		conf.Port,
-		conf.Schema,
-		conf.Charset)
-	conn += "&loc=Asia%2FShanghai&parseTime=True"
-
-	db, err := sqlx.Connect(conf.Driver, conn)
+	d, err := dialect.Get(conf.Driver)
+	if err != nil {
+		return nil, errors.Wrap(err, "unsupported database driver")
+	}
+	db, err := sqlx.Connect(d.Name(), d.DSN(ddlconfig.DbConfig{
+		Driver:  conf.Driver,
+		Host:    conf.Host,
+		Port:    conf.Port,
+		User:    conf.User,
+		Passwd:  conf.Passwd,
+		Schema:  conf.Schema,
+		Charset: conf.Charset,
+	}))
 	if err != nil {
 		return nil, errors.Wrap(err, "database connection failed")
 	}
 	db.MapperFunc(strcase.ToSnake)
 	return db, nil
 }
+
+// Wrap decorates db by ddl.NewObservedDB if conf.Log is true, e.g. dao.NewUserDao(db.Wrap(conf, &ddl.GddDB{DB: conn}))
+func Wrap(conf config.DbConfig, db ddl.DB) ddl.DB {
+	if !conf.Log {
+		return db
+	}
+	options := []ddl.ObserveOption{ddl.WithSlowThreshold(conf.SlowThreshold)}
+	if conf.Redact {
+		options = append(options, ddl.WithRedact(ddl.RedactArgs))
+	}
+	return ddl.NewObservedDB(db, options...)
+}
+
+// NewClusterDb connects to the primary database and its read replicas. Daos created with the returned db read from
+// healthy replicas by round robin, use ddl.WithPrimary to read from the primary database.
+func NewClusterDb(conf config.DbConfig, replicas ...config.DbConfig) (*ddl.ClusterDB, error) {
+	primary, err := NewDb(conf)
+	if err != nil {
+		return nil, err
+	}
+	var dbs []*sqlx.DB
+	for _, rc := range replicas {
+		db, err := NewDb(rc)
+		if err != nil {
+			primary.Close()
+			for _, item := range dbs {
+				item.Close()
+			}
+			return nil, errors.Wrap(err, "replica connection failed")
+		}
+		dbs = append(dbs, db)
+	}
+	return ddl.NewClusterDB(primary, dbs), nil
+}
 `
 	configfile := dir + "/db/db.go"
 	f, err := os.Open(configfile)
@@ -125,6 +125,7 @@ func (receiver *{{.Meta.Name}}Client) SetClient(client *resty.Client) {
 		}
 		_resp, _err := _req.{{$m.Name | restyMethod}}(_server + "/{{$.Meta.Name | lower}}/{{$m.Name | pattern}}")
 		{{- end }}
+		ddhttp.ReportResult(receiver.provider, _server, _resp, _err)
 		if _err != nil {
 			{{- range $r := $m.Results }}
 				{{- if eq $r.Type "error" }}
@@ -91,6 +91,7 @@ import (
 
 type TestfileshttphandlerHandler interface {
 	PageUsers(w http.ResponseWriter, r *http.Request)
+	PageUsersAfter(w http.ResponseWriter, r *http.Request)
 }
 
 func Routes(handler TestfileshttphandlerHandler) []ddmodel.Route {
@@ -101,6 +102,12 @@ func Routes(handler TestfileshttphandlerHandler) []ddmodel.Route {
 			"/testfileshttphandler/pageusers",
 			handler.PageUsers,
 		},
+		{
+			"PageUsersAfter",
+			"POST",
+			"/testfileshttphandler/pageusersafter",
+			handler.PageUsersAfter,
+		},
 	}
 }
 `
@@ -173,11 +173,7 @@ var appendHttpHandlerImplTmpl = `
 		{{- range $r := $m.Results }}
 			{{- if eq $r.Type "error" }}
 				if {{ $r.Name }} != nil {
-					if {{ $r.Name }} == context.Canceled {
-						http.Error(_writer, {{ $r.Name }}.Error(), http.StatusBadRequest)
-					} else {
-						http.Error(_writer, {{ $r.Name }}.Error(), http.StatusInternalServerError)
-					}
+					http.Error(_writer, {{ $r.Name }}.Error(), ddhttp.ErrorStatus({{ $r.Name }}))
 					return
 				}
 			{{- end }}
@@ -232,6 +228,7 @@ import (
 	"fmt"
 	"github.com/sirupsen/logrus"
 	_cast "github.com/unionj-cloud/go-doudou/cast"
+	ddhttp "github.com/unionj-cloud/go-doudou/svc/http"
 	{{.ServiceAlias}} "{{.ServicePackage}}"
 	"net/http"
 	"{{.VoPackage}}"
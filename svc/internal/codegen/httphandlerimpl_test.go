@@ -59,6 +59,9 @@ type TestfileshandlerImpl12HandlerImpl struct {
 func (receiver *TestfileshandlerImpl12HandlerImpl) PageUsers(w http.ResponseWriter, r *http.Request) {
 	panic("implement me")
 }
+func (receiver *TestfileshandlerImpl12HandlerImpl) PageUsersAfter(w http.ResponseWriter, r *http.Request) {
+	panic("implement me")
+}
 
 func NewTestfileshandlerImpl12Handler(testfileshandlerImpl12 service.TestfileshandlerImpl12) TestfileshandlerImpl12Handler {
 	return &TestfileshandlerImpl12HandlerImpl{
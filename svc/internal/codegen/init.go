@@ -28,6 +28,8 @@ import (
 type {{.SvcName}} interface {
 	// You can define your service methods as your need. Below is an example.
 	PageUsers(ctx context.Context, query vo.PageQuery) (code int, data vo.PageRet, msg error)
+	// Cursor based paging example, pass Next of the result as After to get the next page
+	PageUsersAfter(ctx context.Context, query vo.CursorQuery) (code int, data vo.CursorRet, msg error)
 }
 `
 
@@ -70,6 +72,28 @@ type PageRet struct {
 	HasNext  bool
 }
 
+type CursorPage struct {
+	// 排序规则
+	Orders []Order
+	// 上一页返回的游标，第一页为空
+	After string
+	// 每页行数
+	Size int
+}
+
+// 游标分页筛选条件
+type CursorQuery struct {
+	Filter PageFilter
+	Page   CursorPage
+}
+
+type CursorRet struct {
+	Items interface{}
+	// 下一页的游标，最后一页为空
+	Next    string
+	HasNext bool
+}
+
 type UserVo struct {
 	Id    int
 	Name  string
@@ -107,6 +131,12 @@ DB_PASSWD=1234
 DB_SCHEMA=test
 DB_CHARSET=utf8mb4
 DB_DRIVER=mysql
+# if true, statements run by daos created with db.Wrap are logged at debug level and their latency is recorded to prometheus
+DB_LOG=false
+# statements slower than it are logged at warning level if DB_LOG is true
+DB_SLOWTHRESHOLD=1s
+# if true, args of statements are masked in logs
+DB_REDACT=false
 
 GDD_WRITETIMEOUT=15s
 GDD_READTIMEOUT=15s
@@ -127,7 +157,12 @@ GDD_NAME={{.SvcName}}
 GDD_PORT=6060
 GDD_MEM_PORT=
 GDD_BASE_URL=
+# comma separated seed addresses, e.g. 192.168.101.6:52634,dns+seed.example.com:7946,dnssrv+_gossip._tcp.example.com
 GDD_SEED=192.168.101.6:52634
+# memberlist config profile, accept 'lan', 'wan' or 'local'
+GDD_MEM_PROFILE=wan
+# comma separated base64 encoded AES keys for encrypting gossip messages, the first one is the primary key
+GDD_MEM_KEY=
 # accept 'mono' for monolith mode or 'micro' for microservice mode
 GDD_MODE=micro`
 
@@ -30,6 +30,9 @@ type TestfilessvcimplImpl struct {
 func (receiver *TestfilessvcimplImpl) PageUsers(ctx context.Context, query vo.PageQuery) (code int, data vo.PageRet, msg error) {
 	panic("implement me")
 }
+func (receiver *TestfilessvcimplImpl) PageUsersAfter(ctx context.Context, query vo.CursorQuery) (code int, data vo.CursorRet, msg error) {
+	panic("implement me")
+}
 
 func NewTestfilessvcimpl(conf config.Config, db *sqlx.DB) Testfilessvcimpl {
 	return &TestfilessvcimplImpl{
@@ -0,0 +1,75 @@
+package registry
+
+import (
+	"encoding/base64"
+	"github.com/hashicorp/memberlist"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/unionj-cloud/go-doudou/cast"
+	"github.com/unionj-cloud/go-doudou/stringutils"
+	"github.com/unionj-cloud/go-doudou/svc/config"
+	"strings"
+)
+
+// newConf creates memberlist config from the profile set by GDD_MEM_PROFILE,
+// then overrides failure detection params and sets up keyring from env variables
+func newConf() (*memberlist.Config, error) {
+	var mconf *memberlist.Config
+	switch profile := config.GddMemProfile.Load(); profile {
+	case "lan":
+		mconf = memberlist.DefaultLANConfig()
+	case "local":
+		mconf = memberlist.DefaultLocalConfig()
+	case "", "wan":
+		mconf = memberlist.DefaultWANConfig()
+	default:
+		logrus.Warnf("Unknown %s %s, use default wan instead.\n", config.GddMemProfile, profile)
+		mconf = memberlist.DefaultWANConfig()
+	}
+	mconf.ProbeInterval = loadDuration(config.GddMemProbeInterval.String(), config.GddMemProbeInterval.Load(), mconf.ProbeInterval)
+	mconf.ProbeTimeout = loadDuration(config.GddMemProbeTimeout.String(), config.GddMemProbeTimeout.Load(), mconf.ProbeTimeout)
+	if mult := cast.ToInt(config.GddMemSuspicionMult.Load()); mult > 0 {
+		mconf.SuspicionMult = mult
+	}
+	keyring, err := newKeyring(config.GddMemKey.Load())
+	if err != nil {
+		return nil, err
+	}
+	mconf.Keyring = keyring
+	return mconf, nil
+}
+
+// newKeyring returns nil keyring if no key provided, which means gossip messages are not encrypted
+func newKeyring(keys string) (*memberlist.Keyring, error) {
+	var decoded [][]byte
+	for _, key := range strings.Split(keys, ",") {
+		key = strings.TrimSpace(key)
+		if stringutils.IsEmpty(key) {
+			continue
+		}
+		raw, err := decodeKey(key)
+		if err != nil {
+			return nil, err
+		}
+		decoded = append(decoded, raw)
+	}
+	if len(decoded) == 0 {
+		return nil, nil
+	}
+	keyring, err := memberlist.NewKeyring(decoded, decoded[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create keyring")
+	}
+	return keyring, nil
+}
+
+func decodeKey(key string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "key is not base64 encoded")
+	}
+	if err = memberlist.ValidateKey(raw); err != nil {
+		return nil, errors.Wrap(err, "invalid key")
+	}
+	return raw, nil
+}
@@ -0,0 +1,119 @@
+package registry
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/unionj-cloud/go-doudou/svc/config"
+)
+
+func TestNewConf(t *testing.T) {
+	tests := []struct {
+		name              string
+		env               map[string]string
+		wantProbeInterval time.Duration
+		wantProbeTimeout  time.Duration
+		wantSuspicionMult int
+		wantKeyring       bool
+		wantErr           bool
+	}{
+		{
+			name:              "default wan",
+			env:               map[string]string{},
+			wantProbeInterval: 5 * time.Second,
+			wantProbeTimeout:  3 * time.Second,
+			wantSuspicionMult: 6,
+		},
+		{
+			name: "lan",
+			env: map[string]string{
+				config.GddMemProfile.String(): "lan",
+			},
+			wantProbeInterval: time.Second,
+			wantProbeTimeout:  500 * time.Millisecond,
+			wantSuspicionMult: 4,
+		},
+		{
+			name: "local with overrides",
+			env: map[string]string{
+				config.GddMemProfile.String():       "local",
+				config.GddMemProbeInterval.String(): "2s",
+				config.GddMemProbeTimeout.String():  "300ms",
+				config.GddMemSuspicionMult.String(): "5",
+			},
+			wantProbeInterval: 2 * time.Second,
+			wantProbeTimeout:  300 * time.Millisecond,
+			wantSuspicionMult: 5,
+		},
+		{
+			name: "invalid overrides",
+			env: map[string]string{
+				config.GddMemProfile.String():       "unknown",
+				config.GddMemProbeInterval.String(): "abc",
+			},
+			wantProbeInterval: 5 * time.Second,
+			wantProbeTimeout:  3 * time.Second,
+			wantSuspicionMult: 6,
+		},
+		{
+			name: "keyring",
+			env: map[string]string{
+				config.GddMemKey.String(): "T9jncgl9mbLus+baTTa7q7nPSUrXwbDi2dhbtqir37s=,gePi6zB9YeaiBdM6PWySXA==",
+			},
+			wantProbeInterval: 5 * time.Second,
+			wantProbeTimeout:  3 * time.Second,
+			wantSuspicionMult: 6,
+			wantKeyring:       true,
+		},
+		{
+			name: "invalid key length",
+			env: map[string]string{
+				config.GddMemKey.String(): "YWJj",
+			},
+			wantErr: true,
+		},
+		{
+			name: "not base64",
+			env: map[string]string{
+				config.GddMemKey.String(): "%%%",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+			}
+			defer func() {
+				for k := range tt.env {
+					os.Unsetenv(k)
+				}
+			}()
+			got, err := newConf()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newConf() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.ProbeInterval != tt.wantProbeInterval {
+				t.Errorf("ProbeInterval = %s, want %s", got.ProbeInterval, tt.wantProbeInterval)
+			}
+			if got.ProbeTimeout != tt.wantProbeTimeout {
+				t.Errorf("ProbeTimeout = %s, want %s", got.ProbeTimeout, tt.wantProbeTimeout)
+			}
+			if got.SuspicionMult != tt.wantSuspicionMult {
+				t.Errorf("SuspicionMult = %d, want %d", got.SuspicionMult, tt.wantSuspicionMult)
+			}
+			if (got.Keyring != nil) != tt.wantKeyring {
+				t.Errorf("Keyring = %v, wantKeyring %v", got.Keyring, tt.wantKeyring)
+			}
+			if tt.wantKeyring && len(got.Keyring.GetKeys()) != 2 {
+				t.Errorf("len(Keyring.GetKeys()) = %d, want 2", len(got.Keyring.GetKeys()))
+			}
+		})
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"github.com/hashicorp/memberlist"
 	"github.com/sirupsen/logrus"
-	"github.com/unionj-cloud/go-doudou/sliceutils"
+	"time"
 )
 
 type eventDelegate struct {
@@ -20,7 +20,11 @@ func (e eventDelegate) NotifyJoin(node *memberlist.Node) {
 		logrus.Errorln(fmt.Sprintf("%+v", err))
 		return
 	}
-	e.local.registry.members = append(e.local.registry.members, node)
+	r := e.local.registry
+	r.memberLock.Lock()
+	delete(r.left, node.Name)
+	r.members = append(removeMember(r.members, node.Name), node)
+	r.memberLock.Unlock()
 	logrus.Infof("Node %s joined, supplying %s service", node.String(), mm.Meta.Service)
 }
 
@@ -33,8 +37,25 @@ func (e eventDelegate) NotifyLeave(node *memberlist.Node) {
 		logrus.Errorln(fmt.Sprintf("%+v", err))
 		return
 	}
-	index, _ := sliceutils.IndexOfAny(node, e.local.registry.members)
-	e.local.registry.members = append(e.local.registry.members[:index], e.local.registry.members[index+1:]...)
+	// memberlist notifies both nodes left gracefully and nodes declared dead by failure detection
+	state := Left
+	if node.State == memberlist.StateDead {
+		state = Dead
+	}
+	r := e.local.registry
+	r.memberLock.Lock()
+	r.members = removeMember(r.members, node.Name)
+	r.left[node.Name] = leftMember{
+		node:   node,
+		state:  state,
+		leftAt: time.Now(),
+	}
+	r.pruneLeft()
+	r.memberLock.Unlock()
+	if state == Dead {
+		logrus.Warnf("Node %s is dead, supplying %s service", node.FullAddress(), mm.Meta.Service)
+		return
+	}
 	logrus.Infof("Node %s left, supplying %s service", node.FullAddress(), mm.Meta.Service)
 }
 
@@ -47,7 +68,18 @@ func (e eventDelegate) NotifyUpdate(node *memberlist.Node) {
 		logrus.Errorln(fmt.Sprintf("%+v", err))
 		return
 	}
-	index, _ := sliceutils.IndexOfAny(node, e.local.registry.members)
-	e.local.registry.members[index] = node
+	r := e.local.registry
+	r.memberLock.Lock()
+	r.members = append(removeMember(r.members, node.Name), node)
+	r.memberLock.Unlock()
 	logrus.Infof("Node %s updated, supplying %s service", node.FullAddress(), mm.Meta.Service)
 }
+
+func removeMember(members []*memberlist.Node, name string) []*memberlist.Node {
+	for i, member := range members {
+		if member.Name == name {
+			return append(members[:i], members[i+1:]...)
+		}
+	}
+	return members
+}
@@ -0,0 +1,57 @@
+package registry
+
+import (
+	"github.com/unionj-cloud/go-doudou/svc/http/model"
+	"net/http"
+)
+
+type RegistryHandler interface {
+	GetMembers(w http.ResponseWriter, r *http.Request)
+	GetServices(w http.ResponseWriter, r *http.Request)
+	GetKeys(w http.ResponseWriter, r *http.Request)
+	InstallKey(w http.ResponseWriter, r *http.Request)
+	UseKey(w http.ResponseWriter, r *http.Request)
+	RemoveKey(w http.ResponseWriter, r *http.Request)
+}
+
+func Routes() []model.Route {
+	handler := NewRegistryHandler()
+	return []model.Route{
+		{
+			Name:        "GetMembers",
+			Method:      "GET",
+			Pattern:     "/go-doudou/registry/members",
+			HandlerFunc: handler.GetMembers,
+		},
+		{
+			Name:        "GetServices",
+			Method:      "GET",
+			Pattern:     "/go-doudou/registry/services",
+			HandlerFunc: handler.GetServices,
+		},
+		{
+			Name:        "GetKeys",
+			Method:      "GET",
+			Pattern:     "/go-doudou/registry/keys",
+			HandlerFunc: handler.GetKeys,
+		},
+		{
+			Name:        "InstallKey",
+			Method:      "POST",
+			Pattern:     "/go-doudou/registry/keys/install",
+			HandlerFunc: handler.InstallKey,
+		},
+		{
+			Name:        "UseKey",
+			Method:      "POST",
+			Pattern:     "/go-doudou/registry/keys/use",
+			HandlerFunc: handler.UseKey,
+		},
+		{
+			Name:        "RemoveKey",
+			Method:      "POST",
+			Pattern:     "/go-doudou/registry/keys/remove",
+			HandlerFunc: handler.RemoveKey,
+		},
+	}
+}
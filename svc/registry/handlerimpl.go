@@ -0,0 +1,120 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/memberlist"
+	"net/http"
+)
+
+type keyReq struct {
+	// base64 encoded key
+	Key string `json:"key"`
+}
+
+type keysRet struct {
+	// fingerprint of primary key
+	Primary string `json:"primary"`
+	// fingerprints of all installed keys
+	Keys []string `json:"keys"`
+}
+
+// fingerprint identifies a key without exposing it
+func fingerprint(key []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(key))[:16]
+}
+
+type RegistryHandlerImpl struct {
+}
+
+func (receiver *RegistryHandlerImpl) local(_writer http.ResponseWriter) *Node {
+	if localNode == nil {
+		http.Error(_writer, "registry is not enabled", http.StatusNotFound)
+	}
+	return localNode
+}
+
+// GetMembers lists all members local node knows, including members left or dead within an hour
+func (receiver *RegistryHandlerImpl) GetMembers(_writer http.ResponseWriter, _req *http.Request) {
+	node := receiver.local(_writer)
+	if node == nil {
+		return
+	}
+	json.NewEncoder(_writer).Encode(node.Members())
+}
+
+// GetServices lists base urls of alive members grouped by service name
+func (receiver *RegistryHandlerImpl) GetServices(_writer http.ResponseWriter, _req *http.Request) {
+	node := receiver.local(_writer)
+	if node == nil {
+		return
+	}
+	json.NewEncoder(_writer).Encode(node.Services())
+}
+
+func (receiver *RegistryHandlerImpl) keyring(_writer http.ResponseWriter) *memberlist.Keyring {
+	if receiver.local(_writer) == nil {
+		return nil
+	}
+	if localNode.memberConf.Keyring == nil {
+		http.Error(_writer, "gossip encryption is not enabled, set GDD_MEM_KEY to enable it", http.StatusBadRequest)
+		return nil
+	}
+	return localNode.memberConf.Keyring
+}
+
+func (receiver *RegistryHandlerImpl) GetKeys(_writer http.ResponseWriter, _req *http.Request) {
+	keyring := receiver.keyring(_writer)
+	if keyring == nil {
+		return
+	}
+	ret := keysRet{
+		Primary: fingerprint(keyring.GetPrimaryKey()),
+	}
+	for _, key := range keyring.GetKeys() {
+		ret.Keys = append(ret.Keys, fingerprint(key))
+	}
+	json.NewEncoder(_writer).Encode(ret)
+}
+
+func (receiver *RegistryHandlerImpl) modifyKey(_writer http.ResponseWriter, _req *http.Request, fn func(*memberlist.Keyring, []byte) error) {
+	keyring := receiver.keyring(_writer)
+	if keyring == nil {
+		return
+	}
+	var req keyReq
+	if err := json.NewDecoder(_req.Body).Decode(&req); err != nil {
+		http.Error(_writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key, err := decodeKey(req.Key)
+	if err != nil {
+		http.Error(_writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err = fn(keyring, key); err != nil {
+		http.Error(_writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+	receiver.GetKeys(_writer, _req)
+}
+
+// InstallKey adds a key to keyring of local node, it can be used for decrypting messages since then.
+func (receiver *RegistryHandlerImpl) InstallKey(_writer http.ResponseWriter, _req *http.Request) {
+	receiver.modifyKey(_writer, _req, (*memberlist.Keyring).AddKey)
+}
+
+// UseKey changes primary key of local node. The key must have been installed.
+func (receiver *RegistryHandlerImpl) UseKey(_writer http.ResponseWriter, _req *http.Request) {
+	receiver.modifyKey(_writer, _req, (*memberlist.Keyring).UseKey)
+}
+
+// RemoveKey removes a key from keyring of local node. Primary key cannot be removed.
+func (receiver *RegistryHandlerImpl) RemoveKey(_writer http.ResponseWriter, _req *http.Request) {
+	receiver.modifyKey(_writer, _req, (*memberlist.Keyring).RemoveKey)
+}
+
+func NewRegistryHandler() RegistryHandler {
+	return &RegistryHandlerImpl{}
+}
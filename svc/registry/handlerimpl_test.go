@@ -0,0 +1,62 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/unionj-cloud/go-doudou/svc/config"
+)
+
+func TestRegistryHandlerImpl_KeyRotation(t *testing.T) {
+	oldKey := "T9jncgl9mbLus+baTTa7q7nPSUrXwbDi2dhbtqir37s="
+	newKey := "gePi6zB9YeaiBdM6PWySXA=="
+	os.Setenv(config.GddMemKey.String(), oldKey)
+	defer os.Unsetenv(config.GddMemKey.String())
+	node, err := newTestNode("keyring", 0, "")
+	if err != nil {
+		t.Fatalf("NewNode() error = %+v", err)
+	}
+	defer node.Shutdown()
+
+	handler := NewRegistryHandler()
+	call := func(fn http.HandlerFunc, key string) (int, keysRet) {
+		var body bytes.Buffer
+		json.NewEncoder(&body).Encode(keyReq{Key: key})
+		w := httptest.NewRecorder()
+		fn(w, httptest.NewRequest(http.MethodPost, "/", &body))
+		var ret keysRet
+		json.Unmarshal(w.Body.Bytes(), &ret)
+		return w.Code, ret
+	}
+	fp := func(key string) string {
+		raw, _ := base64.StdEncoding.DecodeString(key)
+		return fingerprint(raw)
+	}
+
+	if code, _ := call(handler.UseKey, newKey); code != http.StatusBadRequest {
+		t.Errorf("UseKey() before InstallKey() code = %d, want %d", code, http.StatusBadRequest)
+	}
+	code, ret := call(handler.InstallKey, newKey)
+	if code != http.StatusOK || len(ret.Keys) != 2 || ret.Primary != fp(oldKey) {
+		t.Errorf("InstallKey() code = %d, ret = %+v", code, ret)
+	}
+	code, ret = call(handler.UseKey, newKey)
+	if code != http.StatusOK || ret.Primary != fp(newKey) {
+		t.Errorf("UseKey() code = %d, ret = %+v", code, ret)
+	}
+	if code, _ = call(handler.RemoveKey, newKey); code != http.StatusBadRequest {
+		t.Errorf("RemoveKey() primary key code = %d, want %d", code, http.StatusBadRequest)
+	}
+	code, ret = call(handler.RemoveKey, oldKey)
+	if code != http.StatusOK || len(ret.Keys) != 1 || ret.Keys[0] != fp(newKey) {
+		t.Errorf("RemoveKey() code = %d, ret = %+v", code, ret)
+	}
+	if code, _ = call(handler.InstallKey, "YWJj"); code != http.StatusBadRequest {
+		t.Errorf("InstallKey() invalid key code = %d, want %d", code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,98 @@
+package registry
+
+import (
+	"github.com/hashicorp/memberlist"
+	"github.com/sirupsen/logrus"
+	"sort"
+	"time"
+)
+
+// leftRetention is how long a left or dead node is still listed in Members
+const leftRetention = time.Hour
+
+type leftMember struct {
+	node *memberlist.Node
+	// state is Left if the node left cleanly, Dead if memberlist declared it dead
+	state  NodeState
+	leftAt time.Time
+}
+
+// pruneLeft must be called with memberLock held
+func (r *registry) pruneLeft() {
+	for name, lm := range r.left {
+		if time.Since(lm.leftAt) > leftRetention {
+			delete(r.left, name)
+		}
+	}
+}
+
+// Member is what local node believes about a member of the cluster
+type Member struct {
+	Name    string      `json:"name"`
+	Addr    string      `json:"addr"`
+	Port    uint16      `json:"port"`
+	State   string      `json:"state"`
+	Service string      `json:"service"`
+	BaseUrl string      `json:"baseUrl"`
+	Data    interface{} `json:"data,omitempty"`
+	// Local is true if the member is the node itself
+	Local bool `json:"local"`
+}
+
+func newMember(node *memberlist.Node, state NodeState) Member {
+	member := Member{
+		Name:  node.Name,
+		Addr:  node.Addr.String(),
+		Port:  node.Port,
+		State: state.String(),
+	}
+	mm, err := newMeta(node)
+	if err != nil {
+		logrus.Warnf("%+v", err)
+		return member
+	}
+	n := Node{
+		mmeta:      mm,
+		memberNode: node,
+	}
+	member.Service = mm.Meta.Service
+	if mm.Meta.Port > 0 {
+		member.BaseUrl = n.BaseUrl()
+	}
+	member.Data = mm.Data
+	return member
+}
+
+// Members returns current members, alive or suspect, and members left or dead within an hour, sorted by service and name
+func (n *Node) Members() []Member {
+	var members []Member
+	n.memberLock.RLock()
+	for _, node := range n.members {
+		member := newMember(node, nodeState(node.State))
+		member.Local = node.Name == n.memberNode.Name
+		members = append(members, member)
+	}
+	for _, lm := range n.left {
+		members = append(members, newMember(lm.node, lm.state))
+	}
+	n.memberLock.RUnlock()
+	sort.SliceStable(members, func(i, j int) bool {
+		if members[i].Service != members[j].Service {
+			return members[i].Service < members[j].Service
+		}
+		return members[i].Name < members[j].Name
+	})
+	return members
+}
+
+// Services returns base urls of alive members grouped by service name
+func (n *Node) Services() map[string][]string {
+	services := make(map[string][]string)
+	for _, member := range n.Members() {
+		if member.State != Alive.String() || member.Service == "" {
+			continue
+		}
+		services[member.Service] = append(services[member.Service], member.BaseUrl)
+	}
+	return services
+}
@@ -0,0 +1,152 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+	"github.com/unionj-cloud/go-doudou/svc/config"
+)
+
+func TestNode_Members(t *testing.T) {
+	seed, err := newTestNode("seed", 0, "")
+	if err != nil {
+		t.Fatalf("NewNode() error = %+v", err)
+	}
+	defer seed.Shutdown()
+	seedAddr := fmt.Sprintf("127.0.0.1:%d", seed.memberNode.Port)
+
+	os.Setenv(config.GddPort.String(), "6060")
+	defer os.Unsetenv(config.GddPort.String())
+	node, err := newTestNode("node", 0, seedAddr)
+	if err != nil {
+		t.Fatalf("NewNode() error = %+v", err)
+	}
+
+	observer, err := NewObserver()
+	if err != nil {
+		t.Fatalf("NewObserver() error = %+v", err)
+	}
+	defer observer.Shutdown()
+
+	members := observer.Members()
+	if len(members) != 3 {
+		t.Fatalf("len(Members()) = %d, want 3", len(members))
+	}
+	if !members[0].Local || members[0].Service != "" {
+		t.Errorf("Members()[0] = %+v, want local observer", members[0])
+	}
+	if members[1].Name != "node" || members[1].Service != "testsvc" || members[1].State != "alive" ||
+		members[1].BaseUrl != fmt.Sprintf("http://%s", net.JoinHostPort(members[1].Addr, "6060")) {
+		t.Errorf("Members()[1] = %+v", members[1])
+	}
+	if got := observer.Services()["testsvc"]; len(got) != 2 {
+		t.Errorf("Services() = %v, want 2 testsvc base urls", got)
+	}
+
+	node.Shutdown()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		members = observer.Members()
+		if len(members) == 3 && members[1].State == "left" {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if members[1].Name != "node" || members[1].State != "left" {
+		t.Errorf("Members()[1] = %+v, want left node", members[1])
+	}
+	if got := observer.Services()["testsvc"]; len(got) != 1 {
+		t.Errorf("Services() = %v, want 1 testsvc base url", got)
+	}
+}
+
+func TestEventDelegate_NotifyLeave(t *testing.T) {
+	tests := []struct {
+		name  string
+		state memberlist.NodeStateType
+		want  string
+	}{
+		{"left", memberlist.StateLeft, "left"},
+		{"dead", memberlist.StateDead, "dead"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local := &Node{
+				registry:   &registry{left: make(map[string]leftMember)},
+				memberNode: &memberlist.Node{Name: "local"},
+			}
+			eventDelegate{local: local}.NotifyLeave(&memberlist.Node{
+				Name:  "node",
+				Addr:  net.ParseIP("127.0.0.1"),
+				Port:  7946,
+				Meta:  []byte(`{"_meta":{"service":"testsvc"}}`),
+				State: tt.state,
+			})
+			members := local.Members()
+			if len(members) != 1 || members[0].Name != "node" || members[0].State != tt.want {
+				t.Errorf("Members() = %+v, want node in %s state", members, tt.want)
+			}
+			if got := local.Services(); len(got) != 0 {
+				t.Errorf("Services() = %v, want none", got)
+			}
+		})
+	}
+}
+
+func TestNode_Members_State(t *testing.T) {
+	tests := []struct {
+		name  string
+		state memberlist.NodeStateType
+		want  string
+	}{
+		{"alive", memberlist.StateAlive, "alive"},
+		{"suspect", memberlist.StateSuspect, "suspect"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local := &Node{
+				registry: &registry{members: []*memberlist.Node{{
+					Name:  "node",
+					Addr:  net.ParseIP("127.0.0.1"),
+					Port:  7946,
+					Meta:  []byte(`{"_meta":{"service":"testsvc","port":6060}}`),
+					State: tt.state,
+				}}},
+				memberNode: &memberlist.Node{Name: "local"},
+			}
+			members := local.Members()
+			if len(members) != 1 || members[0].State != tt.want {
+				t.Errorf("Members() = %+v, want node in %s state", members, tt.want)
+			}
+			// suspect member is listed but not used as a service endpoint
+			if got := local.Services()["testsvc"]; (len(got) == 1) != (tt.state == memberlist.StateAlive) {
+				t.Errorf("Services() = %v", local.Services())
+			}
+		})
+	}
+}
+
+func TestRegistryHandlerImpl_GetMembers(t *testing.T) {
+	node, err := newTestNode("members", 0, "")
+	if err != nil {
+		t.Fatalf("NewNode() error = %+v", err)
+	}
+	defer node.Shutdown()
+
+	w := httptest.NewRecorder()
+	NewRegistryHandler().GetMembers(w, httptest.NewRequest(http.MethodGet, "/go-doudou/registry/members", nil))
+	var members []Member
+	if err = json.Unmarshal(w.Body.Bytes(), &members); err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 1 || members[0].Name != "members" || !members[0].Local || members[0].Service != "testsvc" {
+		t.Errorf("GetMembers() = %+v", members)
+	}
+}
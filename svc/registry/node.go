@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/hashicorp/memberlist"
@@ -10,7 +11,9 @@ import (
 	"github.com/unionj-cloud/go-doudou/stringutils"
 	"github.com/unionj-cloud/go-doudou/svc/config"
 	"net"
+	"os"
 	"sync"
+	"time"
 )
 
 type IRegistry interface {
@@ -25,30 +28,72 @@ type registry struct {
 	lock       sync.Mutex
 	memberLock sync.RWMutex
 	members    []*memberlist.Node
+	left       map[string]leftMember
+	resolver   seedResolver
+	seeds      []string
+	stop       chan struct{}
+	stopOnce   sync.Once
 }
 
 func (r *registry) Register() error {
 	if r.memberlist == nil {
 		return errors.New("Memberlist is nil")
 	}
-	seed := config.GddSeed.Load()
-	if stringutils.IsEmpty(seed) {
+	r.seeds = parseSeeds(config.GddSeed.Load())
+	if len(r.seeds) == 0 {
 		logrus.Warnln("No seed found")
 		return nil
 	}
-	_, err := r.memberlist.Join([]string{seed})
-	if err != nil {
+	attempts := cast.ToInt(config.GddMemJoinRetry.Load())
+	if attempts <= 0 {
+		attempts = defaultJoinRetry
+	}
+	backoff := loadDuration(config.GddMemJoinBackoff.String(), config.GddMemJoinBackoff.Load(), defaultJoinBackoff)
+	if _, err := joinWithRetry(r.join, attempts, backoff); err != nil {
 		return errors.Wrap(err, "Failed to join cluster")
 	}
 	logrus.Infof("Node %s joined cluster successfully", r.memberlist.LocalNode().FullAddress())
 	return nil
 }
 
+func (r *registry) join() (int, error) {
+	addrs, err := resolveSeeds(context.Background(), r.resolver, r.seeds)
+	if err != nil {
+		return 0, err
+	}
+	return r.memberlist.Join(addrs)
+}
+
+// rejoin tries to join seeds again periodically when local node finds itself isolated,
+// e.g. all the other nodes restarted, so that the cluster won't be partitioned forever
+func (r *registry) rejoin(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			if r.memberlist.NumMembers() > 1 {
+				continue
+			}
+			logrus.Warnln("Local node is isolated, try to rejoin seeds")
+			if _, err := r.join(); err != nil {
+				logrus.Warnf("Failed to rejoin cluster: %s", err)
+				continue
+			}
+			logrus.Infof("Node %s rejoined cluster successfully", r.memberlist.LocalNode().FullAddress())
+		}
+	}
+}
+
 func (r *registry) Discover(svc string) ([]*Node, error) {
 	if r.memberlist == nil {
 		return nil, errors.New("Memberlist is nil")
 	}
 	var nodes []*Node
+	r.memberLock.RLock()
+	defer r.memberLock.RUnlock()
 	for _, member := range r.members {
 		logrus.Infof("Member: %s %s\n", member.Name, member.Addr)
 		if member.State == memberlist.StateAlive {
@@ -91,8 +136,26 @@ const (
 	Leaving
 	Left
 	Shutdown
+	// Dead is the state of a member which failed without leaving, e.g. crashed or unreachable
+	Dead
+	// Suspect is the state of a member which failed to respond to probes and may be declared dead soon
+	Suspect
 )
 
+// nodeState converts memberlist state of a member to NodeState
+func nodeState(state memberlist.NodeStateType) NodeState {
+	switch state {
+	case memberlist.StateSuspect:
+		return Suspect
+	case memberlist.StateDead:
+		return Dead
+	case memberlist.StateLeft:
+		return Left
+	default:
+		return Alive
+	}
+}
+
 func (s NodeState) String() string {
 	switch s {
 	case Alive:
@@ -103,6 +166,10 @@ func (s NodeState) String() string {
 		return "left"
 	case Shutdown:
 		return "shutdown"
+	case Dead:
+		return "dead"
+	case Suspect:
+		return "suspect"
 	default:
 		return "unknown"
 	}
@@ -122,6 +189,9 @@ type Node struct {
 	remote bool
 }
 
+// localNode is the node created by NewNode, used by management api handlers
+var localNode *Node
+
 type NodeOption func(*Node)
 
 func WithData(data interface{}) NodeOption {
@@ -132,6 +202,18 @@ func WithData(data interface{}) NodeOption {
 	}
 }
 
+func loadDuration(env, val string, defaultVal time.Duration) time.Duration {
+	if stringutils.IsEmpty(val) {
+		return defaultVal
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		logrus.Warnf("Parse %s %s as time.Duration failed: %s, use default %s instead.\n", env, val, err.Error(), defaultVal)
+		return defaultVal
+	}
+	return d
+}
+
 // Borrow source code from https://github.com/phayes/freeport/blob/master/freeport.go
 // GetFreePort asks the kernel for a free open port that is ready to use.
 func getFreePort() (int, error) {
@@ -148,8 +230,43 @@ func getFreePort() (int, error) {
 	return l.Addr().(*net.TCPAddr).Port, nil
 }
 
+func newRegistry(mconf *memberlist.Config) *registry {
+	return &registry{
+		memberConf: mconf,
+		resolver:   net.DefaultResolver,
+		stop:       make(chan struct{}),
+		left:       make(map[string]leftMember),
+	}
+}
+
+// start creates memberlist and joins the cluster
+func (n *Node) start() error {
+	mconf := n.memberConf
+	mconf.Delegate = &delegate{n}
+	mconf.Events = &eventDelegate{n}
+	list, err := memberlist.Create(mconf)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create memberlist")
+	}
+	n.registry.memberlist = list
+	n.registry.broadcasts = &memberlist.TransmitLimitedQueue{
+		NumNodes:       n.NumNodes,
+		RetransmitMult: mconf.RetransmitMult,
+	}
+	if err = n.Register(); err != nil {
+		n.registry.memberlist.Shutdown()
+		return errors.Wrap(err, "Node register failed")
+	}
+	n.state = Alive
+	n.memberNode = list.LocalNode()
+	return nil
+}
+
 func NewNode(opts ...NodeOption) (*Node, error) {
-	mconf := memberlist.DefaultWANConfig()
+	mconf, err := newConf()
+	if err != nil {
+		return nil, errors.Wrap(err, "NewNode() error: Failed to create memberlist config")
+	}
 	memport := cast.ToInt(config.GddMemPort.Load())
 	if memport == 0 {
 		memport, _ = getFreePort()
@@ -167,10 +284,8 @@ func NewNode(opts ...NodeOption) (*Node, error) {
 		return nil, errors.New(fmt.Sprintf("NewNode() error: No env variable %s found", config.GddName))
 	}
 	node := &Node{
-		state: -1,
-		registry: &registry{
-			memberConf: mconf,
-		},
+		state:    -1,
+		registry: newRegistry(mconf),
 	}
 	for _, opt := range opts {
 		opt(node)
@@ -185,39 +300,64 @@ func NewNode(opts ...NodeOption) (*Node, error) {
 		Port:    port,
 		BaseUrl: baseUrl,
 	}
-	mconf.Delegate = &delegate{node}
-	mconf.Events = &eventDelegate{node}
-	list, err := memberlist.Create(mconf)
+	if err = node.start(); err != nil {
+		return nil, errors.Wrap(err, "NewNode() error")
+	}
+	localNode = node
+	if len(node.seeds) > 0 {
+		go node.rejoin(loadDuration(config.GddMemRejoinInterval.String(), config.GddMemRejoinInterval.Load(), defaultRejoinInterval))
+	}
+	return node, nil
+}
+
+// NewObserver joins the cluster from GDD_SEED as a lightweight node which provides no service,
+// only for inspecting the cluster. Memberlist profile and keys are loaded from env variables as NewNode does.
+// Remember to call Shutdown to leave the cluster when done.
+func NewObserver() (*Node, error) {
+	mconf, err := newConf()
 	if err != nil {
-		return nil, errors.Wrap(err, "NewNode() error: Failed to create memberlist")
+		return nil, errors.Wrap(err, "NewObserver() error: Failed to create memberlist config")
 	}
-	node.registry.memberlist = list
-	node.registry.broadcasts = &memberlist.TransmitLimitedQueue{
-		NumNodes:       node.NumNodes,
-		RetransmitMult: mconf.RetransmitMult,
+	memport, _ := getFreePort()
+	if memport > 0 {
+		mconf.BindPort = memport
+		mconf.AdvertisePort = memport
+	}
+	mconf.Name = fmt.Sprintf("%s-observer-%d", mconf.Name, os.Getpid())
+	node := &Node{
+		state:    -1,
+		registry: newRegistry(mconf),
 	}
-	if err = node.Register(); err != nil {
-		node.registry.memberlist.Shutdown()
-		return nil, errors.Wrap(err, "NewNode() error: Node register failed")
+	if err = node.start(); err != nil {
+		return nil, errors.Wrap(err, "NewObserver() error")
 	}
-	node.state = Alive
-	node.memberNode = list.LocalNode()
 	return node, nil
 }
 
-func (n *Node) NumNodes() (numNodes int) {
-	n.memberLock.RLock()
-	numNodes = len(n.memberlist.Members())
-	n.memberLock.RUnlock()
+// Shutdown stops rejoining seeds, leaves the cluster gracefully and shuts down memberlist
+func (n *Node) Shutdown() error {
+	n.stopOnce.Do(func() {
+		close(n.stop)
+	})
+	if err := n.memberlist.Leave(5 * time.Second); err != nil {
+		logrus.Warnf("Node %s failed to leave cluster gracefully: %s", n.memberNode.Name, err)
+	}
+	if err := n.memberlist.Shutdown(); err != nil {
+		return errors.Wrap(err, "Shutdown() error")
+	}
+	n.state = Shutdown
+	return nil
+}
 
-	return numNodes
+func (n *Node) NumNodes() (numNodes int) {
+	return len(n.memberlist.Members())
 }
 
 func (n *Node) BaseUrl() string {
 	if stringutils.IsNotEmpty(n.mmeta.Meta.BaseUrl) {
 		return n.mmeta.Meta.BaseUrl
 	}
-	return fmt.Sprintf("http://%s:%d", n.memberNode.Addr.String(), n.mmeta.Meta.Port)
+	return fmt.Sprintf("http://%s", net.JoinHostPort(n.memberNode.Addr.String(), fmt.Sprint(n.mmeta.Meta.Port)))
 }
 
 func (n *Node) String() string {
@@ -0,0 +1,108 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/unionj-cloud/go-doudou/svc/config"
+)
+
+func newTestNode(name string, memport int, seed string) (*Node, error) {
+	os.Setenv(config.GddName.String(), "testsvc")
+	os.Setenv(config.GddHostname.String(), name)
+	os.Setenv(config.GddMemPort.String(), fmt.Sprint(memport))
+	os.Setenv(config.GddSeed.String(), seed)
+	os.Setenv(config.GddMemJoinRetry.String(), "2")
+	os.Setenv(config.GddMemJoinBackoff.String(), "10ms")
+	os.Setenv(config.GddMemRejoinInterval.String(), "100ms")
+	return NewNode()
+}
+
+func TestNewNode_MultipleSeeds(t *testing.T) {
+	seed, err := newTestNode("seed", 0, "")
+	if err != nil {
+		t.Fatalf("NewNode() error = %+v", err)
+	}
+	defer seed.Shutdown()
+
+	// the first seed is unreachable, joining should still succeed through the second one
+	node, err := newTestNode("node", 0, fmt.Sprintf("127.0.0.1:1,127.0.0.1:%d", seed.memberNode.Port))
+	if err != nil {
+		t.Fatalf("NewNode() error = %+v", err)
+	}
+	defer node.Shutdown()
+
+	if got := node.NumNodes(); got != 2 {
+		t.Errorf("NumNodes() = %d, want 2", got)
+	}
+}
+
+func TestNewNode_JoinFailed(t *testing.T) {
+	if _, err := newTestNode("lonely", 0, "127.0.0.1:1"); err == nil {
+		t.Error("NewNode() error = nil, want error")
+	}
+}
+
+func TestNewNode_Encrypted(t *testing.T) {
+	defer os.Unsetenv(config.GddMemKey.String())
+	os.Setenv(config.GddMemKey.String(), "T9jncgl9mbLus+baTTa7q7nPSUrXwbDi2dhbtqir37s=")
+	seed, err := newTestNode("seed", 0, "")
+	if err != nil {
+		t.Fatalf("NewNode() error = %+v", err)
+	}
+	defer seed.Shutdown()
+	seedAddr := fmt.Sprintf("127.0.0.1:%d", seed.memberNode.Port)
+
+	node, err := newTestNode("node", 0, seedAddr)
+	if err != nil {
+		t.Fatalf("NewNode() error = %+v", err)
+	}
+	defer node.Shutdown()
+
+	// nodes without the key must not be able to join
+	os.Setenv(config.GddMemKey.String(), "")
+	if _, err = newTestNode("plain", 0, seedAddr); err == nil {
+		t.Error("NewNode() without key error = nil, want error")
+	}
+	os.Setenv(config.GddMemKey.String(), "gePi6zB9YeaiBdM6PWySXA==")
+	if _, err = newTestNode("stranger", 0, seedAddr); err == nil {
+		t.Error("NewNode() with wrong key error = nil, want error")
+	}
+	if got := seed.NumNodes(); got != 2 {
+		t.Errorf("NumNodes() = %d, want 2", got)
+	}
+}
+
+func TestNode_Rejoin(t *testing.T) {
+	seed, err := newTestNode("seed", 0, "")
+	if err != nil {
+		t.Fatalf("NewNode() error = %+v", err)
+	}
+	port := int(seed.memberNode.Port)
+
+	node, err := newTestNode("node", 0, fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		t.Fatalf("NewNode() error = %+v", err)
+	}
+	defer node.Shutdown()
+
+	// restart the seed on the same port, the restarted seed knows nobody,
+	// so node should find itself isolated and rejoin
+	seed.Shutdown()
+	restarted, err := newTestNode("seed-restarted", port, "")
+	if err != nil {
+		t.Fatalf("NewNode() error = %+v", err)
+	}
+	defer restarted.Shutdown()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if restarted.NumNodes() == 2 {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Errorf("NumNodes() = %d, want 2", restarted.NumNodes())
+}
@@ -0,0 +1,109 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/unionj-cloud/go-doudou/stringutils"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	dnsPrefix    = "dns+"
+	dnsSrvPrefix = "dnssrv+"
+
+	defaultJoinRetry      = 3
+	defaultJoinBackoff    = time.Second
+	maxJoinBackoff        = 30 * time.Second
+	defaultRejoinInterval = 30 * time.Second
+)
+
+// seedResolver is implemented by *net.Resolver. It is an interface so that tests can stub dns lookups.
+type seedResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+func parseSeeds(seed string) []string {
+	var seeds []string
+	for _, item := range strings.Split(seed, ",") {
+		item = strings.TrimSpace(item)
+		if stringutils.IsNotEmpty(item) {
+			seeds = append(seeds, item)
+		}
+	}
+	return seeds
+}
+
+// resolveSeeds expands dns+ and dnssrv+ seeds into host:port addresses. Plain seeds are returned as they are,
+// memberlist will resolve them by itself. An error is returned only when no address could be resolved at all.
+func resolveSeeds(ctx context.Context, resolver seedResolver, seeds []string) ([]string, error) {
+	var (
+		addrs []string
+		errs  []string
+	)
+	for _, seed := range seeds {
+		switch {
+		case strings.HasPrefix(seed, dnsSrvPrefix):
+			_, srvs, err := resolver.LookupSRV(ctx, "", "", strings.TrimPrefix(seed, dnsSrvPrefix))
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			for _, srv := range srvs {
+				addrs = append(addrs, net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port))))
+			}
+		case strings.HasPrefix(seed, dnsPrefix):
+			host, port, err := net.SplitHostPort(strings.TrimPrefix(seed, dnsPrefix))
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			ips, err := resolver.LookupIPAddr(ctx, host)
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			for _, ip := range ips {
+				addrs = append(addrs, net.JoinHostPort(ip.String(), port))
+			}
+		default:
+			addrs = append(addrs, seed)
+		}
+	}
+	if len(addrs) == 0 && len(errs) > 0 {
+		return nil, errors.Errorf("failed to resolve seeds: %s", strings.Join(errs, "; "))
+	}
+	return addrs, nil
+}
+
+// joinWithRetry calls join until it succeeds or attempts are used up. Backoff is doubled after each failure
+// and capped by maxJoinBackoff.
+func joinWithRetry(join func() (int, error), attempts int, backoff time.Duration) (int, error) {
+	var (
+		n   int
+		err error
+	)
+	if attempts < 1 {
+		attempts = 1
+	}
+	for i := 1; i <= attempts; i++ {
+		if n, err = join(); err == nil {
+			return n, nil
+		}
+		if i == attempts {
+			break
+		}
+		logrus.Warnf("Join attempt %d/%d failed: %s, retry in %s", i, attempts, err, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > maxJoinBackoff {
+			backoff = maxJoinBackoff
+		}
+	}
+	return n, errors.Wrap(err, fmt.Sprintf("failed to join cluster after %d attempts", attempts))
+}
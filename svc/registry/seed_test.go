@@ -0,0 +1,177 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type stubResolver struct {
+	ips  map[string][]net.IPAddr
+	srvs map[string][]*net.SRV
+}
+
+func (s stubResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if ips, ok := s.ips[host]; ok {
+		return ips, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func (s stubResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	if srvs, ok := s.srvs[name]; ok {
+		return name, srvs, nil
+	}
+	return "", nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func TestParseSeeds(t *testing.T) {
+	tests := []struct {
+		name string
+		seed string
+		want []string
+	}{
+		{
+			name: "1",
+			seed: "",
+			want: nil,
+		},
+		{
+			name: "2",
+			seed: "192.168.1.6:7946",
+			want: []string{"192.168.1.6:7946"},
+		},
+		{
+			name: "3",
+			seed: " 192.168.1.6:7946, ,dns+seed.local:7946,dnssrv+_gossip._tcp.seed.local ",
+			want: []string{"192.168.1.6:7946", "dns+seed.local:7946", "dnssrv+_gossip._tcp.seed.local"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseSeeds(tt.seed); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSeeds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveSeeds(t *testing.T) {
+	resolver := stubResolver{
+		ips: map[string][]net.IPAddr{
+			"seed.local": {
+				{IP: net.ParseIP("10.0.0.1")},
+				{IP: net.ParseIP("10.0.0.2")},
+			},
+		},
+		srvs: map[string][]*net.SRV{
+			"_gossip._tcp.seed.local": {
+				{Target: "node1.seed.local.", Port: 7946},
+				{Target: "node2.seed.local.", Port: 7947},
+			},
+		},
+	}
+	tests := []struct {
+		name    string
+		seeds   []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "plain",
+			seeds: []string{"192.168.1.6:7946", "seed.local:7946"},
+			want:  []string{"192.168.1.6:7946", "seed.local:7946"},
+		},
+		{
+			name:  "a",
+			seeds: []string{"dns+seed.local:7946"},
+			want:  []string{"10.0.0.1:7946", "10.0.0.2:7946"},
+		},
+		{
+			name:  "srv",
+			seeds: []string{"dnssrv+_gossip._tcp.seed.local"},
+			want:  []string{"node1.seed.local:7946", "node2.seed.local:7947"},
+		},
+		{
+			name:  "partial failure",
+			seeds: []string{"dns+unknown.local:7946", "dns+seed.local:7946"},
+			want:  []string{"10.0.0.1:7946", "10.0.0.2:7946"},
+		},
+		{
+			name:    "all failed",
+			seeds:   []string{"dns+unknown.local:7946", "dnssrv+_gossip._tcp.unknown.local", "dns+seed.local"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveSeeds(context.Background(), resolver, tt.seeds)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("resolveSeeds() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveSeeds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJoinWithRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		failTimes int
+		attempts  int
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "first attempt",
+			failTimes: 0,
+			attempts:  3,
+			wantCalls: 1,
+		},
+		{
+			name:      "third attempt",
+			failTimes: 2,
+			attempts:  3,
+			wantCalls: 3,
+		},
+		{
+			name:      "exhausted",
+			failTimes: 5,
+			attempts:  3,
+			wantCalls: 3,
+			wantErr:   true,
+		},
+		{
+			name:      "at least once",
+			failTimes: 5,
+			attempts:  0,
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			join := func() (int, error) {
+				calls++
+				if calls <= tt.failTimes {
+					return 0, errors.New("connection refused")
+				}
+				return 1, nil
+			}
+			_, err := joinWithRetry(join, tt.attempts, time.Millisecond)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("joinWithRetry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("joinWithRetry() calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"github.com/Jeffail/gabs/v2"
 	"github.com/iancoleman/strcase"
+	"github.com/joho/godotenv"
+	"github.com/olekukonko/tablewriter"
 	"github.com/pkg/errors"
 	"github.com/radovskyb/watcher"
 	"github.com/sirupsen/logrus"
@@ -14,7 +16,10 @@ import (
 	v3 "github.com/unionj-cloud/go-doudou/openapi/v3"
 	"github.com/unionj-cloud/go-doudou/openapi/v3/codegen/client"
 	"github.com/unionj-cloud/go-doudou/stringutils"
+	ddconfig "github.com/unionj-cloud/go-doudou/svc/config"
 	"github.com/unionj-cloud/go-doudou/svc/internal/codegen"
+	"github.com/unionj-cloud/go-doudou/svc/registry"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -48,6 +53,9 @@ type Svc struct {
 
 	Watch bool
 
+	// Seed overrides GDD_SEED for joining the cluster as an observer
+	Seed string
+
 	*exec.Cmd
 	RestartSig chan int
 }
@@ -294,6 +302,49 @@ func (receiver Svc) GenClient() {
 	}
 }
 
+// Members joins the cluster as an observer node and prints members it knows
+func (receiver Svc) Members() {
+	envfile := filepath.Join(receiver.Dir, ".env")
+	if _, err := os.Stat(envfile); err == nil {
+		if err = godotenv.Load(envfile); err != nil {
+			panic(err)
+		}
+	}
+	if stringutils.IsNotEmpty(receiver.Seed) {
+		os.Setenv(ddconfig.GddSeed.String(), receiver.Seed)
+	}
+	observer, err := registry.NewObserver()
+	if err != nil {
+		panic(err)
+	}
+	defer observer.Shutdown()
+	printMembers(observer.Members())
+}
+
+func printMembers(members []registry.Member) {
+	logrus.Infoln("================ Cluster Members ================")
+	data := [][]string{}
+	for _, m := range members {
+		if m.Local {
+			continue
+		}
+		data = append(data, []string{m.Name, net.JoinHostPort(m.Addr, fmt.Sprint(m.Port)), m.State, m.Service, m.BaseUrl})
+	}
+
+	tableString := &strings.Builder{}
+	table := tablewriter.NewWriter(tableString)
+	table.SetHeader([]string{"Name", "Address", "State", "Service", "BaseUrl"})
+	for _, v := range data {
+		table.Append(v)
+	}
+	table.Render() // Send output
+	rows := strings.Split(strings.TrimSpace(tableString.String()), "\n")
+	for _, row := range rows {
+		logrus.Infoln(row)
+	}
+	logrus.Infoln("=================================================")
+}
+
 func (receiver Svc) run() *exec.Cmd {
 	cmd := exec.Command("go", "build", "-o", "cmd/cmd", "cmd/main.go")
 	cmd.Stdout = os.Stdout
@@ -10,7 +10,11 @@ import (
 	"github.com/unionj-cloud/go-doudou/esutils"
 	"github.com/unionj-cloud/go-doudou/logutils"
 	"github.com/unionj-cloud/go-doudou/pathutils"
+	ddconfig "github.com/unionj-cloud/go-doudou/svc/config"
+	"github.com/unionj-cloud/go-doudou/svc/registry"
 	"github.com/unionj-cloud/go-doudou/test"
+	"io/ioutil"
+	"net"
 	"os"
 	"testing"
 )
@@ -257,3 +261,40 @@ func Test_validateDataType_shouldpanic(t *testing.T) {
 		validateDataType(pathutils.Abs("testfiles1"))
 	})
 }
+
+func TestSvc_Members(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	memport := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	// fail fast when seed is unreachable instead of waiting through default join retries
+	os.Setenv(ddconfig.GddMemJoinRetry.String(), "1")
+	os.Setenv(ddconfig.GddMemJoinBackoff.String(), "10ms")
+	os.Setenv(ddconfig.GddName.String(), "members")
+	os.Setenv(ddconfig.GddMemPort.String(), fmt.Sprint(memport))
+	defer os.Unsetenv(ddconfig.GddMemJoinRetry.String())
+	defer os.Unsetenv(ddconfig.GddMemJoinBackoff.String())
+	defer os.Unsetenv(ddconfig.GddName.String())
+	defer os.Unsetenv(ddconfig.GddMemPort.String())
+	defer os.Unsetenv(ddconfig.GddSeed.String())
+	node, err := registry.NewNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Shutdown()
+
+	dir, err := ioutil.TempDir("", "members")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	assert.NotPanics(t, func() {
+		Svc{Dir: dir, Seed: fmt.Sprintf("127.0.0.1:%d", memport)}.Members()
+	})
+	assert.Panics(t, func() {
+		Svc{Dir: dir, Seed: "127.0.0.1:1"}.Members()
+	})
+}
@@ -3,6 +3,7 @@ package templateutils
 import (
 	"bytes"
 	"github.com/unionj-cloud/go-doudou/constants"
+	"reflect"
 	"strings"
 	"text/template"
 	"time"
@@ -35,3 +36,12 @@ func TrimSuffix(suffix, v string) string {
 func HasPrefix(v, prefix string) bool {
 	return strings.HasPrefix(strings.TrimSpace(v), prefix)
 }
+
+// IsNil reports whether v is nil or a nil pointer
+func IsNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
@@ -34,10 +34,9 @@ func StringBlock(tmpl string, block string, data interface{}) (string, error) {
 	return strings.TrimSpace(sqlBuf.String()), nil
 }
 
-func StringBlockMysql(tmpl string, block string, data interface{}) (string, error) {
+// ParseMysql parses sql template file with the functions available to blocks executed by StringBlockMysql
+func ParseMysql(tmpl string) (*template.Template, error) {
 	var (
-		sqlBuf  bytes.Buffer
-		err     error
 		tpl     *template.Template
 		funcMap map[string]interface{}
 	)
@@ -47,12 +46,45 @@ func StringBlockMysql(tmpl string, block string, data interface{}) (string, erro
 	funcMap["BoolToInt"] = BoolToInt
 	funcMap["Eval"] = Eval(tpl)
 	funcMap["TrimSuffix"] = TrimSuffix
-	funcMap["isNil"] = func(t interface{}) bool {
-		return t != nil
+	funcMap["isNil"] = IsNil
+	// arg binds values by StringBlockMysqlArgs
+	funcMap["arg"] = func(v interface{}) string {
+		return "?"
 	}
-	tpl = template.Must(tpl.Funcs(funcMap).ParseFiles(tmpl))
+	return tpl.Funcs(funcMap).ParseFiles(tmpl)
+}
+
+func StringBlockMysql(tmpl string, block string, data interface{}) (string, error) {
+	var (
+		sqlBuf bytes.Buffer
+		err    error
+		tpl    *template.Template
+	)
+	tpl = template.Must(ParseMysql(tmpl))
 	if err = tpl.ExecuteTemplate(&sqlBuf, block, data); err != nil {
 		return "", errors.Wrap(err, "error returned from calling tpl.ExecuteTemplate")
 	}
 	return strings.TrimSpace(sqlBuf.String()), nil
 }
+
+// StringBlockMysqlArgs executes block like StringBlockMysql, values passed to arg function in the block are replaced
+// by ? placeholders and returned as args in order, e.g. `name`={{arg .Name}}
+func StringBlockMysqlArgs(tmpl string, block string, data interface{}) (string, []interface{}, error) {
+	var (
+		sqlBuf bytes.Buffer
+		err    error
+		tpl    *template.Template
+		args   []interface{}
+	)
+	tpl = template.Must(ParseMysql(tmpl))
+	tpl.Funcs(map[string]interface{}{
+		"arg": func(v interface{}) string {
+			args = append(args, v)
+			return "?"
+		},
+	})
+	if err = tpl.ExecuteTemplate(&sqlBuf, block, data); err != nil {
+		return "", nil, errors.Wrap(err, "error returned from calling tpl.ExecuteTemplate")
+	}
+	return strings.TrimSpace(sqlBuf.String()), args, nil
+}